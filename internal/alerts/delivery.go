@@ -0,0 +1,44 @@
+package alerts
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+)
+
+// deliveryStatus records the outcome of a notifier's most recent send
+type deliveryStatus struct {
+	mu      sync.Mutex
+	lastErr error
+}
+
+func (d *deliveryStatus) record(err error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lastErr = err
+	return err
+}
+
+// redactURL drops the URL from an HTTP client error, keeping only its host.
+// Slack and webhook URLs embed secrets, and these errors reach logs and
+// /healthz.
+func redactURL(err error, target string) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
+		return err
+	}
+	host := "endpoint"
+	if u, parseErr := url.Parse(target); parseErr == nil && u.Host != "" {
+		host = u.Host
+	}
+	return fmt.Errorf("%s %s: %w", urlErr.Op, host, urlErr.Err)
+}
+
+// health returns the error of the last send, or nil if it succeeded or
+// nothing has been sent yet
+func (d *deliveryStatus) health() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastErr
+}
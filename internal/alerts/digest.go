@@ -0,0 +1,114 @@
+package alerts
+
+import (
+	"sort"
+	"time"
+)
+
+// digestTopApps is how many applications a digest lists
+const digestTopApps = 5
+
+// Digest summarizes a period of activity for the periodic digest message
+type Digest struct {
+	Target          string
+	Start           time.Time
+	End             time.Time
+	IdleWarnings    int
+	IdleCriticals   int
+	IdleEmergencies int
+	Terminations    int
+	PollFailures    int
+	PeakPoolPercent float64
+	PeakPoolAt      time.Time
+	TopApps         []AppCount // Applications with the most incidents, most first
+}
+
+// AppCount is the number of incidents for one application. An incident is
+// one session, however many severities it went through.
+type AppCount struct {
+	Application string `json:"application"`
+	Incidents   int    `json:"incidents"`
+}
+
+// DigestCollector accumulates activity between digests. It is used from the
+// poll loop only and is not safe for concurrent use.
+type DigestCollector struct {
+	Interval time.Duration
+
+	current  Digest
+	apps     map[string]int
+	sessions map[string]bool // Sessions already counted in apps
+}
+
+// NewDigestCollector starts collecting a digest period at now
+func NewDigestCollector(target string, interval time.Duration, now time.Time) *DigestCollector {
+	return &DigestCollector{
+		Interval: interval,
+		current:  Digest{Target: target, Start: now},
+		apps:     make(map[string]int),
+		sessions: make(map[string]bool),
+	}
+}
+
+// RecordIdle counts an idle transaction alert. The session is counted as
+// an incident of its application once.
+func (d *DigestCollector) RecordIdle(severity, app, sessionID string) {
+	switch severity {
+	case SeverityCritical:
+		d.current.IdleCriticals++
+	case SeverityWarning:
+		d.current.IdleWarnings++
+	case SeverityEmergency:
+		d.current.IdleEmergencies++
+	}
+	if !d.sessions[sessionID] {
+		d.sessions[sessionID] = true
+		d.apps[app]++
+	}
+}
+
+// RecordTermination counts a terminated connection
+func (d *DigestCollector) RecordTermination() {
+	d.current.Terminations++
+}
+
+// RecordPollFailure counts a failed poll
+func (d *DigestCollector) RecordPollFailure() {
+	d.current.PollFailures++
+}
+
+// RecordPool tracks peak pool usage
+func (d *DigestCollector) RecordPool(percent float64, now time.Time) {
+	if percent > d.current.PeakPoolPercent {
+		d.current.PeakPoolPercent = percent
+		d.current.PeakPoolAt = now
+	}
+}
+
+// Take returns the finished digest and starts a new period if Interval has
+// passed since the current one started
+func (d *DigestCollector) Take(now time.Time) (Digest, bool) {
+	if now.Sub(d.current.Start) < d.Interval {
+		return Digest{}, false
+	}
+
+	digest := d.current
+	digest.End = now
+	for app, n := range d.apps {
+		digest.TopApps = append(digest.TopApps, AppCount{Application: app, Incidents: n})
+	}
+	sort.Slice(digest.TopApps, func(i, j int) bool {
+		if digest.TopApps[i].Incidents != digest.TopApps[j].Incidents {
+			return digest.TopApps[i].Incidents > digest.TopApps[j].Incidents
+		}
+		return digest.TopApps[i].Application < digest.TopApps[j].Application
+	})
+	if len(digest.TopApps) > digestTopApps {
+		digest.TopApps = digest.TopApps[:digestTopApps]
+	}
+
+	d.current = Digest{Target: digest.Target, Start: now}
+	d.apps = make(map[string]int)
+	d.sessions = make(map[string]bool)
+	return digest, true
+}
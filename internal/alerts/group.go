@@ -0,0 +1,175 @@
+package alerts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/owners"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// maxGroupLines caps the connections listed in one grouped Slack message
+const maxGroupLines = 20
+
+// ConnectionAlert is one per-connection alert
+type ConnectionAlert struct {
+	Kind        string
+	Severity    string
+	Target      string
+	PID         int
+	SessionID   string
+	Application string
+	User        string
+	Database    string
+	Duration    time.Duration
+	Query       string
+	Fingerprint string
+	Reason      string       // Terminations only
+	Owner       *owners.Team // Nil when no team owns the connection
+	Reminder    int          // Repeats of an alert already sent are numbered from 1
+}
+
+// Attributes returns what routes match on for this alert. Terminations
+// route as info and resolutions as resolved.
+func (a ConnectionAlert) Attributes() Attributes {
+	severity := a.Severity
+	if severity == "" {
+		severity = SeverityInfo
+		if a.Kind == KindResolved {
+			severity = SeverityResolved
+		}
+	}
+	return Attributes{
+		Kind:        a.Kind,
+		Severity:    severity,
+		Application: a.Application,
+		User:        a.User,
+		Database:    a.Database,
+		Target:      a.Target,
+		PID:         a.PID,
+		SessionID:   a.SessionID,
+		Fingerprint: a.Fingerprint,
+	}
+}
+
+// AlertGroup is a batch of connection alerts with the same kind, target,
+// severity, application, owner and receivers
+type AlertGroup struct {
+	Kind        string
+	Target      string
+	Severity    string
+	Application string
+	Owner       *owners.Team
+	Receivers   []Receiver
+	Alerts      []ConnectionAlert
+	opened      time.Time
+}
+
+type groupKey struct {
+	kind, target, severity, app, team, receivers string
+}
+
+// Grouper batches connection alerts so a burst, such as a deploy leaking
+// many transactions, becomes one message per group instead of one per PID
+type Grouper struct {
+	Window time.Duration // How long a group collects alerts; 0 flushes every poll
+
+	groups map[groupKey]*AlertGroup
+	order  []groupKey
+}
+
+// NewGrouper creates a grouper
+func NewGrouper(window time.Duration) *Grouper {
+	return &Grouper{
+		Window: window,
+		groups: make(map[groupKey]*AlertGroup),
+	}
+}
+
+// Add queues an alert, routed to receivers, into its group. Alerts routed
+// differently, e.g. by user, are never grouped together.
+func (g *Grouper) Add(a ConnectionAlert, receivers []Receiver, now time.Time) {
+	names := make([]string, len(receivers))
+	for i, r := range receivers {
+		names[i] = r.Name
+	}
+	team := ""
+	if a.Owner != nil {
+		team = a.Owner.Name
+	}
+	key := groupKey{a.Kind, a.Target, a.Severity, a.Application, team, strings.Join(names, ",")}
+	group, ok := g.groups[key]
+	if !ok {
+		group = &AlertGroup{
+			Kind:        a.Kind,
+			Target:      a.Target,
+			Severity:    a.Severity,
+			Application: a.Application,
+			Owner:       a.Owner,
+			Receivers:   receivers,
+			opened:      now,
+		}
+		g.groups[key] = group
+		g.order = append(g.order, key)
+	}
+	group.Alerts = append(group.Alerts, a)
+}
+
+// Flush removes and returns the groups whose window has passed, in the order
+// they were opened
+func (g *Grouper) Flush(now time.Time) []*AlertGroup {
+	var ready []*AlertGroup
+	kept := g.order[:0]
+	for _, key := range g.order {
+		group := g.groups[key]
+		if now.Sub(group.opened) >= g.Window {
+			ready = append(ready, group)
+			delete(g.groups, key)
+		} else {
+			kept = append(kept, key)
+		}
+	}
+	g.order = kept
+	return ready
+}
+
+// Title describes the group, e.g. "12 Idle Transactions [critical]"
+func (a *AlertGroup) Title() string {
+	var what string
+	switch a.Kind {
+	case KindIdleTransaction:
+		what = "Idle Transactions"
+	case KindTerminated:
+		what = "Connections Terminated"
+	case KindResolved:
+		what = "Idle Transactions Resolved"
+	default:
+		what = a.Kind
+	}
+	title := fmt.Sprintf("%d %s", len(a.Alerts), what)
+	if a.Severity != "" {
+		title += fmt.Sprintf(" [%s]", a.Severity)
+	}
+	return title
+}
+
+// lines lists the group's connections, one per line, capped at maxLines
+func (a *AlertGroup) lines(maxLines int) string {
+	var b strings.Builder
+	for i, alert := range a.Alerts {
+		if i == maxLines {
+			fmt.Fprintf(&b, "... and %d more\n", len(a.Alerts)-maxLines)
+			break
+		}
+		fmt.Fprintf(&b, "PID %d - %s", alert.PID, util.FormatDuration(alert.Duration))
+		if alert.Fingerprint != "" {
+			fmt.Fprintf(&b, " - %s", alert.Fingerprint)
+		}
+		if alert.Reminder > 0 {
+			fmt.Fprintf(&b, " - reminder #%d", alert.Reminder)
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,172 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGrouper(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	idle := func(pid int, severity, app string) ConnectionAlert {
+		return ConnectionAlert{Kind: KindIdleTransaction, Severity: severity, Target: "db/orders", PID: pid, Application: app}
+	}
+
+	t.Run("per poll", func(t *testing.T) {
+		g := NewGrouper(0)
+		g.Add(idle(1, SeverityWarning, "api"), nil, start)
+		g.Add(idle(2, SeverityWarning, "api"), nil, start)
+		g.Add(idle(3, SeverityCritical, "api"), nil, start)
+		g.Add(idle(4, SeverityWarning, "worker"), nil, start)
+
+		groups := g.Flush(start)
+		if len(groups) != 3 {
+			t.Fatalf("got %d groups, want 3", len(groups))
+		}
+		if n := len(groups[0].Alerts); n != 2 {
+			t.Errorf("first group has %d alerts, want 2", n)
+		}
+		if got := groups[0].Title(); got != "2 Idle Transactions [warning]" {
+			t.Errorf("Title() = %q", got)
+		}
+		if len(g.Flush(start)) != 0 {
+			t.Error("second flush should be empty")
+		}
+	})
+
+	t.Run("split by receivers", func(t *testing.T) {
+		g := NewGrouper(0)
+		team := []Receiver{{Name: "team"}}
+		pager := []Receiver{{Name: "pager"}, {Name: "team"}}
+		g.Add(idle(1, SeverityWarning, "api"), team, start)
+		g.Add(idle(2, SeverityWarning, "api"), pager, start)
+		g.Add(idle(3, SeverityWarning, "api"), team, start)
+
+		groups := g.Flush(start)
+		if len(groups) != 2 || len(groups[0].Alerts) != 2 || groups[1].Receivers[0].Name != "pager" {
+			t.Fatalf("expected team and pager groups, got %+v", groups)
+		}
+	})
+
+	t.Run("window", func(t *testing.T) {
+		g := NewGrouper(time.Minute)
+		g.Add(idle(1, SeverityWarning, "api"), nil, start)
+		g.Add(idle(2, SeverityWarning, "api"), nil, start.Add(30*time.Second))
+		g.Add(idle(3, SeverityWarning, "worker"), nil, start.Add(45*time.Second))
+
+		if groups := g.Flush(start.Add(50 * time.Second)); len(groups) != 0 {
+			t.Fatalf("flushed %d groups before the window passed", len(groups))
+		}
+		groups := g.Flush(start.Add(time.Minute))
+		if len(groups) != 1 || len(groups[0].Alerts) != 2 {
+			t.Fatalf("expected the api group with 2 alerts, got %+v", groups)
+		}
+		groups = g.Flush(start.Add(2 * time.Minute))
+		if len(groups) != 1 || groups[0].Application != "worker" {
+			t.Fatalf("expected the worker group, got %+v", groups)
+		}
+	})
+}
+
+func TestAlertGroupLines(t *testing.T) {
+	g := &AlertGroup{Kind: KindIdleTransaction}
+	for pid := 1; pid <= 25; pid++ {
+		g.Alerts = append(g.Alerts, ConnectionAlert{PID: pid, Duration: time.Minute, Fingerprint: "abcd"})
+	}
+	lines := strings.Split(g.lines(maxGroupLines), "\n")
+	if len(lines) != maxGroupLines+1 {
+		t.Fatalf("got %d lines, want %d", len(lines), maxGroupLines+1)
+	}
+	if lines[0] != "PID 1 - 1m 0s - abcd" {
+		t.Errorf("first line = %q", lines[0])
+	}
+	if lines[maxGroupLines] != "... and 5 more" {
+		t.Errorf("last line = %q", lines[maxGroupLines])
+	}
+}
+
+func TestWebhookClient_GroupAlert(t *testing.T) {
+	var receivedPayload WebhookPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &receivedPayload); err != nil {
+			t.Errorf("failed to unmarshal payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(server.URL, "POST", nil)
+	err := client.GroupAlert(&AlertGroup{
+		Kind:        KindIdleTransaction,
+		Target:      "db/orders",
+		Severity:    SeverityCritical,
+		Application: "api",
+		Alerts: []ConnectionAlert{
+			{PID: 1, Duration: time.Minute, Fingerprint: "aaaa"},
+			{PID: 2, Duration: 2 * time.Minute, Fingerprint: "bbbb"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("GroupAlert() error = %v", err)
+	}
+
+	if receivedPayload.Event != "idle_transaction_group" {
+		t.Errorf("Event = %q, want %q", receivedPayload.Event, "idle_transaction_group")
+	}
+	if receivedPayload.Data["count"] != float64(2) {
+		t.Errorf("count = %v, want 2", receivedPayload.Data["count"])
+	}
+	conns, ok := receivedPayload.Data["connections"].([]interface{})
+	if !ok || len(conns) != 2 {
+		t.Fatalf("connections = %v, want 2 entries", receivedPayload.Data["connections"])
+	}
+}
+
+func TestDigestCollector(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	d := NewDigestCollector("db/orders", time.Hour, start)
+
+	d.RecordIdle(SeverityWarning, "api", "1-1")
+	d.RecordIdle(SeverityCritical, "api", "1-1") // Same session, one incident
+	d.RecordIdle(SeverityWarning, "api", "2-1")
+	d.RecordIdle(SeverityWarning, "worker", "3-1")
+	d.RecordIdle(SeverityEmergency, "worker", "3-1")
+	d.RecordTermination()
+	d.RecordPollFailure()
+	d.RecordPool(60, start.Add(5*time.Minute))
+	d.RecordPool(82, start.Add(10*time.Minute))
+	d.RecordPool(70, start.Add(15*time.Minute))
+
+	if _, ok := d.Take(start.Add(59 * time.Minute)); ok {
+		t.Fatal("digest taken before the interval passed")
+	}
+	digest, ok := d.Take(start.Add(time.Hour))
+	if !ok {
+		t.Fatal("digest not taken after the interval")
+	}
+
+	if digest.IdleWarnings != 3 || digest.IdleCriticals != 1 || digest.IdleEmergencies != 1 {
+		t.Errorf("idle = %d warning, %d critical, %d emergency, want 3, 1 and 1",
+			digest.IdleWarnings, digest.IdleCriticals, digest.IdleEmergencies)
+	}
+	if digest.Terminations != 1 || digest.PollFailures != 1 {
+		t.Errorf("terminations = %d, poll failures = %d, want 1 and 1", digest.Terminations, digest.PollFailures)
+	}
+	if digest.PeakPoolPercent != 82 || !digest.PeakPoolAt.Equal(start.Add(10*time.Minute)) {
+		t.Errorf("peak = %.0f%% at %s", digest.PeakPoolPercent, digest.PeakPoolAt)
+	}
+	if len(digest.TopApps) != 2 || digest.TopApps[0] != (AppCount{"api", 2}) {
+		t.Errorf("TopApps = %+v", digest.TopApps)
+	}
+
+	next, ok := d.Take(start.Add(2 * time.Hour))
+	if !ok || next.IdleWarnings != 0 || next.PeakPoolPercent != 0 {
+		t.Errorf("next digest should start empty, got %+v", next)
+	}
+}
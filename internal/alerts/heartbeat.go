@@ -0,0 +1,150 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Heartbeat statuses
+const (
+	HeartbeatOK   = "ok"
+	HeartbeatFail = "fail"
+)
+
+// HeartbeatTarget is a dead man's switch endpoint, e.g. a Healthchecks.io or
+// Cronitor check
+type HeartbeatTarget struct {
+	URL     string // Pinged after each successful poll
+	FailURL string // Pinged when a poll fails; failures are not reported if empty
+	Method  string // POST (default) or GET
+}
+
+// HeartbeatSummary is the payload sent with each ping
+type HeartbeatSummary struct {
+	Status           string  `json:"status"` // "ok" or "fail"
+	Timestamp        string  `json:"timestamp"`
+	UsedConnections  int     `json:"used_connections,omitempty"`
+	Capacity         int     `json:"capacity,omitempty"`
+	UsagePercent     float64 `json:"usage_percent,omitempty"`
+	PoolSeverity     string  `json:"pool_severity,omitempty"`
+	IdleTransactions int     `json:"idle_transactions"`
+	FailedPolls      int     `json:"failed_polls,omitempty"`
+	ErrorClass       string  `json:"error_class,omitempty"`
+	Error            string  `json:"error,omitempty"`
+}
+
+// HeartbeatClient pings external monitors so they notice when pguard goes quiet
+type HeartbeatClient struct {
+	Targets     []HeartbeatTarget
+	MinInterval time.Duration // Minimum time between pings with the same status
+	HTTPClient  *http.Client
+
+	mu         sync.Mutex
+	lastPing   time.Time
+	lastStatus string
+
+	status deliveryStatus
+}
+
+// NewHeartbeatClient creates a new heartbeat client
+func NewHeartbeatClient(targets []HeartbeatTarget, minInterval time.Duration) *HeartbeatClient {
+	return &HeartbeatClient{
+		Targets:     targets,
+		MinInterval: minInterval,
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// due reports whether a ping with this status should be sent now. A change of
+// status is always sent; repeats wait for MinInterval.
+func (h *HeartbeatClient) due(status string, now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if status == h.lastStatus && now.Sub(h.lastPing) < h.MinInterval {
+		return false
+	}
+	h.lastPing = now
+	h.lastStatus = status
+	return true
+}
+
+// Beat pings every target: the URL when summary.Status is ok, the FailURL
+// otherwise. Pings within MinInterval of the last one with the same status are
+// skipped.
+func (h *HeartbeatClient) Beat(summary HeartbeatSummary) error {
+	if !h.due(summary.Status, time.Now()) {
+		return nil
+	}
+
+	payload, err := json.Marshal(summary)
+	if err != nil {
+		return fmt.Errorf("marshaling heartbeat: %w", err)
+	}
+
+	var errs []error
+	for _, target := range h.Targets {
+		pingURL := target.URL
+		if summary.Status != HeartbeatOK {
+			pingURL = target.FailURL
+		}
+		if pingURL == "" {
+			continue
+		}
+		if err := h.ping(target.Method, pingURL, payload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return h.status.record(errors.Join(errs...))
+}
+
+// Health returns the error from the most recent ping, nil if it succeeded
+func (h *HeartbeatClient) Health() error {
+	return h.status.health()
+}
+
+// ping sends one heartbeat. Check URLs usually embed a secret, so errors
+// name only the host.
+func (h *HeartbeatClient) ping(method, target string, payload []byte) error {
+	if method == "" {
+		method = http.MethodPost
+	}
+	host := target
+	if u, err := url.Parse(target); err == nil {
+		host = u.Host
+	}
+
+	var body io.Reader
+	if method != http.MethodGet {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return fmt.Errorf("invalid heartbeat URL for %s", host)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "pguard")
+
+	resp, err := h.HTTPClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("pinging %s: %w", host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("heartbeat to %s returned status %d", host, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeartbeatClient_Beat(t *testing.T) {
+	var paths []string
+	var lastSummary HeartbeatSummary
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &lastSummary); err != nil {
+			t.Errorf("failed to unmarshal summary: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewHeartbeatClient([]HeartbeatTarget{
+		{URL: server.URL + "/check", FailURL: server.URL + "/check/fail"},
+		{URL: server.URL + "/other"}, // No fail URL
+	}, time.Hour)
+
+	if err := client.Beat(HeartbeatSummary{Status: HeartbeatOK, IdleTransactions: 2}); err != nil {
+		t.Fatalf("Beat(ok) error = %v", err)
+	}
+	if got := strings.Join(paths, ","); got != "/check,/other" {
+		t.Errorf("ok pings = %s, want /check,/other", got)
+	}
+	if lastSummary.IdleTransactions != 2 {
+		t.Errorf("IdleTransactions = %d, want 2", lastSummary.IdleTransactions)
+	}
+
+	// Same status within MinInterval is skipped
+	paths = nil
+	if err := client.Beat(HeartbeatSummary{Status: HeartbeatOK}); err != nil {
+		t.Fatalf("Beat(ok) error = %v", err)
+	}
+	if len(paths) != 0 {
+		t.Errorf("repeated ok ping sent to %v, want none", paths)
+	}
+
+	// A status change is sent right away, only to fail URLs
+	if err := client.Beat(HeartbeatSummary{Status: HeartbeatFail, ErrorClass: "network"}); err != nil {
+		t.Fatalf("Beat(fail) error = %v", err)
+	}
+	if got := strings.Join(paths, ","); got != "/check/fail" {
+		t.Errorf("fail pings = %s, want /check/fail", got)
+	}
+	if lastSummary.ErrorClass != "network" {
+		t.Errorf("ErrorClass = %q, want %q", lastSummary.ErrorClass, "network")
+	}
+}
+
+func TestHeartbeatClient_HealthHidesURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := NewHeartbeatClient([]HeartbeatTarget{{URL: server.URL + "/secret-uuid"}}, 0)
+	if err := client.Beat(HeartbeatSummary{Status: HeartbeatOK}); err == nil {
+		t.Fatal("Beat() error = nil, want error for status 404")
+	}
+	err := client.Health()
+	if err == nil {
+		t.Fatal("Health() = nil after a failed ping")
+	}
+	if strings.Contains(err.Error(), "secret-uuid") {
+		t.Errorf("Health() = %q, should not contain the check URL path", err)
+	}
+}
@@ -0,0 +1,175 @@
+package alerts
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/owners"
+)
+
+// Alert kinds. They are the webhook event names and what routes match on.
+const (
+	KindIdleTransaction     = "idle_transaction"
+	KindTerminated          = "connection_terminated"
+	KindResolved            = "idle_transaction_resolved"
+	KindConnectionPool      = "connection_pool"
+	KindPoolResolved        = "connection_pool_resolved"
+	KindConnectionLimit     = "connection_limit"
+	KindXminHorizon         = "xmin_horizon"
+	KindPreparedTransaction = "prepared_transaction"
+	KindPreparedResolved    = "prepared_transaction_resolved"
+	KindReplicationSlot     = "replication_slot"
+	KindPollFailure         = "poll_failure"
+	KindPollRecovered       = "poll_recovered"
+	KindDigest              = "digest"
+)
+
+// Notifier delivers alerts to one destination. SlackClient and WebhookClient
+// implement it.
+type Notifier interface {
+	IdleTransactionAlert(severity string, pid int, appName string, duration time.Duration, query string, owner *owners.Team, reminder int) error
+	ConnectionPoolAlert(severity string, used, maxConns int, percent float64, firingSince time.Time) error
+	PoolResolvedAlert(peakUsed, maxConns int, peakPercent float64, duration time.Duration) error
+	ConnectionLimitAlert(severity, scope, name string, used, limit int, percent float64, owner *owners.Team) error
+	XminHorizonAlert(severity, kind, holder string, pid int, xminAge int64, owner *owners.Team) error
+	PreparedTransactionAlert(severity, gid, owner, database string, age time.Duration, team *owners.Team) error
+	PreparedResolvedAlert(gid, owner, database string, age time.Duration, team *owners.Team) error
+	ReplicationSlotAlert(severity, slotName, slotType, database string, active bool, retainedBytes, xminAge int64, reason string) error
+	TerminationAlert(pid int, appName string, duration time.Duration, reason string, owner *owners.Team) error
+	ResolvedAlert(pid int, appName string, duration time.Duration, owner *owners.Team) error
+	GroupAlert(g *AlertGroup) error
+	DigestAlert(d Digest) error
+	PollFailureAlert(errorClass, message string, failures int, since time.Duration) error
+	PollRecoveredAlert(downtime time.Duration, failures int) error
+	TestConnection() error
+	Health() error
+}
+
+var (
+	_ Notifier = (*SlackClient)(nil)
+	_ Notifier = (*WebhookClient)(nil)
+)
+
+// Receiver is a named notifier
+type Receiver struct {
+	Name     string
+	Kind     string // "slack" or "webhook"
+	Notifier Notifier
+}
+
+// Attributes describe an alert for routing and silencing. Empty fields only
+// match routes and silences that don't filter on them.
+type Attributes struct {
+	Kind        string
+	Severity    string
+	Application string
+	User        string
+	Database    string
+	Target      string
+
+	// Per-connection alerts only; routes don't match on these
+	PID         int
+	SessionID   string
+	Fingerprint string
+}
+
+// Route sends alerts matching every set field to the named receivers
+type Route struct {
+	Kinds      []string
+	Severities []string
+	App        string // Glob patterns
+	User       string
+	Database   string
+	Target     string
+	Receivers  []string
+	Continue   bool // Keep checking later routes after a match
+}
+
+// Matches reports whether the alert satisfies every criterion of the route
+func (r *Route) Matches(a Attributes) bool {
+	if len(r.Kinds) > 0 && !contains(r.Kinds, a.Kind) {
+		return false
+	}
+	if len(r.Severities) > 0 && !contains(r.Severities, a.Severity) {
+		return false
+	}
+	return globMatch(r.App, a.Application) && globMatch(r.User, a.User) &&
+		globMatch(r.Database, a.Database) && globMatch(r.Target, a.Target)
+}
+
+// Router picks the receivers for each alert
+type Router struct {
+	receivers []Receiver
+	byName    map[string]Receiver
+	routes    []Route
+}
+
+// NewRouter creates a router. Without routes every receiver gets every alert.
+func NewRouter(receivers []Receiver, routes []Route) *Router {
+	r := &Router{
+		receivers: receivers,
+		byName:    make(map[string]Receiver),
+		routes:    routes,
+	}
+	for _, recv := range receivers {
+		r.byName[recv.Name] = recv
+	}
+	return r
+}
+
+// Receivers returns every configured receiver
+func (r *Router) Receivers() []Receiver {
+	return r.receivers
+}
+
+// Route returns the receivers for an alert. Routes are checked in order and
+// the first match wins unless it sets Continue. An alert that matches no
+// route goes to every receiver, so a gap in the rules never loses an alert;
+// a route with no receivers drops what it matches. Receivers named by a
+// route but not configured (e.g. a Slack receiver without a URL) are skipped.
+func (r *Router) Route(a Attributes) []Receiver {
+	if len(r.routes) == 0 {
+		return r.receivers
+	}
+
+	var result []Receiver
+	seen := make(map[string]bool)
+	matched := false
+	for _, route := range r.routes {
+		if !route.Matches(a) {
+			continue
+		}
+		matched = true
+		for _, name := range route.Receivers {
+			recv, ok := r.byName[name]
+			if ok && !seen[name] {
+				seen[name] = true
+				result = append(result, recv)
+			}
+		}
+		if !route.Continue {
+			break
+		}
+	}
+	if !matched {
+		return r.receivers
+	}
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func globMatch(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, err := filepath.Match(pattern, s)
+	return err == nil && ok
+}
@@ -0,0 +1,73 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRouterRoute(t *testing.T) {
+	receivers := []Receiver{{Name: "team"}, {Name: "payments"}, {Name: "pager"}}
+	routes := []Route{
+		{App: "payments-*", Receivers: []string{"payments"}, Continue: true},
+		{Severities: []string{SeverityCritical}, Receivers: []string{"pager", "team"}},
+		{Kinds: []string{KindDigest}, Receivers: nil}, // Dropped
+		{Kinds: []string{KindIdleTransaction, KindResolved}, Database: "analytics", Receivers: []string{"team"}},
+	}
+	router := NewRouter(receivers, routes)
+
+	tests := []struct {
+		name  string
+		attrs Attributes
+		want  string
+	}{
+		{
+			name:  "payments warning stops at first route after continue",
+			attrs: Attributes{Kind: KindIdleTransaction, Severity: SeverityWarning, Application: "payments-api"},
+			want:  "payments",
+		},
+		{
+			name:  "payments critical continues to the pager",
+			attrs: Attributes{Kind: KindIdleTransaction, Severity: SeverityCritical, Application: "payments-api"},
+			want:  "payments,pager,team",
+		},
+		{
+			name:  "critical pages",
+			attrs: Attributes{Kind: KindConnectionPool, Severity: SeverityCritical},
+			want:  "pager,team",
+		},
+		{
+			name:  "matched route without receivers drops",
+			attrs: Attributes{Kind: KindDigest, Severity: SeverityInfo},
+			want:  "",
+		},
+		{
+			name:  "database match",
+			attrs: Attributes{Kind: KindResolved, Severity: SeverityResolved, Database: "analytics"},
+			want:  "team",
+		},
+		{
+			name:  "unmatched goes everywhere",
+			attrs: Attributes{Kind: KindConnectionPool, Severity: SeverityWarning},
+			want:  "team,payments,pager",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, r := range router.Route(tt.attrs) {
+				names = append(names, r.Name)
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("Route() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouterWithoutRoutes(t *testing.T) {
+	router := NewRouter([]Receiver{{Name: "slack"}, {Name: "webhook"}}, nil)
+	if got := router.Route(Attributes{Kind: KindIdleTransaction}); len(got) != 2 {
+		t.Errorf("Route() returned %d receivers, want all 2", len(got))
+	}
+}
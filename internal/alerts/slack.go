@@ -5,17 +5,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/v0xg/pg-idle-guard/internal/owners"
 	"github.com/v0xg/pg-idle-guard/internal/util"
 )
 
 // SlackClient sends alerts to Slack
 type SlackClient struct {
-	WebhookURL string
-	Channel    string
-	Mentions   []string
-	HTTPClient *http.Client
+	WebhookURL        string
+	Channel           string
+	Mentions          []string
+	EmergencyMentions []string // Defaults to Mentions
+	HTTPClient        *http.Client
+
+	status deliveryStatus
 }
 
 // NewSlackClient creates a new Slack client
@@ -57,53 +62,106 @@ type SlackField struct {
 
 // Alert severity levels
 const (
-	SeverityWarning  = "warning"
-	SeverityCritical = "critical"
-	SeverityInfo     = "info"
-	SeverityResolved = "resolved"
+	SeverityWarning   = "warning"
+	SeverityCritical  = "critical"
+	SeverityEmergency = "emergency"
+	SeverityInfo      = "info"
+	SeverityResolved  = "resolved"
 )
 
 // Color codes for Slack
 var severityColors = map[string]string{
-	SeverityWarning:  "#FFA500", // Orange
-	SeverityCritical: "#FF0000", // Red
-	SeverityInfo:     "#0000FF", // Blue
-	SeverityResolved: "#00FF00", // Green
+	SeverityWarning:   "#FFA500", // Orange
+	SeverityCritical:  "#FF0000", // Red
+	SeverityEmergency: "#8B0000", // Dark red
+	SeverityInfo:      "#0000FF", // Blue
+	SeverityResolved:  "#00FF00", // Green
 }
 
-// buildMentionText creates mention text for critical alerts
-func (s *SlackClient) buildMentionText(severity string) string {
-	if severity != SeverityCritical || len(s.Mentions) == 0 {
-		return ""
+// buildMentionText creates mention text for critical and emergency alerts.
+// On critical alerts the owning team's handle, if it has one, replaces the
+// configured mentions; emergency alerts mention both the team and the
+// emergency contacts.
+func (s *SlackClient) buildMentionText(severity string, owner *owners.Team) string {
+	var mentions []string
+	switch severity {
+	case SeverityCritical:
+		mentions = s.Mentions
+		if owner != nil && owner.Slack != "" {
+			mentions = []string{owner.Slack}
+		}
+	case SeverityEmergency:
+		if owner != nil && owner.Slack != "" {
+			mentions = append(mentions, owner.Slack)
+		}
+		if len(s.EmergencyMentions) > 0 {
+			mentions = append(mentions, s.EmergencyMentions...)
+		} else {
+			mentions = append(mentions, s.Mentions...)
+		}
 	}
 	mentionText := ""
-	for _, m := range s.Mentions {
+	for _, m := range mentions {
 		mentionText += m + " "
 	}
 	return mentionText
 }
 
-// IdleTransactionAlert sends an alert about an idle transaction
-func (s *SlackClient) IdleTransactionAlert(severity string, pid int, appName string, duration time.Duration, query string) error {
+// ownerFields describes the team owning the subject of an alert
+func ownerFields(owner *owners.Team) []SlackField {
+	if owner == nil {
+		return nil
+	}
+	team := owner.Name
+	if owner.Slack != "" {
+		team += " " + owner.Slack
+	}
+	fields := []SlackField{{Title: "Team", Value: team, Short: true}}
+	if owner.Escalation != "" {
+		fields = append(fields, SlackField{Title: "Escalation", Value: owner.Escalation, Short: true})
+	}
+	if owner.Runbook != "" {
+		fields = append(fields, SlackField{Title: "Runbook", Value: fmt.Sprintf("<%s|Runbook>", owner.Runbook), Short: true})
+	}
+	return fields
+}
+
+// IdleTransactionAlert sends an alert about an idle transaction. reminder
+// numbers repeats of an alert already sent; 0 is the first alert.
+func (s *SlackClient) IdleTransactionAlert(severity string, pid int, appName string, duration time.Duration, query string, owner *owners.Team, reminder int) error {
 	color := severityColors[severity]
 	if color == "" {
 		color = "#808080"
 	}
 
+	fields := []SlackField{
+		{Title: "Application", Value: appName, Short: true},
+		{Title: "PID", Value: fmt.Sprintf("%d", pid), Short: true},
+		{Title: "Idle Duration", Value: duration.Round(time.Second).String(), Short: true},
+		{Title: "Severity", Value: severity, Short: true},
+	}
+	fields = append(fields, ownerFields(owner)...)
+	if fingerprint := util.QueryFingerprint(query); fingerprint != "" {
+		fields = append(fields, SlackField{Title: "Fingerprint", Value: fingerprint, Short: true})
+	}
+	fields = append(fields, SlackField{Title: "Query", Value: util.Truncate(query, 200)})
+
+	title := fmt.Sprintf("Idle Transaction [%s]", severity)
+	text := ""
+	if reminder > 0 {
+		title += fmt.Sprintf(" - reminder #%d", reminder)
+		text = fmt.Sprintf("Reminder #%d: still open after %s", reminder, util.FormatDuration(duration))
+	}
+
 	msg := SlackMessage{
 		Channel: s.Channel,
-		Text:    s.buildMentionText(severity),
+		Text:    s.buildMentionText(severity, owner),
 		Attachments: []SlackAttachment{
 			{
-				Color: color,
-				Title: fmt.Sprintf("Idle Transaction [%s]", severity),
-				Fields: []SlackField{
-					{Title: "Application", Value: appName, Short: true},
-					{Title: "PID", Value: fmt.Sprintf("%d", pid), Short: true},
-					{Title: "Idle Duration", Value: duration.Round(time.Second).String(), Short: true},
-					{Title: "Severity", Value: severity, Short: true},
-					{Title: "Query", Value: util.Truncate(query, 200)},
-				},
+				Color:     color,
+				Title:     title,
+				Text:      text,
+				Fields:    fields,
 				Footer:    "pguard",
 				Timestamp: time.Now().Unix(),
 			},
@@ -113,8 +171,9 @@ func (s *SlackClient) IdleTransactionAlert(severity string, pid int, appName str
 	return s.send(msg)
 }
 
-// ConnectionPoolAlert sends an alert about connection pool pressure
-func (s *SlackClient) ConnectionPoolAlert(severity string, used, maxConns int, percent float64) error {
+// ConnectionPoolAlert sends an alert about connection pool pressure that has
+// been firing since firingSince
+func (s *SlackClient) ConnectionPoolAlert(severity string, used, maxConns int, percent float64, firingSince time.Time) error {
 	color := severityColors[severity]
 	if color == "" {
 		color = "#808080"
@@ -122,7 +181,7 @@ func (s *SlackClient) ConnectionPoolAlert(severity string, used, maxConns int, p
 
 	msg := SlackMessage{
 		Channel: s.Channel,
-		Text:    s.buildMentionText(severity),
+		Text:    s.buildMentionText(severity, nil),
 		Attachments: []SlackAttachment{
 			{
 				Color: color,
@@ -132,6 +191,29 @@ func (s *SlackClient) ConnectionPoolAlert(severity string, used, maxConns int, p
 					{Title: "Connections", Value: fmt.Sprintf("%d / %d", used, maxConns), Short: true},
 					{Title: "Available", Value: fmt.Sprintf("%d", maxConns-used), Short: true},
 					{Title: "Severity", Value: severity, Short: true},
+					{Title: "Firing Since", Value: firingSince.UTC().Format("15:04:05 MST"), Short: true},
+				},
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// PoolResolvedAlert sends an alert when connection pool pressure is over
+func (s *SlackClient) PoolResolvedAlert(peakUsed, maxConns int, peakPercent float64, duration time.Duration) error {
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Attachments: []SlackAttachment{
+			{
+				Color: severityColors[SeverityResolved],
+				Title: "Connection Pool Resolved",
+				Fields: []SlackField{
+					{Title: "Peak Usage", Value: fmt.Sprintf("%.0f%%", peakPercent), Short: true},
+					{Title: "Peak Connections", Value: fmt.Sprintf("%d / %d", peakUsed, maxConns), Short: true},
+					{Title: "Total Duration", Value: duration.Round(time.Second).String(), Short: true},
 				},
 				Footer:    "pguard",
 				Timestamp: time.Now().Unix(),
@@ -142,19 +224,295 @@ func (s *SlackClient) ConnectionPoolAlert(severity string, used, maxConns int, p
 	return s.send(msg)
 }
 
+// ConnectionLimitAlert sends an alert about a role or database nearing its own
+// connection limit (scope is "role" or "database")
+func (s *SlackClient) ConnectionLimitAlert(severity, scope, name string, used, limit int, percent float64, owner *owners.Team) error {
+	color := severityColors[severity]
+	if color == "" {
+		color = "#808080"
+	}
+
+	titleScope := "Role"
+	if scope == "database" {
+		titleScope = "Database"
+	}
+
+	fields := []SlackField{
+		{Title: titleScope, Value: name, Short: true},
+		{Title: "Usage", Value: fmt.Sprintf("%.0f%%", percent), Short: true},
+		{Title: "Connections", Value: fmt.Sprintf("%d / %d", used, limit), Short: true},
+		{Title: "Available", Value: fmt.Sprintf("%d", limit-used), Short: true},
+	}
+	fields = append(fields, ownerFields(owner)...)
+
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Text:    s.buildMentionText(severity, owner),
+		Attachments: []SlackAttachment{
+			{
+				Color:     color,
+				Title:     fmt.Sprintf("%s Connection Limit [%s]", titleScope, severity),
+				Fields:    fields,
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// XminHorizonAlert sends an alert about the oldest xmin holding back vacuum
+func (s *SlackClient) XminHorizonAlert(severity, kind, holder string, pid int, xminAge int64, owner *owners.Team) error {
+	color := severityColors[severity]
+	if color == "" {
+		color = "#808080"
+	}
+
+	fields := []SlackField{
+		{Title: "Held By", Value: holder, Short: true},
+		{Title: "Xmin Age", Value: fmt.Sprintf("%d transactions", xminAge), Short: true},
+		{Title: "Kind", Value: kind, Short: true},
+		{Title: "Severity", Value: severity, Short: true},
+	}
+	if pid > 0 {
+		fields = append(fields, SlackField{Title: "PID", Value: fmt.Sprintf("%d", pid), Short: true})
+	}
+	fields = append(fields, ownerFields(owner)...)
+
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Text:    s.buildMentionText(severity, owner),
+		Attachments: []SlackAttachment{
+			{
+				Color:     color,
+				Title:     fmt.Sprintf("Xmin Horizon Held Back [%s]", severity),
+				Text:      "Vacuum cannot remove dead rows newer than this xmin.",
+				Fields:    fields,
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// PreparedTransactionAlert sends an alert about an old two-phase transaction
+func (s *SlackClient) PreparedTransactionAlert(severity, gid, owner, database string, age time.Duration, team *owners.Team) error {
+	color := severityColors[severity]
+	if color == "" {
+		color = "#808080"
+	}
+
+	fields := []SlackField{
+		{Title: "GID", Value: gid, Short: true},
+		{Title: "Age", Value: age.Round(time.Second).String(), Short: true},
+		{Title: "Owner", Value: owner, Short: true},
+		{Title: "Database", Value: database, Short: true},
+	}
+	fields = append(fields, ownerFields(team)...)
+
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Text:    s.buildMentionText(severity, team),
+		Attachments: []SlackAttachment{
+			{
+				Color:     color,
+				Title:     fmt.Sprintf("Prepared Transaction [%s]", severity),
+				Text:      "Holds locks and xmin until COMMIT PREPARED or ROLLBACK PREPARED.",
+				Fields:    fields,
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// PreparedResolvedAlert sends an alert when an alerted prepared transaction
+// has been committed or rolled back
+func (s *SlackClient) PreparedResolvedAlert(gid, owner, database string, age time.Duration, team *owners.Team) error {
+	fields := []SlackField{
+		{Title: "GID", Value: gid, Short: true},
+		{Title: "Total Age", Value: age.Round(time.Second).String(), Short: true},
+		{Title: "Owner", Value: owner, Short: true},
+		{Title: "Database", Value: database, Short: true},
+	}
+	fields = append(fields, ownerFields(team)...)
+
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Attachments: []SlackAttachment{
+			{
+				Color:     severityColors[SeverityResolved],
+				Title:     "Prepared Transaction Resolved",
+				Fields:    fields,
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// ReplicationSlotAlert sends an alert about a replication slot retaining WAL or xmin
+func (s *SlackClient) ReplicationSlotAlert(severity, slotName, slotType, database string, active bool, retainedBytes, xminAge int64, reason string) error {
+	color := severityColors[severity]
+	if color == "" {
+		color = "#808080"
+	}
+
+	activeText := "no"
+	if active {
+		activeText = "yes"
+	}
+
+	fields := []SlackField{
+		{Title: "Slot", Value: slotName, Short: true},
+		{Title: "Type", Value: slotType, Short: true},
+		{Title: "Retained WAL", Value: util.FormatBytes(retainedBytes), Short: true},
+		{Title: "Active", Value: activeText, Short: true},
+		{Title: "Xmin Age", Value: fmt.Sprintf("%d", xminAge), Short: true},
+	}
+	if database != "" {
+		fields = append(fields, SlackField{Title: "Database", Value: database, Short: true})
+	}
+	fields = append(fields, SlackField{Title: "Reason", Value: reason})
+
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Text:    s.buildMentionText(severity, nil),
+		Attachments: []SlackAttachment{
+			{
+				Color:     color,
+				Title:     fmt.Sprintf("Replication Slot [%s]", severity),
+				Fields:    fields,
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
 // TerminationAlert sends an alert when a connection is terminated
-func (s *SlackClient) TerminationAlert(pid int, appName string, duration time.Duration, reason string) error {
+func (s *SlackClient) TerminationAlert(pid int, appName string, duration time.Duration, reason string, owner *owners.Team) error {
+	fields := []SlackField{
+		{Title: "Application", Value: appName, Short: true},
+		{Title: "PID", Value: fmt.Sprintf("%d", pid), Short: true},
+		{Title: "Was Idle For", Value: duration.Round(time.Second).String(), Short: true},
+		{Title: "Reason", Value: reason, Short: true},
+	}
+	fields = append(fields, ownerFields(owner)...)
+
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Attachments: []SlackAttachment{
+			{
+				Color:     severityColors[SeverityInfo],
+				Title:     "Connection Terminated",
+				Fields:    fields,
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// ResolvedAlert sends an alert when an idle transaction resolves
+func (s *SlackClient) ResolvedAlert(pid int, appName string, duration time.Duration, owner *owners.Team) error {
+	fields := []SlackField{
+		{Title: "Application", Value: appName, Short: true},
+		{Title: "PID", Value: fmt.Sprintf("%d", pid), Short: true},
+		{Title: "Total Duration", Value: duration.Round(time.Second).String(), Short: true},
+	}
+	fields = append(fields, ownerFields(owner)...)
+
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Attachments: []SlackAttachment{
+			{
+				Color:     severityColors[SeverityResolved],
+				Title:     "Idle Transaction Resolved",
+				Fields:    fields,
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// GroupAlert sends one message for a batch of connection alerts
+func (s *SlackClient) GroupAlert(g *AlertGroup) error {
+	color := severityColors[g.Severity]
+	switch {
+	case g.Kind == KindResolved:
+		color = severityColors[SeverityResolved]
+	case color == "":
+		color = severityColors[SeverityInfo]
+	}
+
+	fields := []SlackField{
+		{Title: "Application", Value: g.Application, Short: true},
+		{Title: "Target", Value: g.Target, Short: true},
+	}
+	fields = append(fields, ownerFields(g.Owner)...)
+	fields = append(fields, SlackField{Title: "PID - Age - Fingerprint", Value: g.lines(maxGroupLines)})
+
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Text:    s.buildMentionText(g.Severity, g.Owner),
+		Attachments: []SlackAttachment{
+			{
+				Color:     color,
+				Title:     g.Title(),
+				Fields:    fields,
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// DigestAlert sends the periodic digest
+func (s *SlackClient) DigestAlert(d Digest) error {
+	apps := "none"
+	if len(d.TopApps) > 0 {
+		var lines []string
+		for _, a := range d.TopApps {
+			lines = append(lines, fmt.Sprintf("%s: %d", a.Application, a.Incidents))
+		}
+		apps = strings.Join(lines, "\n")
+	}
+	peak := fmt.Sprintf("%.0f%%", d.PeakPoolPercent)
+	if !d.PeakPoolAt.IsZero() {
+		peak += " at " + d.PeakPoolAt.UTC().Format("15:04 MST")
+	}
+
 	msg := SlackMessage{
 		Channel: s.Channel,
 		Attachments: []SlackAttachment{
 			{
 				Color: severityColors[SeverityInfo],
-				Title: "Connection Terminated",
+				Title: fmt.Sprintf("pguard Digest - %s", d.Target),
+				Text: fmt.Sprintf("%s to %s", d.Start.UTC().Format("Jan 2 15:04"),
+					d.End.UTC().Format("Jan 2 15:04 MST")),
 				Fields: []SlackField{
-					{Title: "Application", Value: appName, Short: true},
-					{Title: "PID", Value: fmt.Sprintf("%d", pid), Short: true},
-					{Title: "Was Idle For", Value: duration.Round(time.Second).String(), Short: true},
-					{Title: "Reason", Value: reason, Short: true},
+					{Title: "Idle Transactions", Value: fmt.Sprintf("%d warning, %d critical, %d emergency", d.IdleWarnings, d.IdleCriticals, d.IdleEmergencies), Short: true},
+					{Title: "Terminations", Value: fmt.Sprintf("%d", d.Terminations), Short: true},
+					{Title: "Peak Pool Usage", Value: peak, Short: true},
+					{Title: "Failed Polls", Value: fmt.Sprintf("%d", d.PollFailures), Short: true},
+					{Title: "Top Applications", Value: apps},
 				},
 				Footer:    "pguard",
 				Timestamp: time.Now().Unix(),
@@ -165,18 +523,42 @@ func (s *SlackClient) TerminationAlert(pid int, appName string, duration time.Du
 	return s.send(msg)
 }
 
-// ResolvedAlert sends an alert when an idle transaction resolves
-func (s *SlackClient) ResolvedAlert(pid int, appName string, duration time.Duration) error {
+// PollFailureAlert sends an alert when pguard cannot poll the database and is
+// no longer monitoring it
+func (s *SlackClient) PollFailureAlert(errorClass, message string, failures int, since time.Duration) error {
+	msg := SlackMessage{
+		Channel: s.Channel,
+		Text:    s.buildMentionText(SeverityCritical, nil),
+		Attachments: []SlackAttachment{
+			{
+				Color: severityColors[SeverityCritical],
+				Title: "pguard Cannot Poll the Database [monitoring blind]",
+				Fields: []SlackField{
+					{Title: "Error Class", Value: errorClass, Short: true},
+					{Title: "Failed Polls", Value: fmt.Sprintf("%d", failures), Short: true},
+					{Title: "Failing For", Value: since.Round(time.Second).String(), Short: true},
+					{Title: "Error", Value: util.Truncate(message, 300)},
+				},
+				Footer:    "pguard",
+				Timestamp: time.Now().Unix(),
+			},
+		},
+	}
+
+	return s.send(msg)
+}
+
+// PollRecoveredAlert sends an alert when polling succeeds after a poll failure alert
+func (s *SlackClient) PollRecoveredAlert(downtime time.Duration, failures int) error {
 	msg := SlackMessage{
 		Channel: s.Channel,
 		Attachments: []SlackAttachment{
 			{
 				Color: severityColors[SeverityResolved],
-				Title: "Idle Transaction Resolved",
+				Title: "pguard Polling Recovered",
 				Fields: []SlackField{
-					{Title: "Application", Value: appName, Short: true},
-					{Title: "PID", Value: fmt.Sprintf("%d", pid), Short: true},
-					{Title: "Total Duration", Value: duration.Round(time.Second).String(), Short: true},
+					{Title: "Blind For", Value: downtime.Round(time.Second).String(), Short: true},
+					{Title: "Failed Polls", Value: fmt.Sprintf("%d", failures), Short: true},
 				},
 				Footer:    "pguard",
 				Timestamp: time.Now().Unix(),
@@ -205,8 +587,8 @@ func (s *SlackClient) TestConnection() error {
 	return s.send(msg)
 }
 
-// send posts a message to the Slack webhook
-func (s *SlackClient) send(msg SlackMessage) error {
+// deliver posts a message to the Slack webhook
+func (s *SlackClient) deliver(msg SlackMessage) error {
 	if s.WebhookURL == "" {
 		return fmt.Errorf("slack webhook URL not configured")
 	}
@@ -218,7 +600,7 @@ func (s *SlackClient) send(msg SlackMessage) error {
 
 	resp, err := s.HTTPClient.Post(s.WebhookURL, "application/json", bytes.NewReader(payload))
 	if err != nil {
-		return fmt.Errorf("sending request: %w", err)
+		return fmt.Errorf("sending request: %w", redactURL(err, s.WebhookURL))
 	}
 	defer resp.Body.Close()
 
@@ -228,3 +610,13 @@ func (s *SlackClient) send(msg SlackMessage) error {
 
 	return nil
 }
+
+// send delivers a message and records the outcome for Health
+func (s *SlackClient) send(msg SlackMessage) error {
+	return s.status.record(s.deliver(msg))
+}
+
+// Health returns the error from the most recent delivery, nil if it succeeded
+func (s *SlackClient) Health() error {
+	return s.status.health()
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/v0xg/pg-idle-guard/internal/owners"
 	"github.com/v0xg/pg-idle-guard/internal/util"
 )
 
@@ -29,6 +31,8 @@ func TestSlackClient_IdleTransactionAlert(t *testing.T) {
 		"payment-api",
 		5*time.Minute,
 		"UPDATE accounts SET balance = balance + 100",
+		nil,
+		0,
 	)
 
 	if err != nil {
@@ -48,12 +52,49 @@ func TestSlackClient_IdleTransactionAlert(t *testing.T) {
 		t.Errorf("expected red color for critical, got %s", att.Color)
 	}
 
+	want := util.QueryFingerprint("UPDATE accounts SET balance = balance + 100")
+	found := false
+	for _, f := range att.Fields {
+		if f.Title == "Fingerprint" && f.Value == want {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected Fingerprint field %q, got %+v", want, att.Fields)
+	}
+
 	// Check that mention was included for critical
 	if received.Text != "@oncall " {
 		t.Errorf("expected mention text '@oncall ', got '%s'", received.Text)
 	}
 }
 
+func TestSlackClient_IdleTransactionReminder(t *testing.T) {
+	var received SlackMessage
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewSlackClient(server.URL, "#alerts", nil)
+
+	if err := client.IdleTransactionAlert(SeverityCritical, 1, "payment-api", 20*time.Minute, "SELECT 1", nil, 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	att := received.Attachments[0]
+	if att.Title != "Idle Transaction [critical] - reminder #2" {
+		t.Errorf("title = %q", att.Title)
+	}
+	if !strings.HasPrefix(att.Text, "Reminder #2: still open after ") {
+		t.Errorf("text = %q, want the reminder number and open duration", att.Text)
+	}
+}
+
 func TestSlackClient_ConnectionPoolAlert(t *testing.T) {
 	var received SlackMessage
 
@@ -67,7 +108,7 @@ func TestSlackClient_ConnectionPoolAlert(t *testing.T) {
 
 	client := NewSlackClient(server.URL, "#alerts", nil)
 
-	err := client.ConnectionPoolAlert(SeverityWarning, 75, 100, 75.0)
+	err := client.ConnectionPoolAlert(SeverityWarning, 75, 100, 75.0, time.Now())
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -77,6 +118,35 @@ func TestSlackClient_ConnectionPoolAlert(t *testing.T) {
 	}
 }
 
+func TestSlackClient_ConnectionLimitAlert(t *testing.T) {
+	var received SlackMessage
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewSlackClient(server.URL, "#alerts", nil)
+
+	err := client.ConnectionLimitAlert(SeverityWarning, "role", "myapp", 16, 20, 80.0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	att := received.Attachments[0]
+	if att.Title != "Role Connection Limit [warning]" {
+		t.Errorf("unexpected title %q", att.Title)
+	}
+	for _, field := range att.Fields {
+		if field.Title == "Connections" && field.Value != "16 / 20" {
+			t.Errorf("Connections = %q, want %q", field.Value, "16 / 20")
+		}
+	}
+}
+
 func TestSlackClient_TerminationAlert(t *testing.T) {
 	var received SlackMessage
 
@@ -90,7 +160,7 @@ func TestSlackClient_TerminationAlert(t *testing.T) {
 
 	client := NewSlackClient(server.URL, "#alerts", nil)
 
-	err := client.TerminationAlert(12345, "stuck-app", 10*time.Minute, "exceeded critical threshold")
+	err := client.TerminationAlert(12345, "stuck-app", 10*time.Minute, "exceeded critical threshold", nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -136,7 +206,7 @@ func TestSlackClient_ResolvedAlert(t *testing.T) {
 
 	client := NewSlackClient(server.URL, "#alerts", nil)
 
-	err := client.ResolvedAlert(54321, "recovered-app", 3*time.Minute)
+	err := client.ResolvedAlert(54321, "recovered-app", 3*time.Minute, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -197,3 +267,135 @@ func TestTruncate(t *testing.T) {
 		}
 	}
 }
+
+func TestSlackClient_XminHorizonAlert(t *testing.T) {
+	var received SlackMessage
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewSlackClient(server.URL, "#alerts", []string{"@dba"})
+
+	err := client.XminHorizonAlert(SeverityCritical, "session", "PID 4242 (batch)", 4242, 12000000, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	att := received.Attachments[0]
+	if att.Title != "Xmin Horizon Held Back [critical]" {
+		t.Errorf("unexpected title %q", att.Title)
+	}
+	if received.Text != "@dba " {
+		t.Errorf("expected mention on critical, got %q", received.Text)
+	}
+
+	foundHolder := false
+	for _, field := range att.Fields {
+		if field.Title == "Held By" && field.Value == "PID 4242 (batch)" {
+			foundHolder = true
+		}
+	}
+	if !foundHolder {
+		t.Error("expected Held By field")
+	}
+}
+
+func TestSlackClient_OwnerMention(t *testing.T) {
+	var received SlackMessage
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewSlackClient(server.URL, "#test-channel", []string{"@oncall"})
+	team := &owners.Team{
+		Name:       "payments",
+		Slack:      "<!subteam^S01PAY>",
+		Escalation: "payments-oncall@example.com",
+		Runbook:    "https://wiki.example.com/payments-db",
+	}
+
+	if err := client.IdleTransactionAlert(SeverityCritical, 1, "payment-api", 5*time.Minute, "SELECT 1", team, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received.Text != "<!subteam^S01PAY> " {
+		t.Errorf("expected the team handle instead of @oncall, got %q", received.Text)
+	}
+	fields := make(map[string]string)
+	for _, f := range received.Attachments[0].Fields {
+		fields[f.Title] = f.Value
+	}
+	if fields["Team"] != "payments <!subteam^S01PAY>" {
+		t.Errorf("Team field = %q", fields["Team"])
+	}
+	if fields["Escalation"] != "payments-oncall@example.com" {
+		t.Errorf("Escalation field = %q", fields["Escalation"])
+	}
+	if !strings.Contains(fields["Runbook"], "https://wiki.example.com/payments-db") {
+		t.Errorf("Runbook field = %q", fields["Runbook"])
+	}
+
+	received = SlackMessage{}
+	if err := client.IdleTransactionAlert(SeverityWarning, 1, "payment-api", time.Minute, "SELECT 1", team, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if received.Text != "" {
+		t.Errorf("warnings should not mention anyone, got %q", received.Text)
+	}
+}
+
+func TestSlackClient_BuildMentionText(t *testing.T) {
+	team := &owners.Team{Name: "payments", Slack: "@payments"}
+
+	tests := []struct {
+		name      string
+		emergency []string
+		severity  string
+		owner     *owners.Team
+		want      string
+	}{
+		{"warning", nil, SeverityWarning, team, ""},
+		{"critical", nil, SeverityCritical, nil, "@dba "},
+		{"critical with owner", nil, SeverityCritical, team, "@payments "},
+		{"emergency falls back to mentions", nil, SeverityEmergency, nil, "@dba "},
+		{"emergency contacts", []string{"@oncall-lead"}, SeverityEmergency, nil, "@oncall-lead "},
+		{"emergency with owner", []string{"@oncall-lead"}, SeverityEmergency, team, "@payments @oncall-lead "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewSlackClient("http://example.invalid", "#alerts", []string{"@dba"})
+			client.EmergencyMentions = tt.emergency
+			if got := client.buildMentionText(tt.severity, tt.owner); got != tt.want {
+				t.Errorf("buildMentionText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlackClient_ErrorHidesWebhookURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	webhookURL := server.URL + "/services/T0/B0/secret"
+	server.Close()
+
+	client := NewSlackClient(webhookURL, "#alerts", nil)
+	err := client.TestConnection()
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if strings.Contains(err.Error(), "secret") || strings.Contains(client.Health().Error(), "secret") {
+		t.Errorf("error leaks the webhook URL: %v", err)
+	}
+	if !strings.Contains(err.Error(), strings.TrimPrefix(server.URL, "http://")) {
+		t.Errorf("error should name the host: %v", err)
+	}
+}
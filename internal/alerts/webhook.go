@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/v0xg/pg-idle-guard/internal/owners"
 	"github.com/v0xg/pg-idle-guard/internal/util"
 )
 
@@ -16,6 +17,8 @@ type WebhookClient struct {
 	Method     string
 	Headers    map[string]string
 	HTTPClient *http.Client
+
+	status deliveryStatus
 }
 
 // NewWebhookClient creates a new webhook client
@@ -41,10 +44,18 @@ type WebhookPayload struct {
 	Data      map[string]interface{} `json:"data"`
 }
 
-// IdleTransactionAlert sends an alert about an idle transaction
-func (w *WebhookClient) IdleTransactionAlert(severity string, pid int, appName string, duration time.Duration, query string) error {
+// addTeam adds the team owning the subject of an alert to data as "team"
+func addTeam(data map[string]interface{}, team *owners.Team) {
+	if team != nil {
+		data["team"] = team
+	}
+}
+
+// IdleTransactionAlert sends an alert about an idle transaction. reminder
+// numbers repeats of an alert already sent; 0 is the first alert.
+func (w *WebhookClient) IdleTransactionAlert(severity string, pid int, appName string, duration time.Duration, query string, owner *owners.Team, reminder int) error {
 	payload := WebhookPayload{
-		Event:     "idle_transaction",
+		Event:     KindIdleTransaction,
 		Severity:  severity,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Data: map[string]interface{}{
@@ -53,15 +64,20 @@ func (w *WebhookClient) IdleTransactionAlert(severity string, pid int, appName s
 			"duration_seconds": duration.Seconds(),
 			"duration_human":   duration.Round(time.Second).String(),
 			"query":            util.Truncate(query, 500),
+			"fingerprint":      util.QueryFingerprint(query),
+			"reminder":         reminder > 0,
+			"reminder_count":   reminder,
 		},
 	}
+	addTeam(payload.Data, owner)
 	return w.send(payload)
 }
 
-// ConnectionPoolAlert sends an alert about connection pool pressure
-func (w *WebhookClient) ConnectionPoolAlert(severity string, used, maxConns int, percent float64) error {
+// ConnectionPoolAlert sends an alert about connection pool pressure that has
+// been firing since firingSince
+func (w *WebhookClient) ConnectionPoolAlert(severity string, used, maxConns int, percent float64, firingSince time.Time) error {
 	payload := WebhookPayload{
-		Event:     "connection_pool",
+		Event:     KindConnectionPool,
 		Severity:  severity,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Data: map[string]interface{}{
@@ -69,15 +85,127 @@ func (w *WebhookClient) ConnectionPoolAlert(severity string, used, maxConns int,
 			"max_connections":       maxConns,
 			"available_connections": maxConns - used,
 			"usage_percent":         percent,
+			"firing_since":          firingSince.UTC().Format(time.RFC3339),
+		},
+	}
+	return w.send(payload)
+}
+
+// PoolResolvedAlert sends an alert when connection pool pressure is over
+func (w *WebhookClient) PoolResolvedAlert(peakUsed, maxConns int, peakPercent float64, duration time.Duration) error {
+	payload := WebhookPayload{
+		Event:     KindPoolResolved,
+		Severity:  SeverityResolved,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"peak_used_connections": peakUsed,
+			"max_connections":       maxConns,
+			"peak_usage_percent":    peakPercent,
+			"duration_seconds":      duration.Seconds(),
+			"duration_human":        duration.Round(time.Second).String(),
+		},
+	}
+	return w.send(payload)
+}
+
+// ConnectionLimitAlert sends an alert about a role or database nearing its own
+// connection limit (scope is "role" or "database")
+func (w *WebhookClient) ConnectionLimitAlert(severity, scope, name string, used, limit int, percent float64, owner *owners.Team) error {
+	payload := WebhookPayload{
+		Event:     KindConnectionLimit,
+		Severity:  severity,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"scope":                 scope,
+			"name":                  name,
+			"used_connections":      used,
+			"connection_limit":      limit,
+			"available_connections": limit - used,
+			"usage_percent":         percent,
+		},
+	}
+	addTeam(payload.Data, owner)
+	return w.send(payload)
+}
+
+// XminHorizonAlert sends an alert about the oldest xmin holding back vacuum
+func (w *WebhookClient) XminHorizonAlert(severity, kind, holder string, pid int, xminAge int64, owner *owners.Team) error {
+	payload := WebhookPayload{
+		Event:     KindXminHorizon,
+		Severity:  severity,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"kind":     kind,
+			"holder":   holder,
+			"pid":      pid,
+			"xmin_age": xminAge,
+		},
+	}
+	addTeam(payload.Data, owner)
+	return w.send(payload)
+}
+
+// PreparedTransactionAlert sends an alert about an old two-phase transaction
+func (w *WebhookClient) PreparedTransactionAlert(severity, gid, owner, database string, age time.Duration, team *owners.Team) error {
+	payload := WebhookPayload{
+		Event:     KindPreparedTransaction,
+		Severity:  severity,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"gid":         gid,
+			"owner":       owner,
+			"database":    database,
+			"age_seconds": age.Seconds(),
+			"age_human":   age.Round(time.Second).String(),
+		},
+	}
+	addTeam(payload.Data, team)
+	return w.send(payload)
+}
+
+// PreparedResolvedAlert sends an alert when an alerted prepared transaction
+// has been committed or rolled back
+func (w *WebhookClient) PreparedResolvedAlert(gid, owner, database string, age time.Duration, team *owners.Team) error {
+	payload := WebhookPayload{
+		Event:     KindPreparedResolved,
+		Severity:  SeverityResolved,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"gid":         gid,
+			"owner":       owner,
+			"database":    database,
+			"age_seconds": age.Seconds(),
+			"age_human":   age.Round(time.Second).String(),
+		},
+	}
+	addTeam(payload.Data, team)
+	return w.send(payload)
+}
+
+// ReplicationSlotAlert sends an alert about a replication slot retaining WAL or xmin
+func (w *WebhookClient) ReplicationSlotAlert(severity, slotName, slotType, database string, active bool, retainedBytes, xminAge int64, reason string) error {
+	payload := WebhookPayload{
+		Event:     KindReplicationSlot,
+		Severity:  severity,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"slot_name":      slotName,
+			"slot_type":      slotType,
+			"database":       database,
+			"active":         active,
+			"retained_bytes": retainedBytes,
+			"retained_human": util.FormatBytes(retainedBytes),
+			"xmin_age":       xminAge,
+			"reason":         reason,
 		},
 	}
 	return w.send(payload)
 }
 
 // TerminationAlert sends an alert when a connection is terminated
-func (w *WebhookClient) TerminationAlert(pid int, appName string, duration time.Duration, reason string) error {
+func (w *WebhookClient) TerminationAlert(pid int, appName string, duration time.Duration, reason string, owner *owners.Team) error {
 	payload := WebhookPayload{
-		Event:     "connection_terminated",
+		Event:     KindTerminated,
 		Severity:  SeverityInfo,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Data: map[string]interface{}{
@@ -88,13 +216,14 @@ func (w *WebhookClient) TerminationAlert(pid int, appName string, duration time.
 			"reason":           reason,
 		},
 	}
+	addTeam(payload.Data, owner)
 	return w.send(payload)
 }
 
 // ResolvedAlert sends an alert when an idle transaction resolves
-func (w *WebhookClient) ResolvedAlert(pid int, appName string, duration time.Duration) error {
+func (w *WebhookClient) ResolvedAlert(pid int, appName string, duration time.Duration, owner *owners.Team) error {
 	payload := WebhookPayload{
-		Event:     "idle_transaction_resolved",
+		Event:     KindResolved,
 		Severity:  SeverityResolved,
 		Timestamp: time.Now().UTC().Format(time.RFC3339),
 		Data: map[string]interface{}{
@@ -104,6 +233,110 @@ func (w *WebhookClient) ResolvedAlert(pid int, appName string, duration time.Dur
 			"duration_human":   duration.Round(time.Second).String(),
 		},
 	}
+	addTeam(payload.Data, owner)
+	return w.send(payload)
+}
+
+// GroupAlert sends one payload for a batch of connection alerts. The event is
+// the alert kind with a "_group" suffix, e.g. "idle_transaction_group".
+func (w *WebhookClient) GroupAlert(g *AlertGroup) error {
+	severity := g.Severity
+	if severity == "" {
+		severity = SeverityInfo
+		if g.Kind == KindResolved {
+			severity = SeverityResolved
+		}
+	}
+
+	connections := make([]map[string]interface{}, 0, len(g.Alerts))
+	for _, a := range g.Alerts {
+		conn := map[string]interface{}{
+			"pid":              a.PID,
+			"duration_seconds": a.Duration.Seconds(),
+			"fingerprint":      a.Fingerprint,
+			"query":            util.Truncate(a.Query, 500),
+		}
+		if a.Reason != "" {
+			conn["reason"] = a.Reason
+		}
+		if a.Reminder > 0 {
+			conn["reminder_count"] = a.Reminder
+		}
+		connections = append(connections, conn)
+	}
+
+	payload := WebhookPayload{
+		Event:     g.Kind + "_group",
+		Severity:  severity,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"target":      g.Target,
+			"application": g.Application,
+			"count":       len(g.Alerts),
+			"connections": connections,
+		},
+	}
+	addTeam(payload.Data, g.Owner)
+	return w.send(payload)
+}
+
+// DigestAlert sends the periodic digest
+func (w *WebhookClient) DigestAlert(d Digest) error {
+	data := map[string]interface{}{
+		"target":            d.Target,
+		"start":             d.Start.UTC().Format(time.RFC3339),
+		"end":               d.End.UTC().Format(time.RFC3339),
+		"idle_warnings":     d.IdleWarnings,
+		"idle_criticals":    d.IdleCriticals,
+		"idle_emergencies":  d.IdleEmergencies,
+		"terminations":      d.Terminations,
+		"poll_failures":     d.PollFailures,
+		"peak_pool_percent": d.PeakPoolPercent,
+		"top_applications":  d.TopApps,
+	}
+	if !d.PeakPoolAt.IsZero() {
+		data["peak_pool_at"] = d.PeakPoolAt.UTC().Format(time.RFC3339)
+	}
+
+	payload := WebhookPayload{
+		Event:     KindDigest,
+		Severity:  SeverityInfo,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      data,
+	}
+	return w.send(payload)
+}
+
+// PollFailureAlert sends an alert when pguard cannot poll the database and is
+// no longer monitoring it
+func (w *WebhookClient) PollFailureAlert(errorClass, message string, failures int, since time.Duration) error {
+	payload := WebhookPayload{
+		Event:     KindPollFailure,
+		Severity:  SeverityCritical,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"error_class":   errorClass,
+			"error":         util.Truncate(message, 500),
+			"failed_polls":  failures,
+			"since_seconds": since.Seconds(),
+			"since_human":   since.Round(time.Second).String(),
+		},
+	}
+	return w.send(payload)
+}
+
+// PollRecoveredAlert sends an alert when polling succeeds after a poll failure alert
+func (w *WebhookClient) PollRecoveredAlert(downtime time.Duration, failures int) error {
+	payload := WebhookPayload{
+		Event:     KindPollRecovered,
+		Severity:  SeverityResolved,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data: map[string]interface{}{
+			"failed_polls":     failures,
+			"downtime_seconds": downtime.Seconds(),
+			"downtime_human":   downtime.Round(time.Second).String(),
+		},
+	}
 	return w.send(payload)
 }
 
@@ -120,8 +353,8 @@ func (w *WebhookClient) TestConnection() error {
 	return w.send(payload)
 }
 
-// send posts a payload to the webhook URL
-func (w *WebhookClient) send(payload WebhookPayload) error {
+// deliver posts a payload to the webhook URL
+func (w *WebhookClient) deliver(payload WebhookPayload) error {
 	if w.URL == "" {
 		return fmt.Errorf("webhook URL not configured")
 	}
@@ -133,7 +366,7 @@ func (w *WebhookClient) send(payload WebhookPayload) error {
 
 	req, err := http.NewRequest(w.Method, w.URL, bytes.NewReader(data))
 	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+		return fmt.Errorf("creating request: %w", redactURL(err, w.URL))
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -146,7 +379,7 @@ func (w *WebhookClient) send(payload WebhookPayload) error {
 
 	resp, err := w.HTTPClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("sending request: %w", err)
+		return fmt.Errorf("sending request: %w", redactURL(err, w.URL))
 	}
 	defer resp.Body.Close()
 
@@ -156,3 +389,13 @@ func (w *WebhookClient) send(payload WebhookPayload) error {
 
 	return nil
 }
+
+// send delivers a message and records the outcome for Health
+func (w *WebhookClient) send(payload WebhookPayload) error {
+	return w.status.record(w.deliver(payload))
+}
+
+// Health returns the error from the most recent delivery, nil if it succeeded
+func (w *WebhookClient) Health() error {
+	return w.status.health()
+}
@@ -7,6 +7,8 @@ import (
 	"net/http/httptest"
 	"testing"
 	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/owners"
 )
 
 func TestNewWebhookClient(t *testing.T) {
@@ -76,7 +78,7 @@ func TestWebhookClient_IdleTransactionAlert(t *testing.T) {
 		"X-Custom-Header": "test-value",
 	})
 
-	err := client.IdleTransactionAlert(SeverityWarning, 12345, "test-app", 45*time.Second, "SELECT * FROM users")
+	err := client.IdleTransactionAlert(SeverityWarning, 12345, "test-app", 45*time.Second, "SELECT * FROM users", &owners.Team{Name: "accounts", Runbook: "https://wiki.example.com/accounts"}, 2)
 	if err != nil {
 		t.Fatalf("IdleTransactionAlert() error = %v", err)
 	}
@@ -94,6 +96,13 @@ func TestWebhookClient_IdleTransactionAlert(t *testing.T) {
 	if app, ok := receivedPayload.Data["application"].(string); !ok || app != "test-app" {
 		t.Errorf("application = %v, want test-app", receivedPayload.Data["application"])
 	}
+	team, _ := receivedPayload.Data["team"].(map[string]interface{})
+	if team["name"] != "accounts" || team["runbook"] != "https://wiki.example.com/accounts" {
+		t.Errorf("team = %v, want accounts with its runbook", receivedPayload.Data["team"])
+	}
+	if receivedPayload.Data["reminder"] != true || receivedPayload.Data["reminder_count"] != float64(2) {
+		t.Errorf("reminder = %v, reminder_count = %v, want true and 2", receivedPayload.Data["reminder"], receivedPayload.Data["reminder_count"])
+	}
 
 	// Verify headers
 	if receivedHeaders.Get("Content-Type") != "application/json" {
@@ -120,7 +129,7 @@ func TestWebhookClient_ConnectionPoolAlert(t *testing.T) {
 	defer server.Close()
 
 	client := NewWebhookClient(server.URL, "POST", nil)
-	err := client.ConnectionPoolAlert(SeverityCritical, 90, 100, 90.0)
+	err := client.ConnectionPoolAlert(SeverityCritical, 90, 100, 90.0, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
 	if err != nil {
 		t.Fatalf("ConnectionPoolAlert() error = %v", err)
 	}
@@ -140,6 +149,157 @@ func TestWebhookClient_ConnectionPoolAlert(t *testing.T) {
 	if avail, ok := receivedPayload.Data["available_connections"].(float64); !ok || avail != 10 {
 		t.Errorf("available_connections = %v, want 10", receivedPayload.Data["available_connections"])
 	}
+	if since := receivedPayload.Data["firing_since"]; since != "2024-05-01T12:00:00Z" {
+		t.Errorf("firing_since = %v, want 2024-05-01T12:00:00Z", since)
+	}
+}
+
+func TestWebhookClient_PoolResolvedAlert(t *testing.T) {
+	var receivedPayload WebhookPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &receivedPayload); err != nil {
+			t.Errorf("failed to unmarshal payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(server.URL, "POST", nil)
+	err := client.PoolResolvedAlert(93, 100, 93.0, 12*time.Minute)
+	if err != nil {
+		t.Fatalf("PoolResolvedAlert() error = %v", err)
+	}
+
+	if receivedPayload.Event != "connection_pool_resolved" {
+		t.Errorf("Event = %q, want %q", receivedPayload.Event, "connection_pool_resolved")
+	}
+	if receivedPayload.Severity != SeverityResolved {
+		t.Errorf("Severity = %q, want %q", receivedPayload.Severity, SeverityResolved)
+	}
+	if peak := receivedPayload.Data["peak_usage_percent"]; peak != 93.0 {
+		t.Errorf("peak_usage_percent = %v, want 93", peak)
+	}
+	if d := receivedPayload.Data["duration_seconds"]; d != 720.0 {
+		t.Errorf("duration_seconds = %v, want 720", d)
+	}
+}
+
+func TestWebhookClient_XminHorizonAlert(t *testing.T) {
+	var receivedPayload WebhookPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &receivedPayload); err != nil {
+			t.Errorf("failed to unmarshal payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(server.URL, "POST", nil)
+	err := client.XminHorizonAlert(SeverityWarning, "replication_slot", "replication slot cdc", 0, 2500000, nil)
+	if err != nil {
+		t.Fatalf("XminHorizonAlert() error = %v", err)
+	}
+
+	if receivedPayload.Event != "xmin_horizon" {
+		t.Errorf("Event = %q, want %q", receivedPayload.Event, "xmin_horizon")
+	}
+	if kind, ok := receivedPayload.Data["kind"].(string); !ok || kind != "replication_slot" {
+		t.Errorf("kind = %v, want replication_slot", receivedPayload.Data["kind"])
+	}
+	if age, ok := receivedPayload.Data["xmin_age"].(float64); !ok || age != 2500000 {
+		t.Errorf("xmin_age = %v, want 2500000", receivedPayload.Data["xmin_age"])
+	}
+}
+
+func TestWebhookClient_PreparedTransactionAlert(t *testing.T) {
+	var receivedPayload WebhookPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &receivedPayload); err != nil {
+			t.Errorf("failed to unmarshal payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(server.URL, "POST", nil)
+	err := client.PreparedTransactionAlert(SeverityCritical, "order-42", "shop_app", "shop", 45*time.Minute, nil)
+	if err != nil {
+		t.Fatalf("PreparedTransactionAlert() error = %v", err)
+	}
+
+	if receivedPayload.Event != "prepared_transaction" {
+		t.Errorf("Event = %q, want %q", receivedPayload.Event, "prepared_transaction")
+	}
+	if gid, ok := receivedPayload.Data["gid"].(string); !ok || gid != "order-42" {
+		t.Errorf("gid = %v, want order-42", receivedPayload.Data["gid"])
+	}
+	if age, ok := receivedPayload.Data["age_seconds"].(float64); !ok || age != 2700 {
+		t.Errorf("age_seconds = %v, want 2700", receivedPayload.Data["age_seconds"])
+	}
+}
+
+func TestWebhookClient_PreparedResolvedAlert(t *testing.T) {
+	var receivedPayload WebhookPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &receivedPayload); err != nil {
+			t.Errorf("failed to unmarshal payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(server.URL, "POST", nil)
+	err := client.PreparedResolvedAlert("order-42", "shop_app", "shop", time.Hour, nil)
+	if err != nil {
+		t.Fatalf("PreparedResolvedAlert() error = %v", err)
+	}
+
+	if receivedPayload.Event != "prepared_transaction_resolved" {
+		t.Errorf("Event = %q, want %q", receivedPayload.Event, "prepared_transaction_resolved")
+	}
+	if receivedPayload.Severity != SeverityResolved {
+		t.Errorf("Severity = %q, want %q", receivedPayload.Severity, SeverityResolved)
+	}
+	if gid, ok := receivedPayload.Data["gid"].(string); !ok || gid != "order-42" {
+		t.Errorf("gid = %v, want order-42", receivedPayload.Data["gid"])
+	}
+}
+
+func TestWebhookClient_ReplicationSlotAlert(t *testing.T) {
+	var receivedPayload WebhookPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &receivedPayload); err != nil {
+			t.Errorf("failed to unmarshal payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(server.URL, "POST", nil)
+	err := client.ReplicationSlotAlert(SeverityWarning, "debezium", "logical", "shop", false, 2<<30, 0, "slot is inactive")
+	if err != nil {
+		t.Fatalf("ReplicationSlotAlert() error = %v", err)
+	}
+
+	if receivedPayload.Event != "replication_slot" {
+		t.Errorf("Event = %q, want %q", receivedPayload.Event, "replication_slot")
+	}
+	if active, ok := receivedPayload.Data["active"].(bool); !ok || active {
+		t.Errorf("active = %v, want false", receivedPayload.Data["active"])
+	}
+	if human, ok := receivedPayload.Data["retained_human"].(string); !ok || human != "2.0 GB" {
+		t.Errorf("retained_human = %v, want 2.0 GB", receivedPayload.Data["retained_human"])
+	}
 }
 
 func TestWebhookClient_TerminationAlert(t *testing.T) {
@@ -155,7 +315,7 @@ func TestWebhookClient_TerminationAlert(t *testing.T) {
 	defer server.Close()
 
 	client := NewWebhookClient(server.URL, "POST", nil)
-	err := client.TerminationAlert(54321, "terminated-app", 5*time.Minute, "auto-terminate threshold exceeded")
+	err := client.TerminationAlert(54321, "terminated-app", 5*time.Minute, "auto-terminate threshold exceeded", nil)
 	if err != nil {
 		t.Fatalf("TerminationAlert() error = %v", err)
 	}
@@ -187,7 +347,7 @@ func TestWebhookClient_ResolvedAlert(t *testing.T) {
 	defer server.Close()
 
 	client := NewWebhookClient(server.URL, "POST", nil)
-	err := client.ResolvedAlert(99999, "resolved-app", 3*time.Minute)
+	err := client.ResolvedAlert(99999, "resolved-app", 3*time.Minute, nil)
 	if err != nil {
 		t.Fatalf("ResolvedAlert() error = %v", err)
 	}
@@ -200,6 +360,38 @@ func TestWebhookClient_ResolvedAlert(t *testing.T) {
 	}
 }
 
+func TestWebhookClient_PollFailureAlert(t *testing.T) {
+	var receivedPayload WebhookPayload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &receivedPayload); err != nil {
+			t.Errorf("failed to unmarshal payload: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(server.URL, "POST", nil)
+	err := client.PollFailureAlert("auth", "password authentication failed", 3, 90*time.Second)
+	if err != nil {
+		t.Fatalf("PollFailureAlert() error = %v", err)
+	}
+
+	if receivedPayload.Event != "poll_failure" {
+		t.Errorf("Event = %q, want %q", receivedPayload.Event, "poll_failure")
+	}
+	if receivedPayload.Severity != SeverityCritical {
+		t.Errorf("Severity = %q, want %q", receivedPayload.Severity, SeverityCritical)
+	}
+	if receivedPayload.Data["error_class"] != "auth" {
+		t.Errorf("error_class = %v, want %q", receivedPayload.Data["error_class"], "auth")
+	}
+	if receivedPayload.Data["failed_polls"] != float64(3) {
+		t.Errorf("failed_polls = %v, want 3", receivedPayload.Data["failed_polls"])
+	}
+}
+
 func TestWebhookClient_TestConnection(t *testing.T) {
 	var receivedPayload WebhookPayload
 
@@ -261,6 +453,30 @@ func TestWebhookClient_ErrorHandling(t *testing.T) {
 	}
 }
 
+func TestWebhookClient_Health(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewWebhookClient(server.URL, "POST", nil)
+	if err := client.Health(); err != nil {
+		t.Errorf("Health() before any send = %v, want nil", err)
+	}
+
+	_ = client.TestConnection()
+	if err := client.Health(); err == nil {
+		t.Error("Health() after a failed send = nil, want error")
+	}
+
+	status = http.StatusOK
+	_ = client.TestConnection()
+	if err := client.Health(); err != nil {
+		t.Errorf("Health() after a successful send = %v, want nil", err)
+	}
+}
+
 func TestWebhookClient_EmptyURL(t *testing.T) {
 	client := NewWebhookClient("", "POST", nil)
 	err := client.TestConnection()
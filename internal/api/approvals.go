@@ -0,0 +1,95 @@
+package api
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/events"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// Approval is an idle transaction that auto-terminate would have ended, but
+// whose app is protected with require_confirmation
+type Approval struct {
+	SessionID    string    `json:"session_id"`
+	PID          int       `json:"pid"`
+	Application  string    `json:"application"`
+	User         string    `json:"user"`
+	Database     string    `json:"database"`
+	DurationSec  float64   `json:"duration_seconds"`
+	Query        string    `json:"query"`
+	PendingSince time.Time `json:"pending_since"`
+}
+
+// ApprovalQueue holds the sessions waiting for an operator to approve
+// their termination. The daemon replaces the contents on every poll.
+type ApprovalQueue struct {
+	mu      sync.Mutex
+	pending map[string]*Approval
+}
+
+// NewApprovalQueue creates an empty queue
+func NewApprovalQueue() *ApprovalQueue {
+	return &ApprovalQueue{pending: make(map[string]*Approval)}
+}
+
+// Sync makes conns the pending set. Sessions already pending keep their
+// PendingSince; sessions no longer passed in are dropped.
+func (q *ApprovalQueue) Sync(conns []*postgres.Connection) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now().UTC()
+	pending := make(map[string]*Approval, len(conns))
+	for _, conn := range conns {
+		id := events.NewSession(conn.PID, conn.BackendStart).ID
+		since := now
+		if existing, ok := q.pending[id]; ok {
+			since = existing.PendingSince
+		}
+		pending[id] = &Approval{
+			SessionID:    id,
+			PID:          conn.PID,
+			Application:  conn.ApplicationName,
+			User:         conn.Username,
+			Database:     conn.Database,
+			DurationSec:  conn.IdleDuration().Seconds(),
+			Query:        util.TruncateQuery(conn.Query, 200),
+			PendingSince: since,
+		}
+	}
+	q.pending = pending
+}
+
+// List returns the pending approvals, longest waiting first
+func (q *ApprovalQueue) List() []Approval {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]Approval, 0, len(q.pending))
+	for _, a := range q.pending {
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].PendingSince.Equal(result[j].PendingSince) {
+			return result[i].PendingSince.Before(result[j].PendingSince)
+		}
+		return result[i].PID < result[j].PID
+	})
+	return result
+}
+
+// Take removes and returns a pending approval
+func (q *ApprovalQueue) Take(sessionID string) (Approval, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	a, ok := q.pending[sessionID]
+	if !ok {
+		return Approval{}, false
+	}
+	delete(q.pending, sessionID)
+	return *a, true
+}
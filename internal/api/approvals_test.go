@@ -0,0 +1,40 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestApprovalQueueSync(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	a := &postgres.Connection{PID: 1, BackendStart: start, StateChange: time.Now()}
+	b := &postgres.Connection{PID: 2, BackendStart: start, StateChange: time.Now()}
+
+	q := NewApprovalQueue()
+	q.Sync([]*postgres.Connection{a})
+	first := q.List()[0].PendingSince
+
+	time.Sleep(time.Millisecond)
+	q.Sync([]*postgres.Connection{a, b})
+	list := q.List()
+	if len(list) != 2 {
+		t.Fatalf("got %d pending, want 2", len(list))
+	}
+	if !list[0].PendingSince.Equal(first) || list[0].PID != 1 {
+		t.Errorf("session still pending should keep its PendingSince and sort first: %+v", list)
+	}
+
+	q.Sync([]*postgres.Connection{b})
+	if list := q.List(); len(list) != 1 || list[0].PID != 2 {
+		t.Errorf("ended session should be dropped: %+v", list)
+	}
+
+	if _, ok := q.Take("2-1700000000000000"); !ok {
+		t.Error("Take should find the pending session")
+	}
+	if _, ok := q.Take("2-1700000000000000"); ok {
+		t.Error("Take should remove the session")
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/v0xg/pg-idle-guard/internal/audit"
+)
+
+// Role grants access to a class of endpoints. An operator can do everything
+// a viewer can.
+type Role string
+
+const (
+	RoleViewer   Role = "viewer"   // Read endpoints and the event stream
+	RoleOperator Role = "operator" // Cancel, terminate and other actions
+)
+
+// allows returns true if r includes the permissions of required
+func (r Role) allows(required Role) bool {
+	return r == RoleOperator || r == required
+}
+
+// Token is a bearer token and the identity and role it grants
+type Token struct {
+	Name  string `yaml:"name"` // Recorded as the actor in the audit log
+	Token string `yaml:"token"`
+	Role  Role   `yaml:"role"`
+}
+
+// ParseTokens reads a YAML (or JSON) list of tokens
+func ParseTokens(data []byte) ([]Token, error) {
+	var tokens []Token
+	if err := yaml.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("parsing tokens: %w", err)
+	}
+	seen := make(map[string]bool)
+	for i, t := range tokens {
+		if t.Name == "" {
+			return nil, fmt.Errorf("token %d has no name", i+1)
+		}
+		if len(t.Token) < 16 {
+			return nil, fmt.Errorf("token %q must be at least 16 characters", t.Name)
+		}
+		if t.Role != RoleViewer && t.Role != RoleOperator {
+			return nil, fmt.Errorf("token %q has unknown role %q (valid: viewer, operator)", t.Name, t.Role)
+		}
+		if seen[t.Token] {
+			return nil, fmt.Errorf("token %q duplicates another token", t.Name)
+		}
+		seen[t.Token] = true
+	}
+	return tokens, nil
+}
+
+// LoadTokensFile reads tokens from a YAML file
+func LoadTokensFile(path string) ([]Token, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading tokens file: %w", err)
+	}
+	return ParseTokens(data)
+}
+
+type tokenKey struct{}
+
+// Identity returns the token name a request was authenticated as, or "" if
+// the API has no tokens configured
+func Identity(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(Token)
+	return token.Name
+}
+
+// Authorizer checks bearer tokens against roles and audits denied requests
+type Authorizer struct {
+	tokens map[[sha256.Size]byte]Token
+	audit  func(audit.Entry)
+}
+
+// NewAuthorizer creates an authorizer. With no tokens every request is
+// allowed, which is only safe on a loopback listener.
+func NewAuthorizer(tokens []Token, auditFn func(audit.Entry)) *Authorizer {
+	a := &Authorizer{tokens: make(map[[sha256.Size]byte]Token), audit: auditFn}
+	for _, t := range tokens {
+		a.tokens[sha256.Sum256([]byte(t.Token))] = t
+	}
+	return a
+}
+
+// Enabled returns true if requests must carry a token
+func (a *Authorizer) Enabled() bool {
+	return len(a.tokens) > 0
+}
+
+// Require wraps next so that it only runs for tokens holding role
+func (a *Authorizer) Require(role Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.Enabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := a.lookup(r)
+		if !ok {
+			a.deny(r, "", "missing or invalid bearer token")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="pguard"`)
+			writeError(w, http.StatusUnauthorized, fmt.Errorf("missing or invalid bearer token"))
+			return
+		}
+		if !token.Role.allows(role) {
+			reason := fmt.Sprintf("role %s required, token has %s", role, token.Role)
+			a.deny(r, token.Name, reason)
+			writeError(w, http.StatusForbidden, fmt.Errorf("%s", reason))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, token)))
+	})
+}
+
+// lookup finds the token presented in the Authorization header. Tokens are
+// compared by hash so the lookup time does not depend on how much matched.
+func (a *Authorizer) lookup(r *http.Request) (Token, bool) {
+	scheme, presented, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || presented == "" {
+		return Token{}, false
+	}
+	sum := sha256.Sum256([]byte(presented))
+	for hash, token := range a.tokens {
+		if subtle.ConstantTimeCompare(hash[:], sum[:]) == 1 {
+			return token, true
+		}
+	}
+	return Token{}, false
+}
+
+func (a *Authorizer) deny(r *http.Request, actor, reason string) {
+	if a.audit == nil {
+		return
+	}
+	if actor == "" {
+		actor = "anonymous"
+	}
+	a.audit(audit.Entry{
+		Action: "api_request",
+		Target: r.Method + " " + r.URL.Path,
+		Actor:  actor,
+		Source: "api",
+		Result: audit.ResultDenied,
+		Error:  reason,
+		Details: map[string]interface{}{
+			"remote_addr": r.RemoteAddr,
+		},
+	})
+}
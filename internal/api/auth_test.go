@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/v0xg/pg-idle-guard/internal/audit"
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+const testTokens = `
+- name: dashboard
+  token: viewer-token-0123456789
+  role: viewer
+- name: oncall
+  token: operator-token-0123456789
+  role: operator
+`
+
+func TestParseTokens(t *testing.T) {
+	tokens, err := ParseTokens([]byte(testTokens))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 2 || tokens[1].Role != RoleOperator {
+		t.Errorf("tokens = %+v", tokens)
+	}
+
+	invalid := map[string]string{
+		"no name":    "- {token: abcdefghijklmnopq, role: viewer}",
+		"short":      "- {name: a, token: short, role: viewer}",
+		"bad role":   "- {name: a, token: abcdefghijklmnopq, role: admin}",
+		"duplicate":  "- {name: a, token: abcdefghijklmnopq, role: viewer}\n- {name: b, token: abcdefghijklmnopq, role: operator}",
+		"not a list": "token: abcdefghijklmnopq",
+	}
+	for name, data := range invalid {
+		if _, err := ParseTokens([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAuthorizer(t *testing.T) {
+	tokens, err := ParseTokens([]byte(testTokens))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var denied []audit.Entry
+	var signaledBy []string
+	auth := NewAuthorizer(tokens, func(e audit.Entry) { denied = append(denied, e) })
+	signal := func(ctx context.Context, conn *postgres.Connection, cancelOnly bool) (bool, error) {
+		signaledBy = append(signaledBy, Identity(ctx))
+		return true, nil
+	}
+	mux := http.NewServeMux()
+	NewServer(testDB(), config.DefaultConfig(), nil, signal, auth, NewApprovalQueue(), nil).Register(mux)
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		token      string
+		wantCode   int
+		wantDenied bool
+	}{
+		{"no token", http.MethodGet, "/api/v1/connections", "", http.StatusUnauthorized, true},
+		{"wrong token", http.MethodGet, "/api/v1/connections", "not-a-real-token-at-all", http.StatusUnauthorized, true},
+		{"viewer reads", http.MethodGet, "/api/v1/connections", "viewer-token-0123456789", http.StatusOK, false},
+		{"operator reads", http.MethodGet, "/api/v1/locks", "operator-token-0123456789", http.StatusOK, false},
+		{"viewer terminates", http.MethodPost, "/api/v1/connections/100/terminate", "viewer-token-0123456789", http.StatusForbidden, true},
+		{"operator terminates", http.MethodPost, "/api/v1/connections/100/terminate", "operator-token-0123456789", http.StatusOK, false},
+		{"openapi is public", http.MethodGet, "/api/v1/openapi.json", "", http.StatusOK, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			denied = nil
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"session_id":"100-1714561200000000"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if got := len(denied) > 0; got != tt.wantDenied {
+				t.Fatalf("denied audited = %v, want %v", got, tt.wantDenied)
+			}
+			if tt.wantDenied {
+				e := denied[0]
+				if e.Result != audit.ResultDenied || e.Source != "api" || e.Target != tt.method+" "+tt.target {
+					t.Errorf("audit entry = %+v", e)
+				}
+			}
+		})
+	}
+
+	if len(signaledBy) != 1 || signaledBy[0] != "oncall" {
+		t.Errorf("signal identities = %v, want [oncall]", signaledBy)
+	}
+}
+
+func TestAuthorizerDisabled(t *testing.T) {
+	auth := NewAuthorizer(nil, nil)
+	if auth.Enabled() {
+		t.Fatal("authorizer without tokens should be disabled")
+	}
+	handler := auth.Require(RoleOperator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status %d, want 200", rec.Code)
+	}
+}
+
+func TestServerReadOnly(t *testing.T) {
+	mux := http.NewServeMux()
+	NewServer(testDB(), config.DefaultConfig(), nil, nil, NewAuthorizer(nil, nil), NewApprovalQueue(), nil).ReadOnly().Register(mux)
+
+	tests := []struct {
+		method   string
+		target   string
+		wantCode int
+	}{
+		{http.MethodGet, "/api/v1/connections", http.StatusOK},
+		{http.MethodPost, "/api/v1/connections/100/terminate", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/connections/100/cancel", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/silences", http.StatusMethodNotAllowed}, // GET stays
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tt.wantCode {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.target, rec.Code, tt.wantCode)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	mux, _ := testServer(testDB())
+	rec := do(mux, http.MethodGet, "/api/v1/openapi.json", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d", rec.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `json:"properties"`
+				Required   []string               `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI == "" {
+		t.Error("missing openapi version")
+	}
+
+	server := NewServer(testDB(), nil, nil, nil, nil, nil, nil)
+	for _, r := range server.routes() {
+		op, ok := doc.Paths[Prefix+r.Path][map[string]string{
+			http.MethodGet:  "get",
+			http.MethodPost: "post",
+		}[r.Method]]
+		if !ok {
+			t.Errorf("%s %s is not documented", r.Method, r.Path)
+			continue
+		}
+		if op["operationId"] == "" {
+			t.Errorf("%s %s has no operationId", r.Method, r.Path)
+		}
+	}
+
+	conn, ok := doc.Components.Schemas["ConnectionStatus"]
+	if !ok {
+		t.Fatal("ConnectionStatus schema missing")
+	}
+	if _, ok := conn.Properties["session_id"]; !ok {
+		t.Errorf("ConnectionStatus properties = %v", conn.Properties)
+	}
+
+	action := doc.Components.Schemas["ActionRequest"]
+	if len(action.Required) != 0 {
+		t.Errorf("ActionRequest fields are omitempty, got required %v", action.Required)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	tests := []struct {
+		r    route
+		want string
+	}{
+		{route{Method: http.MethodGet, Path: "/idle-transactions"}, "getIdleTransactions"},
+		{route{Method: http.MethodPost, Path: "/connections/{pid}/terminate"}, "postConnectionsPidTerminate"},
+	}
+	for _, tt := range tests {
+		if got := operationID(tt.r); got != tt.want {
+			t.Errorf("operationID(%s %s) = %q, want %q", tt.r.Method, tt.r.Path, got, tt.want)
+		}
+	}
+}
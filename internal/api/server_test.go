@@ -0,0 +1,369 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/silence"
+)
+
+type fakeDB struct {
+	stats     *postgres.PoolStats
+	conns     []*postgres.Connection
+	locks     []*postgres.Lock
+	blockedBy map[int][]int
+	err       error
+}
+
+func (f *fakeDB) GetPoolStats(ctx context.Context) (*postgres.PoolStats, error) {
+	return f.stats, f.err
+}
+
+func (f *fakeDB) GetConnections(ctx context.Context) ([]*postgres.Connection, error) {
+	return f.conns, f.err
+}
+
+func (f *fakeDB) GetIdleTransactions(ctx context.Context) ([]*postgres.Connection, error) {
+	var idle []*postgres.Connection
+	for _, c := range f.conns {
+		if c.IsIdleInTransaction() {
+			idle = append(idle, c)
+		}
+	}
+	return idle, f.err
+}
+
+func (f *fakeDB) GetAllLocks(ctx context.Context) ([]*postgres.Lock, error) {
+	return f.locks, f.err
+}
+
+func (f *fakeDB) GetBlockingPIDs(ctx context.Context) (map[int][]int, error) {
+	return f.blockedBy, f.err
+}
+
+// testServer returns a mux serving the API and the PIDs it signaled
+func testServer(db Database) (*http.ServeMux, *[]int) {
+	return testServerWithApprovals(db, NewApprovalQueue())
+}
+
+func testServerWithApprovals(db Database, approvals *ApprovalQueue) (*http.ServeMux, *[]int) {
+	var signaled []int
+	signal := func(ctx context.Context, conn *postgres.Connection, cancelOnly bool) (bool, error) {
+		signaled = append(signaled, conn.PID)
+		return true, nil
+	}
+	mux := http.NewServeMux()
+	silences, _ := silence.NewManager(nil)
+	NewServer(db, config.DefaultConfig(), nil, signal, NewAuthorizer(nil, nil), approvals, silences).Register(mux)
+	return mux, &signaled
+}
+
+func do(mux *http.ServeMux, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+var backendStart = time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+
+func testDB() *fakeDB {
+	now := time.Now()
+	return &fakeDB{
+		stats: &postgres.PoolStats{MaxConnections: 100, TotalConnections: 80},
+		conns: []*postgres.Connection{
+			{PID: 100, BackendStart: backendStart, StateChange: now.Add(-10 * time.Minute), State: postgres.StateIdleInTransaction, ApplicationName: "payment-api", Username: "app", Database: "orders", ClientAddr: "10.0.3.7",
+				Query: "UPDATE accounts SET balance = 0 WHERE id = 1"},
+			{PID: 200, BackendStart: backendStart, StateChange: now, State: postgres.StateActive, ApplicationName: "billing", Username: "app", Database: "orders", ClientAddr: "10.0.4.1"},
+		},
+		locks: []*postgres.Lock{
+			{PID: 100, LockType: "relation", Mode: "RowExclusiveLock", Granted: true, Relation: "accounts"},
+			{PID: 200, LockType: "transactionid", Mode: "ShareLock", Granted: false},
+		},
+		blockedBy: map[int][]int{200: {100}},
+	}
+}
+
+func TestConnections(t *testing.T) {
+	mux, _ := testServer(testDB())
+
+	tests := []struct {
+		query    string
+		wantPIDs []int
+		wantCode int
+	}{
+		{query: "", wantPIDs: []int{100, 200}, wantCode: http.StatusOK},
+		{query: "?app=payment-*", wantPIDs: []int{100}, wantCode: http.StatusOK},
+		{query: "?state=idle-in-transaction&idle_longer_than=5m", wantPIDs: []int{100}, wantCode: http.StatusOK},
+		{query: "?client_cidr=10.0.4.0/24", wantPIDs: []int{200}, wantCode: http.StatusOK},
+		{query: "?user=nobody", wantPIDs: []int{}, wantCode: http.StatusOK},
+		{query: "?state=sleeping", wantCode: http.StatusBadRequest},
+		{query: "?query_matches=(", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			rec := do(mux, http.MethodGet, "/api/v1/connections"+tt.query, "")
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if tt.wantCode != http.StatusOK {
+				var e ErrorResponse
+				if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e.Error == "" {
+					t.Errorf("expected error body, got %s", rec.Body)
+				}
+				return
+			}
+
+			var got []ConnectionStatus
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.wantPIDs) {
+				t.Fatalf("got %d connections, want %v", len(got), tt.wantPIDs)
+			}
+			for i, pid := range tt.wantPIDs {
+				if got[i].PID != pid {
+					t.Errorf("connection %d has PID %d, want %d", i, got[i].PID, pid)
+				}
+			}
+		})
+	}
+}
+
+func TestIdleTransactionsAndLocks(t *testing.T) {
+	mux, _ := testServer(testDB())
+
+	rec := do(mux, http.MethodGet, "/api/v1/idle-transactions", "")
+	var idle []IdleTransactionStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &idle); err != nil {
+		t.Fatal(err)
+	}
+	if len(idle) != 1 || idle[0].PID != 100 || idle[0].Severity != "critical" || idle[0].Fingerprint == "" {
+		t.Errorf("idle transactions = %+v", idle)
+	}
+	if len(idle) == 1 && (len(idle[0].Blocking) != 1 || idle[0].Blocking[0] != 200) {
+		t.Errorf("PID 100 should block 200, got %v", idle[0].Blocking)
+	}
+
+	rec = do(mux, http.MethodGet, "/api/v1/pool", "")
+	var pool PoolResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &pool); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Status != "warning" || pool.Pool.UsedConnections != 80 || pool.Thresholds.PoolWarningPct != 75 {
+		t.Errorf("pool = %+v", pool)
+	}
+
+	rec = do(mux, http.MethodGet, "/api/v1/locks?granted=false", "")
+	var locks []LockStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &locks); err != nil {
+		t.Fatal(err)
+	}
+	if len(locks) != 1 || locks[0].PID != 200 || len(locks[0].BlockedBy) != 1 || locks[0].BlockedBy[0] != 100 {
+		t.Errorf("awaited locks = %+v", locks)
+	}
+
+	if rec := do(mux, http.MethodGet, "/api/v1/locks?pid=abc", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid pid: status %d, want 400", rec.Code)
+	}
+}
+
+func TestActions(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		body         string
+		wantCode     int
+		wantSignaled bool
+	}{
+		{
+			name:         "terminate by session id",
+			target:       "/api/v1/connections/100/terminate",
+			body:         `{"session_id":"100-1714561200000000"}`,
+			wantCode:     http.StatusOK,
+			wantSignaled: true,
+		},
+		{
+			name:         "cancel by backend start",
+			target:       "/api/v1/connections/100/cancel",
+			body:         `{"backend_start":"2024-05-01T11:00:00Z"}`,
+			wantCode:     http.StatusOK,
+			wantSignaled: true,
+		},
+		{
+			name:     "identity required",
+			target:   "/api/v1/connections/100/terminate",
+			body:     `{}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "pid reused",
+			target:   "/api/v1/connections/100/terminate",
+			body:     `{"session_id":"100-1"}`,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "unknown pid",
+			target:   "/api/v1/connections/999/terminate",
+			body:     `{"session_id":"999-1"}`,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "bad pid",
+			target:   "/api/v1/connections/abc/terminate",
+			body:     `{"session_id":"x"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown field",
+			target:   "/api/v1/connections/100/terminate",
+			body:     `{"pid":100}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux, signaled := testServer(testDB())
+			rec := do(mux, http.MethodPost, tt.target, tt.body)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+			if got := len(*signaled) > 0; got != tt.wantSignaled {
+				t.Errorf("signaled = %v, want %v", got, tt.wantSignaled)
+			}
+		})
+	}
+
+	mux, _ := testServer(testDB())
+	if rec := do(mux, http.MethodGet, "/api/v1/connections/100/terminate", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET on an action: status %d, want 405", rec.Code)
+	}
+}
+
+func TestDatabaseError(t *testing.T) {
+	mux, _ := testServer(&fakeDB{err: errors.New("connection refused")})
+	rec := do(mux, http.MethodGet, "/api/v1/idle-transactions", "")
+	if rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "connection refused") {
+		t.Errorf("status %d body %s", rec.Code, rec.Body)
+	}
+}
+
+func TestApprove(t *testing.T) {
+	db := testDB()
+	approvals := NewApprovalQueue()
+	approvals.Sync(db.conns[:1])
+	mux, signaled := testServerWithApprovals(db, approvals)
+
+	var pending []Approval
+	if err := json.Unmarshal(do(mux, http.MethodGet, "/api/v1/approvals", "").Body.Bytes(), &pending); err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].SessionID != "100-1714561200000000" {
+		t.Fatalf("pending = %+v", pending)
+	}
+
+	if rec := do(mux, http.MethodPost, "/api/v1/approvals/200-1714561200000000/approve", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("approving a session that is not pending: status %d, want 404", rec.Code)
+	}
+
+	rec := do(mux, http.MethodPost, "/api/v1/approvals/100-1714561200000000/approve", "")
+	if rec.Code != http.StatusOK || len(*signaled) != 1 || (*signaled)[0] != 100 {
+		t.Fatalf("approve: status %d, signaled %v: %s", rec.Code, *signaled, rec.Body)
+	}
+	if len(approvals.List()) != 0 {
+		t.Error("approved session should leave the queue")
+	}
+
+	// The session ended between polls: nothing is terminated
+	approvals.Sync(db.conns[:1])
+	db.conns[0].BackendStart = db.conns[0].BackendStart.Add(time.Second)
+	if rec := do(mux, http.MethodPost, "/api/v1/approvals/100-1714561200000000/approve", ""); rec.Code != http.StatusConflict {
+		t.Errorf("approving an ended session: status %d, want 409", rec.Code)
+	}
+	if len(*signaled) != 1 {
+		t.Errorf("signaled %v, want only the first approval", *signaled)
+	}
+}
+
+func TestSilences(t *testing.T) {
+	mux, _ := testServer(testDB())
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+	}{
+		{"no expiry", `{"app":"payment-api","comment":"known"}`, http.StatusBadRequest},
+		{"both expiries", `{"app":"payment-api","comment":"known","duration":"1h","expires_at":"2099-01-01T00:00:00Z"}`, http.StatusBadRequest},
+		{"bad duration", `{"app":"payment-api","comment":"known","duration":"soon"}`, http.StatusBadRequest},
+		{"no matcher", `{"comment":"known","duration":"1h","created_by":"alice"}`, http.StatusBadRequest},
+		{"no creator", `{"app":"payment-api","comment":"known","duration":"1h"}`, http.StatusBadRequest},
+		{"unknown field", `{"application":"payment-api"}`, http.StatusBadRequest},
+		{"trailing data", `{"app":"*","comment":"x","duration":"1h","created_by":"x"}=`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := do(mux, http.MethodPost, "/api/v1/silences", tt.body); rec.Code != tt.wantCode {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.wantCode, rec.Body)
+			}
+		})
+	}
+
+	// A cross-site form can only send text/plain, form or multipart bodies
+	form := httptest.NewRequest(http.MethodPost, "/api/v1/silences",
+		strings.NewReader(`{"app":"*","comment":"x","duration":"87600h","created_by":"x"}=`))
+	form.Header.Set("Content-Type", "text/plain")
+	formRec := httptest.NewRecorder()
+	mux.ServeHTTP(formRec, form)
+	if formRec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain body: status %d, want 415", formRec.Code)
+	}
+
+	rec := do(mux, http.MethodPost, "/api/v1/silences",
+		`{"app":"payment-*","comment":"known leak","duration":"2h","created_by":"alice"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var created silence.Silence
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID == "" || created.CreatedBy != "alice" || time.Until(created.ExpiresAt) < time.Hour {
+		t.Errorf("created = %+v", created)
+	}
+
+	var list []silence.Silence
+	rec = do(mux, http.MethodGet, "/api/v1/silences", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 1 {
+		t.Fatalf("list = %s, %v", rec.Body, err)
+	}
+
+	if rec := do(mux, http.MethodPost, "/api/v1/silences/"+created.ID+"/expire", ""); rec.Code != http.StatusOK {
+		t.Fatalf("expire status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := do(mux, http.MethodPost, "/api/v1/silences/nope/expire", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expire unknown: status %d, want 404", rec.Code)
+	}
+
+	rec = do(mux, http.MethodGet, "/api/v1/silences", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 0 {
+		t.Errorf("active list after expiry = %s", rec.Body)
+	}
+	rec = do(mux, http.MethodGet, "/api/v1/silences?all=true", "")
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil || len(list) != 1 {
+		t.Errorf("full list after expiry = %s", rec.Body)
+	}
+}
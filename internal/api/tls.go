@@ -0,0 +1,147 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader serves the certificate and client CA currently on disk,
+// reloading them when the files change so renewed certificates are picked
+// up without a restart
+type certReloader struct {
+	certFile, keyFile, caFile string
+
+	mu       sync.Mutex
+	modTimes [3]time.Time
+	cert     *tls.Certificate
+	clientCA *x509.CertPool
+}
+
+// NewTLSConfig builds a server TLS config from PEM files. With caFile set,
+// clients must present a certificate signed by that CA.
+func NewTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile, caFile: caFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	base := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return &tls.Config{
+		MinVersion: base.MinVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.reloadIfChanged()
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			cfg := base.Clone()
+			cfg.Certificates = []tls.Certificate{*r.cert}
+			cfg.ClientCAs = r.clientCA
+			return cfg, nil
+		},
+	}, nil
+}
+
+// reloadIfChanged reloads the files if any modification time changed. A
+// failed reload keeps serving the previous certificate.
+func (r *certReloader) reloadIfChanged() {
+	r.mu.Lock()
+	current := r.modTimes
+	r.mu.Unlock()
+
+	modTimes := r.statAll()
+	if modTimes == current {
+		return
+	}
+	if err := r.reload(); err != nil {
+		slog.Error("reloading API TLS certificate failed, keeping the previous one", "error", err)
+		// Don't retry on every handshake; wait for the files to change again
+		r.mu.Lock()
+		r.modTimes = modTimes
+		r.mu.Unlock()
+		return
+	}
+	slog.Info("reloaded API TLS certificate", "cert_file", r.certFile)
+}
+
+func (r *certReloader) reload() error {
+	modTimes := r.statAll()
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading API certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("reading client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in client CA %s", r.caFile)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.clientCA = pool
+	r.modTimes = modTimes
+	return nil
+}
+
+func (r *certReloader) statAll() [3]time.Time {
+	var times [3]time.Time
+	for i, path := range []string{r.certFile, r.keyFile, r.caFile} {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// NewClientTLSConfig builds a TLS config for calling the API. caFile adds a
+// CA to trust besides the system roots; certFile and keyFile present a
+// client certificate, for a daemon that requires one.
+func NewClientTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if (certFile == "") != (keyFile == "") {
+		return nil, fmt.Errorf("client certificate and key must be given together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,160 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSigned writes a self-signed certificate and key for commonName
+func writeSelfSigned(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{certFile, keyFile} {
+		if err := os.Chtimes(f, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func servedCommonName(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	served, err := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(served.Certificates[0].Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestTLSConfigReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	start := time.Now().Add(-time.Minute)
+
+	writeSelfSigned(t, certFile, keyFile, "first", start)
+	cfg, err := NewTLSConfig(certFile, keyFile, certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := servedCommonName(t, cfg); got != "first" {
+		t.Errorf("served %q, want first", got)
+	}
+
+	served, _ := cfg.GetConfigForClient(&tls.ClientHelloInfo{})
+	if served.ClientAuth != tls.RequireAndVerifyClientCert || served.ClientCAs == nil {
+		t.Error("client CA should require client certificates")
+	}
+
+	writeSelfSigned(t, certFile, keyFile, "renewed", start.Add(30*time.Second))
+	if got := servedCommonName(t, cfg); got != "renewed" {
+		t.Errorf("served %q after renewal, want renewed", got)
+	}
+
+	// A broken renewal keeps the last good certificate
+	if err := os.WriteFile(keyFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got := servedCommonName(t, cfg); got != "renewed" {
+		t.Errorf("served %q after broken renewal, want renewed", got)
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewTLSConfig(filepath.Join(dir, "missing.crt"), filepath.Join(dir, "missing.key"), ""); err == nil {
+		t.Error("expected error for missing files")
+	}
+
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	writeSelfSigned(t, certFile, keyFile, "x", time.Now())
+	if _, err := NewTLSConfig(certFile, keyFile, keyFile); err == nil {
+		t.Error("expected error for a client CA without certificates")
+	}
+}
+
+func TestNewClientTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.crt")
+	caKey := filepath.Join(dir, "ca.key")
+	writeSelfSigned(t, caFile, caKey, "pguard-ca", time.Now())
+
+	serverCfg, err := NewTLSConfig(caFile, caKey, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCfg, err := NewClientTLSConfig(caFile, caFile, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if clientCfg.RootCAs == nil || len(clientCfg.Certificates) != 1 {
+		t.Fatalf("client config = %+v", clientCfg)
+	}
+
+	// A full handshake: the client trusts the private CA and presents the
+	// certificate the server requires
+	clientConn, serverConn := net.Pipe()
+	clientCfg.ServerName = "pguard-ca"
+	errs := make(chan error, 1)
+	go func() {
+		errs <- tls.Server(serverConn, serverCfg).Handshake()
+		serverConn.Close()
+	}()
+	if err := tls.Client(clientConn, clientCfg).Handshake(); err != nil {
+		t.Fatalf("client handshake: %v", err)
+	}
+	clientConn.Close()
+	if err := <-errs; err != nil {
+		t.Fatalf("server handshake: %v", err)
+	}
+
+	if _, err := NewClientTLSConfig("", caFile, ""); err == nil {
+		t.Error("expected error for a certificate without a key")
+	}
+	if _, err := NewClientTLSConfig(caKey, "", ""); err == nil {
+		t.Error("expected error for a CA file without certificates")
+	}
+}
@@ -0,0 +1,276 @@
+// Package api holds the daemon's versioned HTTP API and the response types it
+// shares with `pguard status --json`.
+package api
+
+import (
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/events"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// StatusOutput represents the JSON output of the status command
+type StatusOutput struct {
+	Status           string                  `json:"status" yaml:"status"` // "ok", "warning", "critical"
+	Pool             PoolStatus              `json:"pool" yaml:"pool"`
+	IdleTransactions []IdleTransactionStatus `json:"idle_transactions" yaml:"idle_transactions"`
+	Connections      []ConnectionStatus      `json:"connections,omitempty" yaml:"connections,omitempty"` // Only with --verbose
+	ReplicationSlots []ReplicationSlotStatus `json:"replication_slots,omitempty" yaml:"replication_slots,omitempty"`
+	Roles            []LimitStatus           `json:"roles,omitempty" yaml:"roles,omitempty"`
+	Databases        []LimitStatus           `json:"databases,omitempty" yaml:"databases,omitempty"`
+	Thresholds       ThresholdStatus         `json:"thresholds" yaml:"thresholds"`
+}
+
+// PoolStatus represents connection pool statistics
+type PoolStatus struct {
+	MaxConnections       int     `json:"max_connections" yaml:"max_connections"`
+	ReservedSuperuser    int     `json:"reserved_superuser" yaml:"reserved_superuser"`
+	ReservedConnections  int     `json:"reserved_connections" yaml:"reserved_connections"`
+	ReservedRDS          int     `json:"reserved_rds" yaml:"reserved_rds"`
+	Capacity             int     `json:"capacity" yaml:"capacity"` // Slots usable by normal roles
+	TotalConnections     int     `json:"total_connections" yaml:"total_connections"`
+	UsedConnections      int     `json:"used_connections" yaml:"used_connections"` // Total minus excluded backends
+	ActiveConnections    int     `json:"active_connections" yaml:"active_connections"`
+	IdleConnections      int     `json:"idle_connections" yaml:"idle_connections"`
+	IdleInTransaction    int     `json:"idle_in_transaction" yaml:"idle_in_transaction"`
+	AvailableConnections int     `json:"available_connections" yaml:"available_connections"`
+	OwnConnections       int     `json:"own_connections" yaml:"own_connections"`
+	RDSAdminConnections  int     `json:"rdsadmin_connections" yaml:"rdsadmin_connections"`
+	ExcludedConnections  int     `json:"excluded_connections" yaml:"excluded_connections"`
+	UsagePercent         float64 `json:"usage_percent" yaml:"usage_percent"`
+}
+
+// IdleTransactionStatus represents a single idle transaction
+type IdleTransactionStatus struct {
+	PID         int     `json:"pid" yaml:"pid"`
+	SessionID   string  `json:"session_id" yaml:"session_id"` // Stable across PID reuse, see events.Session
+	Application string  `json:"application" yaml:"application"`
+	User        string  `json:"user" yaml:"user"`
+	Database    string  `json:"database" yaml:"database"`
+	Duration    string  `json:"duration" yaml:"duration"`
+	DurationSec float64 `json:"duration_seconds" yaml:"duration_seconds"`
+	Query       string  `json:"query" yaml:"query"`
+	Fingerprint string  `json:"fingerprint,omitempty" yaml:"fingerprint,omitempty"` // Query shape, for silence add --fingerprint
+	Severity    string  `json:"severity" yaml:"severity"`                           // "warning", "critical", or ""
+	Team        string  `json:"team,omitempty" yaml:"team,omitempty"`               // Owning team, see owners.file
+	Blocking    []int   `json:"blocking,omitempty" yaml:"blocking,omitempty"`       // PIDs waiting on this session's locks (API only)
+}
+
+// ConnectionStatus represents a single connection (for verbose output)
+type ConnectionStatus struct {
+	PID          int       `json:"pid" yaml:"pid"`
+	SessionID    string    `json:"session_id" yaml:"session_id"`
+	BackendStart time.Time `json:"backend_start" yaml:"backend_start"`
+	State        string    `json:"state" yaml:"state"`
+	Application  string    `json:"application" yaml:"application"`
+	User         string    `json:"user" yaml:"user"`
+	Database     string    `json:"database" yaml:"database"`
+	ClientAddr   string    `json:"client_addr" yaml:"client_addr"`
+	Duration     string    `json:"duration" yaml:"duration"`
+	DurationSec  float64   `json:"duration_seconds" yaml:"duration_seconds"`
+	Query        string    `json:"query" yaml:"query"`
+	Team         string    `json:"team,omitempty" yaml:"team,omitempty"`
+}
+
+// ReplicationSlotStatus represents a single replication slot
+type ReplicationSlotStatus struct {
+	Name          string `json:"name" yaml:"name"`
+	SlotType      string `json:"slot_type" yaml:"slot_type"`
+	Database      string `json:"database,omitempty" yaml:"database,omitempty"`
+	Active        bool   `json:"active" yaml:"active"`
+	RetainedBytes int64  `json:"retained_bytes" yaml:"retained_bytes"`
+	XminAge       int64  `json:"xmin_age" yaml:"xmin_age"`
+	Severity      string `json:"severity" yaml:"severity"` // "warning", "critical", or ""
+	Reason        string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// LimitStatus represents a role's or database's usage of its own connection limit
+type LimitStatus struct {
+	Name         string  `json:"name" yaml:"name"`
+	Connections  int     `json:"connections" yaml:"connections"`
+	Limit        int     `json:"limit" yaml:"limit"` // -1 means no limit
+	UsagePercent float64 `json:"usage_percent" yaml:"usage_percent"`
+	Severity     string  `json:"severity" yaml:"severity"` // "warning", "critical", or ""
+}
+
+// ThresholdStatus shows the configured thresholds
+type ThresholdStatus struct {
+	IdleWarning     string `json:"idle_warning" yaml:"idle_warning"`
+	IdleCritical    string `json:"idle_critical" yaml:"idle_critical"`
+	PoolWarningPct  int    `json:"pool_warning_percent" yaml:"pool_warning_percent"`
+	PoolCriticalPct int    `json:"pool_critical_percent" yaml:"pool_critical_percent"`
+}
+
+// LockStatus represents a lock held or awaited by a backend
+type LockStatus struct {
+	PID       int    `json:"pid"`
+	LockType  string `json:"lock_type"`
+	Mode      string `json:"mode"`
+	Relation  string `json:"relation,omitempty"`
+	Granted   bool   `json:"granted"`
+	BlockedBy []int  `json:"blocked_by,omitempty"` // Only for locks not yet granted
+}
+
+// PoolResponse is the pool state with its severity
+type PoolResponse struct {
+	Status     string          `json:"status"` // "ok", "warning", "critical"
+	Pool       PoolStatus      `json:"pool"`
+	Thresholds ThresholdStatus `json:"thresholds"`
+}
+
+// WhoAmIResponse describes the caller's token
+type WhoAmIResponse struct {
+	Name        string `json:"name,omitempty"`
+	Role        Role   `json:"role"`
+	AuthEnabled bool   `json:"auth_enabled"` // false: every caller has the operator role
+}
+
+// SummaryStatus is the compact document served on /status
+type SummaryStatus struct {
+	MaxConnections        int `json:"max_connections"`
+	Total                 int `json:"total"`
+	Active                int `json:"active"`
+	Idle                  int `json:"idle"`
+	IdleInTransaction     int `json:"idle_in_transaction"`
+	Available             int `json:"available"`
+	IdleTransactionsCount int `json:"idle_transactions_count"`
+}
+
+// ActionRequest identifies the session a cancel or terminate is meant for.
+// One of SessionID or BackendStart is required so a reused PID is never signaled.
+type ActionRequest struct {
+	SessionID    string     `json:"session_id,omitempty"`
+	BackendStart *time.Time `json:"backend_start,omitempty"`
+}
+
+// ActionResponse is the outcome of a cancel or terminate
+type ActionResponse struct {
+	PID       int    `json:"pid"`
+	SessionID string `json:"session_id"`
+	Action    string `json:"action"`  // "cancel" or "terminate"
+	Success   bool   `json:"success"` // false if the backend was already gone
+}
+
+// SilenceRequest creates a silence. At least one matcher is required, and
+// one of Duration or ExpiresAt.
+type SilenceRequest struct {
+	App         string     `json:"app,omitempty"`
+	User        string     `json:"user,omitempty"`
+	PID         int        `json:"pid,omitempty"`
+	SessionID   string     `json:"session_id,omitempty"`
+	Fingerprint string     `json:"fingerprint,omitempty"`
+	Target      string     `json:"target,omitempty"`
+	Duration    string     `json:"duration,omitempty"` // e.g. 2h
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	Comment     string     `json:"comment"`
+	CreatedBy   string     `json:"created_by,omitempty"` // Ignored when the API has tokens; the token name is used
+}
+
+// ErrorResponse is returned with every 4xx and 5xx status
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// NewPoolStatus converts pool statistics to their API form
+func NewPoolStatus(stats *postgres.PoolStats) PoolStatus {
+	return PoolStatus{
+		MaxConnections:       stats.MaxConnections,
+		ReservedSuperuser:    stats.ReservedSuperuser,
+		ReservedConnections:  stats.ReservedConnections,
+		ReservedRDS:          stats.ReservedRDS,
+		Capacity:             stats.Capacity(),
+		TotalConnections:     stats.TotalConnections,
+		UsedConnections:      stats.UsedConnections(),
+		ActiveConnections:    stats.ActiveConnections,
+		IdleConnections:      stats.IdleConnections,
+		IdleInTransaction:    stats.IdleInTransaction,
+		AvailableConnections: stats.AvailableConnections,
+		OwnConnections:       stats.OwnConnections,
+		RDSAdminConnections:  stats.RDSAdminConnections,
+		ExcludedConnections:  stats.ExcludedConnections,
+		UsagePercent:         stats.UsagePercent(),
+	}
+}
+
+// NewThresholdStatus reports the configured thresholds
+func NewThresholdStatus(cfg *config.Config) ThresholdStatus {
+	return ThresholdStatus{
+		IdleWarning:     cfg.Thresholds.IdleTransaction.Warning.String(),
+		IdleCritical:    cfg.Thresholds.IdleTransaction.Critical.String(),
+		PoolWarningPct:  cfg.Thresholds.ConnectionPool.WarningPercent,
+		PoolCriticalPct: cfg.Thresholds.ConnectionPool.CriticalPercent,
+	}
+}
+
+// PoolSeverity returns "critical", "warning" or "" for the pool's usage
+func PoolSeverity(stats *postgres.PoolStats, cfg *config.Config) string {
+	usagePercent := stats.UsagePercent()
+	if usagePercent >= float64(cfg.Thresholds.ConnectionPool.CriticalPercent) {
+		return "critical"
+	}
+	if usagePercent >= float64(cfg.Thresholds.ConnectionPool.WarningPercent) {
+		return "warning"
+	}
+	return ""
+}
+
+// IdleSeverity returns "critical", "warning" or "" for an idle duration
+func IdleSeverity(duration time.Duration, cfg *config.Config) string {
+	if duration >= cfg.Thresholds.IdleTransaction.Critical {
+		return "critical"
+	}
+	if duration >= cfg.Thresholds.IdleTransaction.Warning {
+		return "warning"
+	}
+	return ""
+}
+
+// NewIdleTransactionStatus converts an idle-in-transaction connection to its API form
+func NewIdleTransactionStatus(conn *postgres.Connection, cfg *config.Config) IdleTransactionStatus {
+	duration := conn.IdleDuration()
+	return IdleTransactionStatus{
+		PID:         conn.PID,
+		SessionID:   events.NewSession(conn.PID, conn.BackendStart).ID,
+		Application: conn.ApplicationName,
+		User:        conn.Username,
+		Database:    conn.Database,
+		Duration:    util.FormatDuration(duration),
+		DurationSec: duration.Seconds(),
+		Query:       util.TruncateQuery(conn.Query, 200),
+		Fingerprint: util.QueryFingerprint(conn.Query),
+		Severity:    IdleSeverity(duration, cfg),
+	}
+}
+
+// NewConnectionStatus converts a connection to its API form
+func NewConnectionStatus(conn *postgres.Connection) ConnectionStatus {
+	duration := conn.IdleDuration()
+	return ConnectionStatus{
+		PID:          conn.PID,
+		SessionID:    events.NewSession(conn.PID, conn.BackendStart).ID,
+		BackendStart: conn.BackendStart.UTC(),
+		State:        string(conn.State),
+		Application:  conn.ApplicationName,
+		User:         conn.Username,
+		Database:     conn.Database,
+		ClientAddr:   conn.ClientAddr,
+		Duration:     util.FormatDuration(duration),
+		DurationSec:  duration.Seconds(),
+		Query:        util.TruncateQuery(conn.Query, 200),
+	}
+}
+
+// NewSummaryStatus builds the /status document
+func NewSummaryStatus(stats *postgres.PoolStats, idleCount int) SummaryStatus {
+	return SummaryStatus{
+		MaxConnections:        stats.MaxConnections,
+		Total:                 stats.TotalConnections,
+		Active:                stats.ActiveConnections,
+		Idle:                  stats.IdleConnections,
+		IdleInTransaction:     stats.IdleInTransaction,
+		Available:             stats.AvailableConnections,
+		IdleTransactionsCount: idleCount,
+	}
+}
@@ -0,0 +1,91 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Result values for audit entries
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+	ResultDenied  = "denied"
+)
+
+// Entry is a single audited action, written as one JSON line
+type Entry struct {
+	Time    time.Time              `json:"time"`
+	Action  string                 `json:"action"` // e.g. "terminate", "cancel", "rollback_prepared"
+	Target  string                 `json:"target"` // e.g. "pid:18234", "gid:order-42"
+	Actor   string                 `json:"actor"`  // OS user for the CLI, identity for the API
+	Source  string                 `json:"source"` // "cli", "daemon", "api"
+	Result  string                 `json:"result"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Logger appends audit entries to a file
+type Logger struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewLogger creates an audit logger writing to path
+func NewLogger(path string) *Logger {
+	return &Logger{path: path}
+}
+
+// Path returns the file the logger writes to
+func (l *Logger) Path() string {
+	return l.path
+}
+
+// Record appends an entry to the audit log, filling in Time and Actor if unset
+func (l *Logger) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if e.Actor == "" {
+		e.Actor = CurrentUser()
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("marshaling audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return fmt.Errorf("creating audit log directory: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+
+	return nil
+}
+
+// CurrentUser returns the name of the OS user running pguard
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
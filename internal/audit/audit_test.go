@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoggerRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "audit.log")
+	logger := NewLogger(path)
+
+	entries := []Entry{
+		{Action: "terminate", Target: "pid:1001", Source: "cli", Result: ResultSuccess},
+		{Action: "rollback_prepared", Target: "gid:tx-1", Source: "cli", Result: ResultFailed, Error: "permission denied"},
+	}
+	for _, e := range entries {
+		if err := logger.Record(e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("audit log not created: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("audit log mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var got []Entry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		got = append(got, e)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("got %d entries, want 2", len(got))
+	}
+	if got[0].Time.IsZero() {
+		t.Error("Time should be filled in")
+	}
+	if got[0].Actor == "" {
+		t.Error("Actor should be filled in")
+	}
+	if got[1].Error != "permission denied" {
+		t.Errorf("Error = %q, want %q", got[1].Error, "permission denied")
+	}
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/v0xg/pg-idle-guard/internal/audit"
+)
+
+var (
+	auditMu     sync.Mutex
+	auditLogger *audit.Logger
+)
+
+// recordAudit appends an entry to the audit log. Audit failures are logged
+// but never block the action being audited.
+func recordAudit(e audit.Entry) {
+	path, err := cfg.AuditPath()
+	if err != nil {
+		slog.Warn("failed to resolve audit log path", "error", err)
+		return
+	}
+
+	auditMu.Lock()
+	if auditLogger == nil || auditLogger.Path() != path {
+		auditLogger = audit.NewLogger(path)
+	}
+	logger := auditLogger
+	auditMu.Unlock()
+
+	if err := logger.Record(e); err != nil {
+		slog.Warn("failed to write audit log", "path", path, "error", err)
+	}
+}
+
+// auditResult maps an action's outcome to an audit result and error string
+func auditResult(err error) (result, errMsg string) {
+	if err != nil {
+		return audit.ResultFailed, err.Error()
+	}
+	return audit.ResultSuccess, ""
+}
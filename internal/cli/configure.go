@@ -296,14 +296,14 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 	slackChoice = strings.ToLower(slackChoice)
 
 	if slackChoice == "y" || slackChoice == "yes" {
-		newCfg.Alerts.Slack.Enabled = true
+		slack := config.SlackConfig{Name: "slack", Enabled: true}
 
 		fmt.Printf("Slack webhook URL: ")
 		webhookURL, urlErr := readLine(reader)
 		if urlErr != nil {
 			return urlErr
 		}
-		newCfg.Alerts.Slack.WebhookURL = webhookURL
+		slack.WebhookURL = webhookURL
 
 		fmt.Printf("Slack channel [#alerts]: ")
 		channel, chanErr := readLine(reader)
@@ -313,7 +313,7 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 		if channel == "" {
 			channel = "#alerts"
 		}
-		newCfg.Alerts.Slack.Channel = channel
+		slack.Channel = channel
 
 		fmt.Printf("Mention users on critical alerts (comma-separated, optional): ")
 		mentions, mentionErr := readLine(reader)
@@ -325,10 +325,11 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 			for _, mention := range rawMentions {
 				trimmed := strings.TrimSpace(mention)
 				if trimmed != "" {
-					newCfg.Alerts.Slack.MentionUsers = append(newCfg.Alerts.Slack.MentionUsers, trimmed)
+					slack.MentionUsers = append(slack.MentionUsers, trimmed)
 				}
 			}
 		}
+		newCfg.Alerts.Slack = config.SlackReceivers{slack}
 	}
 
 	// Webhook alerts (optional)
@@ -341,14 +342,14 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 	webhookChoice = strings.ToLower(webhookChoice)
 
 	if webhookChoice == "y" || webhookChoice == "yes" {
-		newCfg.Alerts.Webhook.Enabled = true
+		webhook := config.WebhookConfig{Name: "webhook", Enabled: true}
 
 		fmt.Printf("Webhook URL: ")
 		webhookURL, urlErr := readLine(reader)
 		if urlErr != nil {
 			return urlErr
 		}
-		newCfg.Alerts.Webhook.URL = webhookURL
+		webhook.URL = webhookURL
 
 		fmt.Printf("HTTP method [POST]: ")
 		method, methodErr := readLine(reader)
@@ -358,7 +359,7 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 		if method == "" {
 			method = "POST"
 		}
-		newCfg.Alerts.Webhook.Method = strings.ToUpper(method)
+		webhook.Method = strings.ToUpper(method)
 
 		fmt.Printf("Add custom headers? [y/N]: ")
 		headersChoice, headersErr := readLine(reader)
@@ -368,7 +369,7 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 		headersChoice = strings.ToLower(headersChoice)
 
 		if headersChoice == "y" || headersChoice == "yes" {
-			newCfg.Alerts.Webhook.Headers = make(map[string]string)
+			webhook.Headers = make(map[string]string)
 			for {
 				fmt.Printf("Header key (empty to finish): ")
 				key, keyErr := readLine(reader)
@@ -383,9 +384,10 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 				if valueErr != nil {
 					return valueErr
 				}
-				newCfg.Alerts.Webhook.Headers[key] = value
+				webhook.Headers[key] = value
 			}
 		}
+		newCfg.Alerts.Webhook = config.WebhookReceivers{webhook}
 	}
 
 	// Alert cooldown
@@ -577,15 +579,18 @@ func runConfigureTest(cmd *cobra.Command, args []string) error {
 		fmt.Println("[OK]")
 	}
 
-	// Test Slack webhook if configured
-	if cfg.Alerts.Slack.Enabled {
-		fmt.Print("Testing Slack webhook... ")
-		webhookURL := cfg.Alerts.Slack.WebhookURL
-		if webhookURL == "" {
+	// Test Slack webhooks if configured
+	for _, slack := range cfg.Alerts.Slack {
+		if !slack.Enabled {
+			continue
+		}
+		fmt.Printf("Testing Slack webhook %q... ", slack.Name)
+		webhookURL := slack.WebhookURL
+		if webhookURL == "" && len(cfg.Alerts.Slack) == 1 {
 			webhookURL = os.Getenv("SLACK_WEBHOOK_URL")
 		}
 		if webhookURL != "" {
-			slackClient := alerts.NewSlackClient(webhookURL, cfg.Alerts.Slack.Channel, nil)
+			slackClient := alerts.NewSlackClient(webhookURL, slack.Channel, nil)
 			if err := slackClient.TestConnection(); err != nil {
 				fmt.Println("[FAILED]")
 				fmt.Printf("    Error: %v\n", err)
@@ -627,10 +632,25 @@ func runConfigureShow(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 	fmt.Println("Alerts")
 	fmt.Println(strings.Repeat("-", 44))
-	if cfg.Alerts.Slack.Enabled {
-		fmt.Printf("  Slack:     enabled (%s)\n", cfg.Alerts.Slack.Channel)
-	} else {
-		fmt.Println("  Slack:     disabled")
+	if len(cfg.Alerts.Slack) == 0 && len(cfg.Alerts.Webhook) == 0 {
+		fmt.Println("  Receivers: none")
+	}
+	for _, slack := range cfg.Alerts.Slack {
+		if slack.Enabled {
+			fmt.Printf("  Slack:     %s, enabled (%s)\n", slack.Name, slack.Channel)
+		} else {
+			fmt.Printf("  Slack:     %s, disabled\n", slack.Name)
+		}
+	}
+	for _, webhook := range cfg.Alerts.Webhook {
+		if webhook.Enabled {
+			fmt.Printf("  Webhook:   %s, enabled\n", webhook.Name)
+		} else {
+			fmt.Printf("  Webhook:   %s, disabled\n", webhook.Name)
+		}
+	}
+	if len(cfg.Alerts.Routes) > 0 {
+		fmt.Printf("  Routes:    %d\n", len(cfg.Alerts.Routes))
 	}
 
 	fmt.Println()
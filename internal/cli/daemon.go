@@ -2,52 +2,118 @@ package cli
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/v0xg/pg-idle-guard/internal/alerts"
+	"github.com/v0xg/pg-idle-guard/internal/api"
+	"github.com/v0xg/pg-idle-guard/internal/audit"
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/events"
+	"github.com/v0xg/pg-idle-guard/internal/health"
+	"github.com/v0xg/pg-idle-guard/internal/owners"
 	"github.com/v0xg/pg-idle-guard/internal/postgres"
 	"github.com/v0xg/pg-idle-guard/internal/secrets"
+	"github.com/v0xg/pg-idle-guard/internal/silence"
+	"github.com/v0xg/pg-idle-guard/internal/state"
 	"github.com/v0xg/pg-idle-guard/internal/util"
+	"github.com/v0xg/pg-idle-guard/internal/web"
 )
 
-var slackClient *alerts.SlackClient
-var webhookClient *alerts.WebhookClient
+// router picks the Slack and webhook receivers for each alert; nil when
+// none are configured
+var router *alerts.Router
+
+// silences suppress alerts about known issues; kept in the state store
+var silences *silence.Manager
+
+// heartbeatClient pings dead man's switch monitors; nil when disabled
+var heartbeatClient *alerts.HeartbeatClient
+
+// eventBroker feeds /events; nil when the API is disabled
+var eventBroker *events.Broker
+
+// approvals holds protected sessions waiting for an operator to approve termination
+var approvals = api.NewApprovalQueue()
+
+// alertGroups batches per-connection alerts; nil when grouping is disabled
+var alertGroups *alerts.Grouper
+
+// digest collects activity for the periodic digest; nil when disabled
+var digest *alerts.DigestCollector
+
+// pollHealth tracks poll loop progress for /livez and /readyz
+var pollHealth *health.Monitor
 
 // alertCooldown tracks last alert times to prevent spam
 type alertCooldown struct {
-	lastPoolWarning  time.Time
-	lastPoolCritical time.Time
-	// Per-PID tracking for idle transaction alerts is handled by trackedIdle.warningSent/criticalSent
+	// last is keyed by alert kind and severity, e.g. "xmin/warning"
+	last map[string]time.Time
+	// Per-PID tracking for idle transaction alerts is handled by trackedIdle
 }
 
 var cooldown = &alertCooldown{}
 
-// canSendPoolAlert checks if enough time has passed since the last pool alert
-func (a *alertCooldown) canSendPoolAlert(severity string, cooldownDuration time.Duration) bool {
+// canSend checks if enough time has passed since the last alert of this kind and severity
+func (a *alertCooldown) canSend(kind, severity string, cooldownDuration time.Duration) bool {
+	if a.last == nil {
+		a.last = make(map[string]time.Time)
+	}
+	key := kind + "/" + severity
 	now := time.Now()
-	switch severity {
-	case alerts.SeverityWarning:
-		if now.Sub(a.lastPoolWarning) >= cooldownDuration {
-			a.lastPoolWarning = now
-			return true
-		}
-	case alerts.SeverityCritical:
-		if now.Sub(a.lastPoolCritical) >= cooldownDuration {
-			a.lastPoolCritical = now
-			return true
-		}
+	if now.Sub(a.last[key]) >= cooldownDuration {
+		a.last[key] = now
+		return true
+	}
+	return false
+}
+
+// pollFailures detects when pguard has been unable to poll for too long and
+// is no longer monitoring the database
+type pollFailures struct {
+	count   int
+	firstAt time.Time
+	alerted bool
+}
+
+// failed records a failed poll. It returns true once, when the failures reach
+// either limit and the blind alert should be sent.
+func (p *pollFailures) failed(now time.Time, limits config.PollFailureConfig) bool {
+	if p.count == 0 {
+		p.firstAt = now
+	}
+	p.count++
+	if p.alerted {
+		return false
+	}
+	if p.count >= limits.Failures || (limits.After > 0 && now.Sub(p.firstAt) >= limits.After) {
+		p.alerted = true
+		return true
 	}
 	return false
 }
 
+// succeeded resets the detector after a successful poll. recovered is true if
+// the blind alert had been sent, with how long polling was failing.
+func (p *pollFailures) succeeded(now time.Time) (recovered bool, downtime time.Duration, failures int) {
+	recovered, failures = p.alerted, p.count
+	if p.count > 0 {
+		downtime = now.Sub(p.firstAt)
+	}
+	*p = pollFailures{}
+	return recovered, downtime, failures
+}
+
 var daemonCmd = &cobra.Command{
 	Use:   "daemon",
 	Short: "Run as a background service",
@@ -75,6 +141,9 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 	}
 	defer client.Close()
 
+	// The loop is stuck if a few intervals pass without a poll finishing
+	pollHealth = health.NewMonitor(3*cfg.Polling.Interval+cfg.Polling.Timeout, cfg.API.ReadyPolls)
+
 	slog.Info("pguard daemon starting")
 	slog.Info("connected to PostgreSQL")
 	slog.Info("configuration loaded",
@@ -83,6 +152,12 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 		"critical_threshold", cfg.Thresholds.IdleTransaction.Critical,
 		"alert_cooldown", cfg.Alerts.Cooldown)
 
+	if cfg.Thresholds.XminHorizon.Enabled {
+		slog.Info("xmin horizon detection enabled",
+			"warning_age", cfg.Thresholds.XminHorizon.WarningAge,
+			"critical_age", cfg.Thresholds.XminHorizon.CriticalAge)
+	}
+
 	if cfg.AutoTerm.Enabled {
 		if cfg.AutoTerm.DryRun {
 			slog.Info("auto-terminate enabled", "mode", "dry-run")
@@ -91,61 +166,54 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if cfg.Alerts.Slack.Enabled {
-		webhookURL := cfg.Alerts.Slack.WebhookURL
-		if webhookURL == "" {
-			webhookURL = os.Getenv("SLACK_WEBHOOK_URL")
-		}
-		// Try to resolve from Secrets Manager if webhook_secret is configured
-		if webhookURL == "" && cfg.Alerts.Slack.WebhookSecret != "" {
-			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-			resolvedURL, resolveErr := secrets.ResolveWebhookSecret(ctx, cfg.Alerts.Slack.WebhookSecret, cfg.Connection.AWSRegion)
-			cancel()
-			if resolveErr != nil {
-				slog.Error("failed to resolve slack webhook from secrets manager", "error", resolveErr)
-			} else {
-				webhookURL = resolvedURL
-			}
-		}
-		if webhookURL != "" {
-			slackClient = alerts.NewSlackClient(
-				webhookURL,
-				cfg.Alerts.Slack.Channel,
-				cfg.Alerts.Slack.MentionUsers,
-			)
-			slog.Info("slack alerts enabled", "channel", cfg.Alerts.Slack.Channel)
-
-			// Send test message
-			if err := slackClient.TestConnection(); err != nil {
-				slog.Warn("slack test failed", "error", err)
-			}
-		} else {
-			slog.Warn("slack enabled but no webhook URL configured")
-		}
+	if err := loadOwnership(); err != nil {
+		return err
 	}
 
-	if cfg.Alerts.Webhook.Enabled {
-		url := cfg.Alerts.Webhook.URL
-		if url == "" {
-			url = os.Getenv("WEBHOOK_URL")
+	statePath, err := cfg.StatePath()
+	if err != nil {
+		return err
+	}
+	store, err := state.Open(statePath)
+	if err != nil {
+		return fmt.Errorf("opening state store: %w", err)
+	}
+	if silences, err = silence.NewManager(store); err != nil {
+		return err
+	}
+	pollHealth.AddComponent("state", func(context.Context) error { return store.Health() })
+	slog.Info("state store opened", "path", statePath, "active_silences", silences.Active(time.Now()))
+	if ownership != nil {
+		slog.Info("ownership loaded", "file", cfg.Owners.File, "teams", len(ownership.Teams))
+	}
+
+	if receivers := buildReceivers(); len(receivers) > 0 {
+		router = alerts.NewRouter(receivers, buildRoutes())
+		if len(cfg.Alerts.Routes) > 0 {
+			slog.Info("alert routing enabled", "routes", len(cfg.Alerts.Routes))
 		}
-		if url != "" {
-			webhookClient = alerts.NewWebhookClient(
-				url,
-				cfg.Alerts.Webhook.Method,
-				cfg.Alerts.Webhook.Headers,
-			)
-			slog.Info("webhook alerts enabled", "url", url, "method", cfg.Alerts.Webhook.Method)
+	}
 
-			// Send test message
-			if err := webhookClient.TestConnection(); err != nil {
-				slog.Warn("webhook test failed", "error", err)
-			}
-		} else {
-			slog.Warn("webhook enabled but no URL configured")
+	if cfg.Alerts.Heartbeat.Enabled {
+		targets := make([]alerts.HeartbeatTarget, len(cfg.Alerts.Heartbeat.Targets))
+		for i, t := range cfg.Alerts.Heartbeat.Targets {
+			targets[i] = alerts.HeartbeatTarget{URL: t.URL, FailURL: t.FailURL, Method: t.Method}
 		}
+		heartbeatClient = alerts.NewHeartbeatClient(targets, cfg.Alerts.Heartbeat.MinInterval)
+		slog.Info("heartbeat pings enabled", "targets", len(targets), "min_interval", cfg.Alerts.Heartbeat.MinInterval)
+	}
+
+	if cfg.Alerts.Grouping.Enabled {
+		alertGroups = alerts.NewGrouper(cfg.Alerts.Grouping.Window)
+	}
+	if cfg.Alerts.Digest.Enabled {
+		digest = alerts.NewDigestCollector(cfg.TargetName(), cfg.Alerts.Digest.Interval, time.Now())
+		slog.Info("alert digest enabled", "interval", cfg.Alerts.Digest.Interval)
 	}
 
+	registerHealthComponents(client)
+	pollHealth.SetAlertingReady()
+
 	// Handle shutdown signals
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -156,8 +224,12 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 	// Start HTTP server for health checks
 	var httpServer *http.Server
 	if cfg.API.Enabled {
-		httpServer = startHTTPServer(cfg.API.Listen, client)
-		slog.Info("HTTP API listening", "address", cfg.API.Listen)
+		eventBroker = events.NewBroker(cfg.API.EventBuffer)
+		httpServer, err = startHTTPServer(cfg.API.Listen, client)
+		if err != nil {
+			return fmt.Errorf("starting HTTP API: %w", err)
+		}
+		slog.Info("HTTP API listening", "address", cfg.API.Listen, "tls", httpServer.TLSConfig != nil)
 	}
 
 	go func() {
@@ -184,11 +256,33 @@ func runDaemon(cmd *cobra.Command, args []string) error {
 // trackedIdle keeps state for alerting
 type trackedIdle struct {
 	pid          int
+	backendStart time.Time
 	appName      string
+	username     string
+	database     string
+	clientAddr   string
 	query        string
+	fingerprint  string
 	firstSeen    time.Time
 	warningSent  bool
 	criticalSent bool
+
+	emergencySent bool
+	lastNotified  time.Time // Last alert or reminder, for re-notify
+	reminders     int
+}
+
+// severity returns the highest severity alerted so far, or ""
+func (tc *trackedIdle) severity() string {
+	switch {
+	case tc.emergencySent:
+		return alerts.SeverityEmergency
+	case tc.criticalSent:
+		return alerts.SeverityCritical
+	case tc.warningSent:
+		return alerts.SeverityWarning
+	}
+	return ""
 }
 
 func monitorLoop(ctx context.Context, client *postgres.Client) error {
@@ -196,6 +290,8 @@ func monitorLoop(ctx context.Context, client *postgres.Client) error {
 	defer ticker.Stop()
 
 	tracked := make(map[int]*trackedIdle)
+	prepared := make(map[string]*trackedPrepared)
+	failures := &pollFailures{}
 
 	for {
 		select {
@@ -203,67 +299,94 @@ func monitorLoop(ctx context.Context, client *postgres.Client) error {
 			slog.Info("daemon stopped")
 			return nil
 		case <-ticker.C:
-			if err := pollAndAlert(ctx, client, tracked); err != nil {
-				slog.Error("polling failed", "error", err)
+			pollHealth.Progress()
+			stats, err := pollAndAlert(ctx, client, tracked, prepared)
+			pollHealth.PollDone(err)
+			now := time.Now()
+			if err != nil {
+				class := postgres.ClassifyError(err)
+				slog.Error("polling failed", "error", err, "error_class", class)
+				publishEvent(events.Event{
+					Time:     now.UTC(),
+					Type:     events.TypePollFailed,
+					Severity: alerts.SeverityCritical,
+					Message:  err.Error(),
+				})
+				if digest != nil {
+					digest.RecordPollFailure()
+				}
+				if failures.failed(now, cfg.Alerts.PollFailure) {
+					since := now.Sub(failures.firstAt)
+					slog.Error("monitoring blind: cannot poll the database",
+						"error_class", class,
+						"failed_polls", failures.count,
+						"since", util.FormatDuration(since))
+					sendPollFailureAlert(class, err, failures.count, since)
+				}
+				sendHeartbeat(alerts.HeartbeatSummary{
+					Status:      alerts.HeartbeatFail,
+					FailedPolls: failures.count,
+					ErrorClass:  class,
+					Error:       err.Error(),
+				})
+			} else {
+				if recovered, downtime, count := failures.succeeded(now); recovered {
+					slog.Info("polling recovered", "failed_polls", count, "downtime", util.FormatDuration(downtime))
+					sendPollRecoveredAlert(downtime, count)
+				}
+				sendHeartbeat(alerts.HeartbeatSummary{
+					Status:           alerts.HeartbeatOK,
+					UsedConnections:  stats.UsedConnections(),
+					Capacity:         stats.Capacity(),
+					UsagePercent:     stats.UsagePercent(),
+					PoolSeverity:     poolState.severity,
+					IdleTransactions: len(tracked),
+				})
 			}
+			flushAlertGroups(now)
+			sendDigest(now)
 		}
 	}
 }
 
-func pollAndAlert(ctx context.Context, client *postgres.Client, tracked map[int]*trackedIdle) error {
+func pollAndAlert(ctx context.Context, client *postgres.Client, tracked map[int]*trackedIdle, prepared map[string]*trackedPrepared) (*postgres.PoolStats, error) {
 	queryCtx, cancel := context.WithTimeout(ctx, cfg.Polling.Timeout)
 	defer cancel()
 
 	// Get pool stats
 	stats, err := client.GetPoolStats(queryCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Check connection pool thresholds
-	usagePercent := stats.UsagePercent()
-	maxAvailable := stats.MaxConnections - stats.ReservedSuperuser
-	if usagePercent >= float64(cfg.Thresholds.ConnectionPool.CriticalPercent) {
-		slog.Error("connection pool critical",
-			"usage_percent", usagePercent,
-			"used", stats.TotalConnections,
-			"max", maxAvailable)
-		if cooldown.canSendPoolAlert(alerts.SeverityCritical, cfg.Alerts.Cooldown) {
-			sendPoolAlert(alerts.SeverityCritical, stats.TotalConnections, maxAvailable, usagePercent)
-		}
-	} else if usagePercent >= float64(cfg.Thresholds.ConnectionPool.WarningPercent) {
-		slog.Warn("connection pool warning",
-			"usage_percent", usagePercent,
-			"used", stats.TotalConnections,
-			"max", maxAvailable)
-		if cooldown.canSendPoolAlert(alerts.SeverityWarning, cfg.Alerts.Cooldown) {
-			sendPoolAlert(alerts.SeverityWarning, stats.TotalConnections, maxAvailable, usagePercent)
+	checkPoolPressure(stats)
+	if digest != nil {
+		digest.RecordPool(stats.UsagePercent(), time.Now())
+	}
+
+	// Check per-role and per-database connection limits
+	if cfg.Thresholds.RoleLimit.Enabled || cfg.Thresholds.DatabaseLimit.Enabled {
+		if err := checkConnectionLimits(queryCtx, client); err != nil {
+			slog.Warn("connection limit check failed", "error", err)
 		}
 	}
 
 	// Get idle transactions
 	conns, err := client.GetIdleTransactions(queryCtx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Track which PIDs we see
 	seenPIDs := make(map[int]bool)
+	var needApproval []*postgres.Connection
 
+	now := time.Now()
 	for _, conn := range conns {
 		seenPIDs[conn.PID] = true
 		duration := conn.IdleDuration()
-
-		tc, exists := tracked[conn.PID]
-		if !exists {
-			tc = &trackedIdle{
-				pid:       conn.PID,
-				appName:   conn.ApplicationName,
-				query:     util.TruncateQuery(conn.Query, 100),
-				firstSeen: time.Now(),
-			}
-			tracked[conn.PID] = tc
-		}
+		tc := trackIdle(tracked, conn, now)
 
 		// Check for warning threshold
 		if !tc.warningSent && duration >= cfg.Thresholds.IdleTransaction.Warning {
@@ -271,8 +394,11 @@ func pollAndAlert(ctx context.Context, client *postgres.Client, tracked map[int]
 				"pid", conn.PID,
 				"app", conn.ApplicationName,
 				"duration", util.FormatDuration(duration))
-			sendIdleTransactionAlert(alerts.SeverityWarning, conn.PID, conn.ApplicationName, duration, conn.Query)
+			notifyConnection(connectionAlert(alerts.KindIdleTransaction, alerts.SeverityWarning, conn, duration))
+			publishEvent(events.ForConnection(events.TypeIdleThreshold, alerts.SeverityWarning, conn, duration,
+				fmt.Sprintf("PID %d (%s) idle for %s", conn.PID, conn.ApplicationName, util.FormatDuration(duration))))
 			tc.warningSent = true
+			tc.lastNotified = now
 		}
 
 		// Check for critical threshold
@@ -281,64 +407,399 @@ func pollAndAlert(ctx context.Context, client *postgres.Client, tracked map[int]
 				"pid", conn.PID,
 				"app", conn.ApplicationName,
 				"duration", util.FormatDuration(duration))
-			sendIdleTransactionAlert(alerts.SeverityCritical, conn.PID, conn.ApplicationName, duration, conn.Query)
+			notifyConnection(connectionAlert(alerts.KindIdleTransaction, alerts.SeverityCritical, conn, duration))
+			publishEvent(events.ForConnection(events.TypeIdleThreshold, alerts.SeverityCritical, conn, duration,
+				fmt.Sprintf("PID %d (%s) idle for %s", conn.PID, conn.ApplicationName, util.FormatDuration(duration))))
 			tc.criticalSent = true
+			tc.lastNotified = now
 		}
 
+		// Check for emergency threshold
+		if emergency := cfg.Thresholds.IdleTransaction.Emergency; emergency > 0 && !tc.emergencySent && duration >= emergency {
+			slog.Error("idle transaction emergency",
+				"pid", conn.PID,
+				"app", conn.ApplicationName,
+				"duration", util.FormatDuration(duration))
+			notifyConnection(connectionAlert(alerts.KindIdleTransaction, alerts.SeverityEmergency, conn, duration))
+			publishEvent(events.ForConnection(events.TypeIdleThreshold, alerts.SeverityEmergency, conn, duration,
+				fmt.Sprintf("PID %d (%s) idle for %s", conn.PID, conn.ApplicationName, util.FormatDuration(duration))))
+			tc.emergencySent = true
+			tc.lastNotified = now
+		}
+
+		remindIdle(tc, conn, duration, now)
+
 		// Auto-terminate if enabled
 		if cfg.AutoTerm.Enabled && duration >= cfg.AutoTerm.After {
+			if rule := autoTermRuleFor(conn); rule.NeedsApproval && duration >= rule.After {
+				needApproval = append(needApproval, conn)
+			}
 			if shouldTerminate(conn, duration) {
 				if cfg.AutoTerm.DryRun {
 					slog.Info("dry-run: would terminate",
 						"pid", conn.PID,
 						"app", conn.ApplicationName,
 						"duration", util.FormatDuration(duration))
+					publishEvent(events.ForConnection(events.TypeDryRun, "", conn, duration,
+						fmt.Sprintf("Would terminate PID %d (%s) after %s idle", conn.PID, conn.ApplicationName, util.FormatDuration(duration))))
 				} else {
 					slog.Warn("auto-terminating connection",
 						"pid", conn.PID,
 						"app", conn.ApplicationName,
 						"duration", util.FormatDuration(duration))
-					if success, err := client.TerminateBackend(queryCtx, conn.PID); err != nil {
+					success, err := client.TerminateBackend(queryCtx, conn.PID)
+					result, errMsg := auditResult(err)
+					recordAudit(audit.Entry{
+						Action: "terminate",
+						Target: fmt.Sprintf("pid:%d", conn.PID),
+						Source: "daemon",
+						Result: result,
+						Error:  errMsg,
+						Details: map[string]interface{}{
+							"application":      conn.ApplicationName,
+							"duration_seconds": duration.Seconds(),
+							"reason":           "auto-terminate threshold exceeded",
+						},
+					})
+					if err != nil {
 						slog.Error("failed to terminate backend", "pid", conn.PID, "error", err)
 					} else if success {
-						sendTerminationAlert(conn.PID, conn.ApplicationName, duration, "auto-terminate threshold exceeded")
+						a := connectionAlert(alerts.KindTerminated, "", conn, duration)
+						a.Reason = "auto-terminate threshold exceeded"
+						notifyConnection(a)
+						publishEvent(events.ForConnection(events.TypeTerminated, "", conn, duration,
+							fmt.Sprintf("Terminated PID %d (%s) after %s idle", conn.PID, conn.ApplicationName, util.FormatDuration(duration))))
 					}
 				}
 			}
 		}
 	}
 
+	approvals.Sync(needApproval)
+
 	// Check for resolved transactions
 	for pid, tc := range tracked {
 		if !seenPIDs[pid] {
-			totalDuration := time.Since(tc.firstSeen)
-			slog.Info("idle transaction resolved",
-				"pid", pid,
-				"app", tc.appName,
-				"duration", util.FormatDuration(totalDuration))
-			// Send resolved alert if we had sent warning/critical alerts
-			if tc.warningSent || tc.criticalSent {
-				sendResolvedAlert(pid, tc.appName, totalDuration)
-			}
+			resolveIdle(tc)
 			delete(tracked, pid)
 		}
 	}
 
+	// Prepared transactions never appear as idle in transaction, so they are
+	// checked separately. Like the xmin check, failures are only logged.
+	if err := checkPreparedTransactions(queryCtx, client, prepared); err != nil {
+		slog.Warn("prepared transaction check failed", "error", err)
+	}
+
+	if cfg.Thresholds.ReplicationSlot.Enabled {
+		if err := checkReplicationSlots(queryCtx, client); err != nil {
+			slog.Warn("replication slot check failed", "error", err)
+		}
+	}
+
+	// Check what is holding back the xmin horizon. A failure here should not
+	// stop idle transaction alerting, so it is logged rather than returned.
+	if cfg.Thresholds.XminHorizon.Enabled {
+		if err := checkXminHorizon(queryCtx, client); err != nil {
+			slog.Warn("xmin horizon check failed", "error", err)
+		}
+	}
+
+	return stats, nil
+}
+
+// trackIdle returns the tracking entry for conn, creating it if needed. A
+// PID whose backend start changed is a new session that reused the PID, so
+// the old session is resolved and the new one starts without its alerts.
+func trackIdle(tracked map[int]*trackedIdle, conn *postgres.Connection, now time.Time) *trackedIdle {
+	if tc, exists := tracked[conn.PID]; exists {
+		if tc.backendStart.Equal(conn.BackendStart) {
+			return tc
+		}
+		resolveIdle(tc)
+	}
+	tc := &trackedIdle{
+		pid:          conn.PID,
+		backendStart: conn.BackendStart,
+		appName:      conn.ApplicationName,
+		username:     conn.Username,
+		database:     conn.Database,
+		clientAddr:   conn.ClientAddr,
+		query:        util.TruncateQuery(conn.Query, 100),
+		fingerprint:  util.QueryFingerprint(conn.Query),
+		firstSeen:    now,
+	}
+	tracked[conn.PID] = tc
+	return tc
+}
+
+// resolveIdle logs the end of a tracked idle transaction and, if it was
+// alerted on, sends the resolved alert and event
+func resolveIdle(tc *trackedIdle) {
+	totalDuration := time.Since(tc.firstSeen)
+	slog.Info("idle transaction resolved",
+		"pid", tc.pid,
+		"app", tc.appName,
+		"duration", util.FormatDuration(totalDuration))
+	// Send resolved alert if we had sent warning/critical alerts
+	if tc.warningSent || tc.criticalSent {
+		notifyConnection(alerts.ConnectionAlert{
+			Kind:        alerts.KindResolved,
+			Target:      cfg.TargetName(),
+			PID:         tc.pid,
+			SessionID:   events.NewSession(tc.pid, tc.backendStart).ID,
+			Application: tc.appName,
+			User:        tc.username,
+			Database:    tc.database,
+			Duration:    totalDuration,
+			Query:       tc.query,
+			Fingerprint: tc.fingerprint,
+			Owner:       ownership.Match(tc.appName, tc.username, tc.clientAddr),
+		})
+		publishEvent(events.Event{
+			Time:        time.Now().UTC(),
+			Type:        events.TypeIdleResolved,
+			Session:     events.NewSession(tc.pid, tc.backendStart),
+			Application: tc.appName,
+			User:        tc.username,
+			Database:    tc.database,
+			ClientAddr:  tc.clientAddr,
+			DurationSec: totalDuration.Seconds(),
+			Fingerprint: tc.fingerprint,
+			Message: fmt.Sprintf("Resolved: PID %d (%s) - was idle for %s",
+				tc.pid, tc.appName, util.FormatDuration(totalDuration)),
+		})
+	}
+}
+
+// remindIdle repeats the alert for the highest severity reached, with the
+// current idle duration, once its re-notify interval has passed
+func remindIdle(tc *trackedIdle, conn *postgres.Connection, duration time.Duration, now time.Time) {
+	severity := tc.severity()
+	interval := cfg.Alerts.Renotify.Interval(severity)
+	if interval <= 0 || now.Sub(tc.lastNotified) < interval {
+		return
+	}
+	tc.reminders++
+	slog.Warn("idle transaction still open",
+		"pid", conn.PID,
+		"app", conn.ApplicationName,
+		"severity", severity,
+		"duration", util.FormatDuration(duration),
+		"reminder", tc.reminders)
+	a := connectionAlert(alerts.KindIdleTransaction, severity, conn, duration)
+	a.Reminder = tc.reminders
+	notifyConnection(a)
+	tc.lastNotified = now
+}
+
+// checkConnectionLimits alerts when any single role or database nears its own
+// connection limit. Alerts repeat per role/database and severity after the cooldown.
+func checkConnectionLimits(ctx context.Context, client *postgres.Client) error {
+	if cfg.Thresholds.RoleLimit.Enabled {
+		roles, err := client.GetRoleUsage(ctx)
+		if err != nil {
+			return err
+		}
+		alertLimitUsage("role", roles, cfg.Thresholds.RoleLimit)
+	}
+
+	if cfg.Thresholds.DatabaseLimit.Enabled {
+		databases, err := client.GetDatabaseUsage(ctx)
+		if err != nil {
+			return err
+		}
+		alertLimitUsage("database", databases, cfg.Thresholds.DatabaseLimit)
+	}
+
 	return nil
 }
 
-func shouldTerminate(conn *postgres.Connection, duration time.Duration) bool {
+func alertLimitUsage(scope string, usage []*postgres.LimitUsage, t config.ConnectionLimitThresholds) {
+	for _, u := range usage {
+		severity := limitSeverity(u, t)
+		if severity == "" {
+			continue
+		}
+
+		logFn := slog.Warn
+		if severity == alerts.SeverityCritical {
+			logFn = slog.Error
+		}
+		logFn(scope+" connection limit "+severity,
+			scope, u.Name,
+			"usage_percent", u.UsagePercent(),
+			"used", u.Connections,
+			"limit", u.Limit)
+
+		if cooldown.canSend(scope+":"+u.Name, severity, cfg.Alerts.Cooldown) {
+			sendConnectionLimitAlert(severity, scope, u)
+		}
+	}
+}
+
+// trackedPrepared keeps alert state for a prepared transaction
+type trackedPrepared struct {
+	txn          *postgres.PreparedTransaction
+	warningSent  bool
+	criticalSent bool
+}
+
+// checkPreparedTransactions alerts once per severity for each prepared
+// transaction older than the configured thresholds, and again once an
+// alerted transaction is gone
+func checkPreparedTransactions(ctx context.Context, client *postgres.Client, tracked map[string]*trackedPrepared) error {
+	prepared, err := client.GetPreparedTransactions(ctx)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range prepared {
+		seen[p.GID] = true
+		age := p.Age()
+
+		tp, exists := tracked[p.GID]
+		if !exists {
+			tp = &trackedPrepared{}
+			tracked[p.GID] = tp
+		}
+		tp.txn = p
+
+		if !tp.warningSent && age >= cfg.Thresholds.PreparedXact.Warning {
+			slog.Warn("prepared transaction detected",
+				"gid", p.GID,
+				"database", p.Database,
+				"owner", p.Owner,
+				"age", util.FormatDuration(age))
+			sendPreparedTransactionAlert(alerts.SeverityWarning, p)
+			tp.warningSent = true
+		}
+
+		if !tp.criticalSent && age >= cfg.Thresholds.PreparedXact.Critical {
+			slog.Error("prepared transaction critical",
+				"gid", p.GID,
+				"database", p.Database,
+				"owner", p.Owner,
+				"age", util.FormatDuration(age))
+			sendPreparedTransactionAlert(alerts.SeverityCritical, p)
+			tp.criticalSent = true
+		}
+	}
+
+	for gid, tp := range tracked {
+		if !seen[gid] {
+			age := tp.txn.Age()
+			slog.Info("prepared transaction resolved", "gid", gid, "age", util.FormatDuration(age))
+			if tp.warningSent || tp.criticalSent {
+				sendPreparedResolvedAlert(tp.txn, age)
+			}
+			delete(tracked, gid)
+		}
+	}
+
+	return nil
+}
+
+// checkReplicationSlots alerts on slots retaining too much WAL or xmin, or
+// sitting inactive. Alerts repeat per slot and severity after the cooldown.
+func checkReplicationSlots(ctx context.Context, client *postgres.Client) error {
+	slots, err := client.GetReplicationSlots(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, slot := range slots {
+		severity, reason := slotSeverity(slot, cfg)
+		if severity == "" {
+			continue
+		}
+
+		logFn := slog.Warn
+		if severity == alerts.SeverityCritical {
+			logFn = slog.Error
+		}
+		logFn("replication slot "+severity,
+			"slot", slot.Name,
+			"active", slot.Active,
+			"retained", util.FormatBytes(slot.RetainedBytes),
+			"xmin_age", slot.XminAge,
+			"reason", reason)
+
+		if cooldown.canSend("slot:"+slot.Name, severity, cfg.Alerts.Cooldown) {
+			sendReplicationSlotAlert(severity, slot, reason)
+		}
+	}
+
+	return nil
+}
+
+// checkXminHorizon alerts when the oldest xmin holder exceeds the configured age
+func checkXminHorizon(ctx context.Context, client *postgres.Client) error {
+	horizon, err := client.GetXminHorizon(ctx)
+	if err != nil {
+		return err
+	}
+
+	oldest := horizon.Oldest()
+	if oldest == nil {
+		return nil
+	}
+
+	severity := xminSeverity(oldest.XminAge)
+	if severity == "" {
+		return nil
+	}
+
+	logFn := slog.Warn
+	if severity == alerts.SeverityCritical {
+		logFn = slog.Error
+	}
+	logFn("xmin horizon held back",
+		"severity", severity,
+		"holder", oldest.Description(),
+		"kind", oldest.Kind,
+		"xmin_age", oldest.XminAge)
+
+	if cooldown.canSend("xmin", severity, cfg.Alerts.Cooldown) {
+		sendXminHorizonAlert(severity, oldest)
+	}
+	return nil
+}
+
+// xminSeverity maps an xmin age to an alert severity, or "" if under thresholds
+func xminSeverity(age int64) string {
+	if age >= cfg.Thresholds.XminHorizon.CriticalAge {
+		return alerts.SeverityCritical
+	}
+	if age >= cfg.Thresholds.XminHorizon.WarningAge {
+		return alerts.SeverityWarning
+	}
+	return ""
+}
+
+// autoTermRule is the auto-terminate rule that applies to a connection
+type autoTermRule struct {
+	Name          string        // Config entry that decided, e.g. "exclude_apps"
+	Exempt        bool          // Never auto-terminated
+	NeedsApproval bool          // Exempt, but an operator may approve termination
+	After         time.Duration // Idle duration required on top of auto_terminate.after
+}
+
+// autoTermRuleFor returns the first auto-terminate rule matching conn:
+// exclusions first, then protected apps, then the default
+func autoTermRuleFor(conn *postgres.Connection) autoTermRule {
 	// Check exclusion list
 	for _, excluded := range cfg.AutoTerm.ExcludeApps {
 		if conn.ApplicationName == excluded {
-			return false
+			return autoTermRule{Name: "exclude_apps", Exempt: true}
 		}
 	}
 
 	// Check excluded IPs
 	for _, excludedIP := range cfg.AutoTerm.ExcludeIPs {
 		if conn.ClientAddr == excludedIP {
-			return false
+			return autoTermRule{Name: "exclude_ips", Exempt: true}
 		}
 	}
 
@@ -347,106 +808,417 @@ func shouldTerminate(conn *postgres.Connection, duration time.Duration) bool {
 		if conn.ApplicationName == protected.Name {
 			// If RequireConfirmation is set, never auto-terminate (requires manual intervention)
 			if protected.RequireConfirmation {
-				slog.Debug("skipping protected app requiring confirmation",
-					"pid", conn.PID,
-					"app", conn.ApplicationName)
-				return false
-			}
-			// Only terminate if duration exceeds the app-specific threshold
-			if duration < protected.MinIdleDuration {
-				slog.Debug("protected app under threshold",
-					"pid", conn.PID,
-					"app", conn.ApplicationName,
-					"duration", util.FormatDuration(duration),
-					"threshold", util.FormatDuration(protected.MinIdleDuration))
-				return false
+				return autoTermRule{Name: "protected_apps (require_confirmation)", Exempt: true, NeedsApproval: true, After: protected.MinIdleDuration}
 			}
-			// Duration exceeds protected app threshold, allow termination
-			slog.Info("protected app exceeded custom threshold",
-				"pid", conn.PID,
-				"app", conn.ApplicationName,
-				"duration", util.FormatDuration(duration),
-				"threshold", util.FormatDuration(protected.MinIdleDuration))
-			return true
+			return autoTermRule{Name: "protected_apps", After: protected.MinIdleDuration}
 		}
 	}
 
+	return autoTermRule{Name: "auto_terminate.after"}
+}
+
+func shouldTerminate(conn *postgres.Connection, duration time.Duration) bool {
+	rule := autoTermRuleFor(conn)
+	if rule.Exempt {
+		slog.Debug("skipping auto-terminate",
+			"pid", conn.PID,
+			"app", conn.ApplicationName,
+			"rule", rule.Name)
+		return false
+	}
+
+	// Only terminate if duration exceeds the app-specific threshold
+	if duration < rule.After {
+		slog.Debug("protected app under threshold",
+			"pid", conn.PID,
+			"app", conn.ApplicationName,
+			"duration", util.FormatDuration(duration),
+			"threshold", util.FormatDuration(rule.After))
+		return false
+	}
+
+	if rule.After > 0 {
+		slog.Info("protected app exceeded custom threshold",
+			"pid", conn.PID,
+			"app", conn.ApplicationName,
+			"duration", util.FormatDuration(duration),
+			"threshold", util.FormatDuration(rule.After))
+	}
 	return true
 }
 
-// Alert helper functions - send to all configured channels
+// publishEvent hands an event to /events subscribers, if the API is enabled
+func publishEvent(e events.Event) {
+	if eventBroker != nil {
+		eventBroker.Publish(e)
+	}
+}
 
-func sendPoolAlert(severity string, used, maxConns int, percent float64) {
-	if slackClient != nil {
-		if err := slackClient.ConnectionPoolAlert(severity, used, maxConns, percent); err != nil {
-			slog.Error("failed to send slack alert", "error", err)
-		}
+// connectionAlert describes an alert about conn
+func connectionAlert(kind, severity string, conn *postgres.Connection, duration time.Duration) alerts.ConnectionAlert {
+	return alerts.ConnectionAlert{
+		Kind:        kind,
+		Severity:    severity,
+		Target:      cfg.TargetName(),
+		PID:         conn.PID,
+		SessionID:   events.NewSession(conn.PID, conn.BackendStart).ID,
+		Application: conn.ApplicationName,
+		User:        conn.Username,
+		Database:    conn.Database,
+		Duration:    duration,
+		Query:       conn.Query,
+		Fingerprint: util.QueryFingerprint(conn.Query),
+		Owner:       ownership.Match(conn.ApplicationName, conn.Username, conn.ClientAddr),
 	}
-	if webhookClient != nil {
-		if err := webhookClient.ConnectionPoolAlert(severity, used, maxConns, percent); err != nil {
-			slog.Error("failed to send webhook alert", "error", err)
+}
+
+// notifyConnection counts a per-connection alert for the digest and sends
+// it, or queues it when grouping is enabled. Reminders aren't counted.
+func notifyConnection(a alerts.ConnectionAlert) {
+	if digest != nil && a.Reminder == 0 {
+		switch a.Kind {
+		case alerts.KindIdleTransaction:
+			digest.RecordIdle(a.Severity, a.Application, a.SessionID)
+		case alerts.KindTerminated:
+			digest.RecordTermination()
 		}
 	}
+	if router == nil || isSilenced(a.Attributes()) {
+		return
+	}
+	receivers := router.Route(a.Attributes())
+	if len(receivers) == 0 {
+		return
+	}
+	if alertGroups != nil {
+		alertGroups.Add(a, receivers, time.Now())
+		return
+	}
+	deliverConnectionAlert(a, receivers)
 }
 
-func sendIdleTransactionAlert(severity string, pid int, appName string, duration time.Duration, query string) {
-	if slackClient != nil {
-		if err := slackClient.IdleTransactionAlert(severity, pid, appName, duration, query); err != nil {
-			slog.Error("failed to send slack alert", "error", err)
-		}
+// flushAlertGroups sends the groups whose window has passed. A group of one
+// is sent as the ordinary alert.
+func flushAlertGroups(now time.Time) {
+	if alertGroups == nil {
+		return
 	}
-	if webhookClient != nil {
-		if err := webhookClient.IdleTransactionAlert(severity, pid, appName, duration, query); err != nil {
-			slog.Error("failed to send webhook alert", "error", err)
+	for _, g := range alertGroups.Flush(now) {
+		if len(g.Alerts) == 1 {
+			deliverConnectionAlert(g.Alerts[0], g.Receivers)
+			continue
 		}
+		sendTo(g.Receivers, func(n alerts.Notifier) error { return n.GroupAlert(g) })
 	}
 }
 
-func sendTerminationAlert(pid int, appName string, duration time.Duration, reason string) {
-	if slackClient != nil {
-		if err := slackClient.TerminationAlert(pid, appName, duration, reason); err != nil {
-			slog.Error("failed to send slack alert", "error", err)
+// sendDigest sends the periodic digest once its interval has passed
+func sendDigest(now time.Time) {
+	if digest == nil {
+		return
+	}
+	d, ok := digest.Take(now)
+	if !ok {
+		return
+	}
+	notify(alerts.Attributes{Kind: alerts.KindDigest, Severity: alerts.SeverityInfo},
+		func(n alerts.Notifier) error { return n.DigestAlert(d) })
+}
+
+// Alert helper functions - send to the receivers the alert routes to
+
+// notify sends an alert to the receivers it routes to
+func notify(attrs alerts.Attributes, send func(alerts.Notifier) error) {
+	attrs.Target = cfg.TargetName()
+	if router == nil || isSilenced(attrs) {
+		return
+	}
+	sendTo(router.Route(attrs), send)
+}
+
+// isSilenced reports whether an active silence suppresses the alert
+func isSilenced(attrs alerts.Attributes) bool {
+	if silences == nil {
+		return false
+	}
+	s := silences.Silenced(attrs, time.Now())
+	if s == nil {
+		return false
+	}
+	slog.Debug("alert silenced",
+		"kind", attrs.Kind,
+		"app", attrs.Application,
+		"pid", attrs.PID,
+		"silence", s.ID)
+	return true
+}
+
+// sendTo sends an alert to each receiver, logging failures. A send can take
+// up to the notifier's timeout, so each one counts as poll loop progress and
+// a slow receiver doesn't fail /livez.
+func sendTo(receivers []alerts.Receiver, send func(alerts.Notifier) error) {
+	for _, r := range receivers {
+		pollHealth.Progress()
+		err := send(r.Notifier)
+		pollHealth.Progress()
+		if err != nil {
+			slog.Error("failed to send "+r.Kind+" alert", "receiver", r.Name, "error", err)
 		}
 	}
-	if webhookClient != nil {
-		if err := webhookClient.TerminationAlert(pid, appName, duration, reason); err != nil {
-			slog.Error("failed to send webhook alert", "error", err)
+}
+
+func deliverConnectionAlert(a alerts.ConnectionAlert, receivers []alerts.Receiver) {
+	sendTo(receivers, func(n alerts.Notifier) error {
+		switch a.Kind {
+		case alerts.KindIdleTransaction:
+			return n.IdleTransactionAlert(a.Severity, a.PID, a.Application, a.Duration, a.Query, a.Owner, a.Reminder)
+		case alerts.KindTerminated:
+			return n.TerminationAlert(a.PID, a.Application, a.Duration, a.Reason, a.Owner)
+		case alerts.KindResolved:
+			return n.ResolvedAlert(a.PID, a.Application, a.Duration, a.Owner)
 		}
+		return nil
+	})
+}
+
+func sendPoolAlert(severity string, used, maxConns int, percent float64, firingSince time.Time) {
+	notify(alerts.Attributes{Kind: alerts.KindConnectionPool, Severity: severity},
+		func(n alerts.Notifier) error {
+			return n.ConnectionPoolAlert(severity, used, maxConns, percent, firingSince)
+		})
+}
+
+func sendPoolResolvedAlert(peakUsed, maxConns int, peakPercent float64, duration time.Duration) {
+	notify(alerts.Attributes{Kind: alerts.KindPoolResolved, Severity: alerts.SeverityResolved},
+		func(n alerts.Notifier) error {
+			return n.PoolResolvedAlert(peakUsed, maxConns, peakPercent, duration)
+		})
+}
+
+func sendXminHorizonAlert(severity string, holder *postgres.XminHolder) {
+	attrs := alerts.Attributes{
+		Kind:        alerts.KindXminHorizon,
+		Severity:    severity,
+		Application: holder.ApplicationName,
+		User:        holder.Username,
+		Database:    holder.Database,
+	}
+	owner := ownership.Match(holder.ApplicationName, holder.Username, "")
+	notify(attrs, func(n alerts.Notifier) error {
+		return n.XminHorizonAlert(severity, string(holder.Kind), holder.Description(), holder.PID, holder.XminAge, owner)
+	})
+}
+
+func sendPreparedTransactionAlert(severity string, p *postgres.PreparedTransaction) {
+	attrs := alerts.Attributes{
+		Kind:     alerts.KindPreparedTransaction,
+		Severity: severity,
+		User:     p.Owner,
+		Database: p.Database,
+	}
+	team := ownership.Match("", p.Owner, "")
+	notify(attrs, func(n alerts.Notifier) error {
+		return n.PreparedTransactionAlert(severity, p.GID, p.Owner, p.Database, p.Age(), team)
+	})
+}
+
+func sendPreparedResolvedAlert(p *postgres.PreparedTransaction, age time.Duration) {
+	attrs := alerts.Attributes{
+		Kind:     alerts.KindPreparedResolved,
+		Severity: alerts.SeverityResolved,
+		User:     p.Owner,
+		Database: p.Database,
 	}
+	team := ownership.Match("", p.Owner, "")
+	notify(attrs, func(n alerts.Notifier) error {
+		return n.PreparedResolvedAlert(p.GID, p.Owner, p.Database, age, team)
+	})
 }
 
-func sendResolvedAlert(pid int, appName string, duration time.Duration) {
-	if slackClient != nil {
-		if err := slackClient.ResolvedAlert(pid, appName, duration); err != nil {
-			slog.Error("failed to send slack alert", "error", err)
+func sendReplicationSlotAlert(severity string, slot *postgres.ReplicationSlot, reason string) {
+	attrs := alerts.Attributes{
+		Kind:     alerts.KindReplicationSlot,
+		Severity: severity,
+		Database: slot.Database,
+	}
+	notify(attrs, func(n alerts.Notifier) error {
+		return n.ReplicationSlotAlert(severity, slot.Name, slot.SlotType, slot.Database, slot.Active, slot.RetainedBytes, slot.XminAge, reason)
+	})
+}
+
+func sendConnectionLimitAlert(severity, scope string, u *postgres.LimitUsage) {
+	attrs := alerts.Attributes{Kind: alerts.KindConnectionLimit, Severity: severity}
+	var owner *owners.Team
+	switch scope {
+	case "role":
+		attrs.User = u.Name
+		owner = ownership.Match("", u.Name, "")
+	case "database":
+		attrs.Database = u.Name
+	}
+	notify(attrs, func(n alerts.Notifier) error {
+		return n.ConnectionLimitAlert(severity, scope, u.Name, u.Connections, u.Limit, u.UsagePercent(), owner)
+	})
+}
+
+// sendHeartbeat pings heartbeat targets in the background so a slow
+// endpoint can't hold up polling
+func sendHeartbeat(summary alerts.HeartbeatSummary) {
+	if heartbeatClient == nil {
+		return
+	}
+	summary.Timestamp = time.Now().UTC().Format(time.RFC3339)
+	go func() {
+		if err := heartbeatClient.Beat(summary); err != nil {
+			slog.Warn("heartbeat ping failed", "error", err)
 		}
+	}()
+}
+
+func sendPollFailureAlert(errorClass string, pollErr error, failures int, since time.Duration) {
+	notify(alerts.Attributes{Kind: alerts.KindPollFailure, Severity: alerts.SeverityCritical},
+		func(n alerts.Notifier) error {
+			return n.PollFailureAlert(errorClass, pollErr.Error(), failures, since)
+		})
+}
+
+func sendPollRecoveredAlert(downtime time.Duration, failures int) {
+	notify(alerts.Attributes{Kind: alerts.KindPollRecovered, Severity: alerts.SeverityResolved},
+		func(n alerts.Notifier) error { return n.PollRecoveredAlert(downtime, failures) })
+}
+
+// registerHealthComponents adds the dependencies reported by /healthz
+func registerHealthComponents(client *postgres.Client) {
+	pollHealth.AddComponent("database", client.Ping)
+	if router != nil {
+		for _, r := range router.Receivers() {
+			n := r.Notifier
+			pollHealth.AddComponent(r.Kind+"/"+r.Name, func(context.Context) error { return n.Health() })
+		}
+	}
+	if heartbeatClient != nil {
+		pollHealth.AddComponent("heartbeat", func(context.Context) error { return heartbeatClient.Health() })
+	}
+}
+
+// serveMetrics writes the daemon's own metrics in the Prometheus text format
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	p := newPromWriter(w)
+
+	p.gauge("pguard_silences_active", "Alert silences in effect.", float64(silences.Active(time.Now())))
+	counts := silences.Counts()
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
 	}
-	if webhookClient != nil {
-		if err := webhookClient.ResolvedAlert(pid, appName, duration); err != nil {
-			slog.Error("failed to send webhook alert", "error", err)
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		p.counter("pguard_alerts_silenced_total", "Alerts suppressed by a silence since the daemon started.",
+			float64(counts[kind]), "kind", kind)
+	}
+}
+
+// loadAPITokens reads API bearer tokens from the configured file or secret
+func loadAPITokens() ([]api.Token, error) {
+	if cfg.API.Auth.TokensFile != "" {
+		return api.LoadTokensFile(cfg.API.Auth.TokensFile)
+	}
+	if cfg.API.Auth.TokensSecret != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		data, err := secrets.ResolveSecretString(ctx, cfg.API.Auth.TokensSecret, cfg.Connection.AWSRegion)
+		if err != nil {
+			return nil, fmt.Errorf("resolving API tokens secret: %w", err)
 		}
+		return api.ParseTokens([]byte(data))
+	}
+	return nil, nil
+}
+
+// isLoopbackListen returns true if listen only accepts local connections
+func isLoopbackListen(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
 	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
 }
 
-func startHTTPServer(listen string, client *postgres.Client) *http.Server {
+func startHTTPServer(listen string, client *postgres.Client) (*http.Server, error) {
+	tokens, err := loadAPITokens()
+	if err != nil {
+		return nil, err
+	}
+	auth := api.NewAuthorizer(tokens, recordAudit)
+	// Without tokens anyone who can reach the listener could terminate
+	// sessions, so only loopback gets the operator routes
+	readOnly := !auth.Enabled() && !isLoopbackListen(listen)
+	if auth.Enabled() {
+		slog.Info("API token authentication enabled", "tokens", len(tokens))
+	} else if readOnly {
+		slog.Warn("API has no tokens configured and is not on loopback; operator endpoints are disabled",
+			"address", listen)
+	}
+
 	mux := http.NewServeMux()
 
-	// Health check
+	// Health checks. /health is kept for existing probes; it fails whenever
+	// the database is down, so it makes a poor liveness probe. Like the other
+	// unauthenticated probes it doesn't echo the error, which names the host
+	// and user.
 	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
 
 		if err := client.Ping(ctx); err != nil {
+			slog.Warn("health check failed", "error", err)
 			w.WriteHeader(http.StatusServiceUnavailable)
-			fmt.Fprintf(w, "unhealthy: %v", err)
+			fmt.Fprint(w, "unhealthy: database failing")
 			return
 		}
 		w.WriteHeader(http.StatusOK)
 		fmt.Fprint(w, "ok")
 	})
+	mux.HandleFunc("/livez", pollHealth.Livez)
+	mux.HandleFunc("/readyz", pollHealth.Readyz)
+	// Component errors can name hosts, so only the verbose report, which
+	// needs a token, includes them
+	verboseHealth := auth.Require(api.RoleViewer, http.HandlerFunc(pollHealth.Healthz))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("verbose") {
+			verboseHealth.ServeHTTP(w, r)
+			return
+		}
+		pollHealth.Healthz(w, r)
+	})
+
+	// Prometheus metrics about the daemon itself
+	mux.Handle("GET /metrics", auth.Require(api.RoleViewer, http.HandlerFunc(serveMetrics)))
+
+	// Event stream
+	mux.Handle("/events", auth.Require(api.RoleViewer, events.SSEHandler(eventBroker)))
+
+	// Versioned API
+	signal := func(ctx context.Context, conn *postgres.Connection, cancelOnly bool) (bool, error) {
+		return signalBackend(ctx, client, conn, cancelOnly, "api", api.Identity(ctx))
+	}
+	apiServer := api.NewServer(client, cfg, ownership, signal, auth, approvals, silences)
+	if readOnly {
+		apiServer.ReadOnly()
+	}
+	apiServer.Register(mux)
+
+	// Dashboard. The assets are public; the data behind them needs a token.
+	mux.Handle(web.Prefix, web.Handler())
+	mux.Handle("GET /{$}", http.RedirectHandler(web.Prefix, http.StatusFound))
 
 	// Status endpoint
-	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+	mux.Handle("/status", auth.Require(api.RoleViewer, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
 		defer cancel()
 
@@ -460,16 +1232,10 @@ func startHTTPServer(listen string, client *postgres.Client) *http.Server {
 		idle, _ := client.GetIdleTransactions(ctx)
 
 		w.Header().Set("Content-Type", "application/json")
-		fmt.Fprintf(w, `{"max_connections":%d,"total":%d,"active":%d,"idle":%d,"idle_in_transaction":%d,"available":%d,"idle_transactions_count":%d}`,
-			stats.MaxConnections,
-			stats.TotalConnections,
-			stats.ActiveConnections,
-			stats.IdleConnections,
-			stats.IdleInTransaction,
-			stats.AvailableConnections,
-			len(idle),
-		)
-	})
+		if err := json.NewEncoder(w).Encode(api.NewSummaryStatus(stats, len(idle))); err != nil {
+			slog.Warn("writing status response failed", "error", err)
+		}
+	})))
 
 	server := &http.Server{
 		Addr:         listen,
@@ -477,12 +1243,34 @@ func startHTTPServer(listen string, client *postgres.Client) *http.Server {
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
+	// Shutdown doesn't cancel request contexts, so end /events streams itself
+	server.RegisterOnShutdown(eventBroker.Close)
+
+	if cfg.API.TLS.CertFile != "" {
+		tlsConfig, err := api.NewTLSConfig(cfg.API.TLS.CertFile, cfg.API.TLS.KeyFile, cfg.API.TLS.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		server.TLSConfig = tlsConfig
+	}
+
+	// Bind before returning so a busy port fails startup instead of being logged
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", listen, err)
+	}
 
 	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if server.TLSConfig != nil {
+			err = server.ServeTLS(listener, "", "")
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			slog.Error("HTTP server error", "error", err)
 		}
 	}()
 
-	return server
+	return server, nil
 }
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/alerts"
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/events"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// poolState is the daemon's connection pool alert
+var poolState = &poolPressure{}
+
+// poolPressure is the connection pool alert. It fires once usage has stayed
+// over a threshold for MinDuration, moves between warning and critical, and
+// clears only once usage drops below the clear thresholds, so usage hovering
+// at a threshold doesn't flap.
+type poolPressure struct {
+	severity     string    // "" when not firing
+	firingSince  time.Time // When usage first crossed the threshold that fired
+	pendingSince time.Time // When usage first called for a higher severity
+	peakPercent  float64
+	peakUsed     int
+}
+
+// poolTransition is a change of the pool alert's severity. previous is "" when
+// the alert fires; severity is "" when it resolves.
+type poolTransition struct {
+	severity    string
+	previous    string
+	firingSince time.Time
+	duration    time.Duration // Since firingSince
+	peakPercent float64
+	peakUsed    int
+}
+
+// target returns the severity usage calls for, given the current severity
+func (p *poolPressure) target(percent float64, t config.ConnectionPoolThresholds) string {
+	switch {
+	case percent >= float64(t.CriticalPercent):
+		return alerts.SeverityCritical
+	case p.severity == alerts.SeverityCritical && percent >= t.CriticalClear():
+		return alerts.SeverityCritical
+	case percent >= float64(t.WarningPercent):
+		return alerts.SeverityWarning
+	case p.severity != "" && percent >= t.WarningClear():
+		return alerts.SeverityWarning
+	}
+	return ""
+}
+
+// update feeds one poll's usage into the alert and returns the transition it
+// caused, or nil. Firing and escalating wait for t.MinDuration; de-escalating
+// and resolving happen at once, since the clear thresholds already damp them.
+func (p *poolPressure) update(used int, percent float64, now time.Time, t config.ConnectionPoolThresholds) *poolTransition {
+	target := p.target(percent, t)
+	var tr *poolTransition
+
+	switch {
+	case target == p.severity:
+		p.pendingSince = time.Time{}
+
+	case severityRank(target) > severityRank(p.severity):
+		if p.pendingSince.IsZero() {
+			p.pendingSince = now
+		}
+		if now.Sub(p.pendingSince) < t.MinDuration {
+			break
+		}
+		if p.severity == "" {
+			p.firingSince = p.pendingSince
+			p.peakPercent, p.peakUsed = 0, 0
+		}
+		tr = &poolTransition{severity: target, previous: p.severity}
+		p.severity = target
+		p.pendingSince = time.Time{}
+
+	default:
+		tr = &poolTransition{severity: target, previous: p.severity}
+		p.severity = target
+		p.pendingSince = time.Time{}
+	}
+
+	if tr != nil || p.severity != "" {
+		if percent > p.peakPercent {
+			p.peakPercent, p.peakUsed = percent, used
+		}
+	}
+	if tr != nil {
+		tr.firingSince = p.firingSince
+		tr.duration = now.Sub(p.firingSince)
+		tr.peakPercent, tr.peakUsed = p.peakPercent, p.peakUsed
+	}
+	if p.severity == "" {
+		p.firingSince = time.Time{}
+	}
+	return tr
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case alerts.SeverityCritical:
+		return 2
+	case alerts.SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// checkPoolPressure updates the pool alert and notifies on any transition
+func checkPoolPressure(stats *postgres.PoolStats) {
+	used, capacity, percent := stats.UsedConnections(), stats.Capacity(), stats.UsagePercent()
+	tr := poolState.update(used, percent, time.Now(), cfg.Thresholds.ConnectionPool)
+	if tr == nil {
+		return
+	}
+
+	if tr.severity == "" {
+		slog.Info("connection pool pressure resolved",
+			"peak_percent", tr.peakPercent,
+			"duration", util.FormatDuration(tr.duration))
+		sendPoolResolvedAlert(tr.peakUsed, capacity, tr.peakPercent, tr.duration)
+		publishEvent(events.ForPool("", tr.previous, stats,
+			fmt.Sprintf("Connection pool pressure resolved after %s, peak %.0f%%",
+				util.FormatDuration(tr.duration), tr.peakPercent)))
+		return
+	}
+
+	change := "firing"
+	switch {
+	case tr.previous == "":
+	case severityRank(tr.severity) > severityRank(tr.previous):
+		change = "escalated"
+	default:
+		change = "de-escalated"
+	}
+	log := slog.Warn
+	if tr.severity == alerts.SeverityCritical {
+		log = slog.Error
+	}
+	log("connection pool "+tr.severity,
+		"change", change,
+		"usage_percent", percent,
+		"used", used,
+		"max", capacity,
+		"firing_since", tr.firingSince)
+	sendPoolAlert(tr.severity, used, capacity, percent, tr.firingSince)
+	publishEvent(events.ForPool(tr.severity, tr.previous, stats,
+		fmt.Sprintf("Connection pool %s (%s): %d/%d (%.0f%%)", tr.severity, change, used, capacity, percent)))
+}
@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+)
+
+func TestPoolPressure(t *testing.T) {
+	thresholds := config.ConnectionPoolThresholds{
+		WarningPercent:       75,
+		CriticalPercent:      90,
+		WarningClearPercent:  70,
+		CriticalClearPercent: 85,
+	}
+
+	// Each step is one poll, 10s apart. want is the transition as
+	// "previous->severity", or "" for none.
+	tests := []struct {
+		name        string
+		minDuration time.Duration
+		usage       []float64
+		want        []string
+	}{
+		{
+			name:  "fires, escalates, de-escalates and resolves",
+			usage: []float64{50, 80, 95, 88, 80, 72, 60},
+			want:  []string{"", "->warning", "warning->critical", "", "critical->warning", "", "warning->"},
+		},
+		{
+			name:  "no flapping around the warning threshold",
+			usage: []float64{76, 74, 76, 73, 71, 69},
+			want:  []string{"->warning", "", "", "", "", "warning->"},
+		},
+		{
+			name:  "critical holds until under its clear threshold",
+			usage: []float64{92, 89, 86, 84},
+			want:  []string{"->critical", "", "", "critical->warning"},
+		},
+		{
+			name:        "waits for the minimum duration",
+			minDuration: 30 * time.Second,
+			usage:       []float64{80, 80, 80, 80, 60},
+			want:        []string{"", "", "", "->warning", "warning->"},
+		},
+		{
+			name:        "short spike never fires",
+			minDuration: 30 * time.Second,
+			usage:       []float64{95, 95, 50, 50},
+			want:        []string{"", "", "", ""},
+		},
+		{
+			name:        "resolves from critical in one step",
+			minDuration: 0,
+			usage:       []float64{95, 40},
+			want:        []string{"->critical", "critical->"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			th := thresholds
+			th.MinDuration = tt.minDuration
+			p := &poolPressure{}
+			start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+			for i, usage := range tt.usage {
+				tr := p.update(int(usage), usage, start.Add(time.Duration(i)*10*time.Second), th)
+				got := ""
+				if tr != nil {
+					got = tr.previous + "->" + tr.severity
+				}
+				if got != tt.want[i] {
+					t.Errorf("poll %d (%.0f%%): transition %q, want %q", i, usage, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPoolPressureResolvedSummary(t *testing.T) {
+	thresholds := config.ConnectionPoolThresholds{WarningPercent: 75, CriticalPercent: 90}
+	p := &poolPressure{}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	p.update(80, 80, start, thresholds)
+	p.update(93, 93, start.Add(time.Minute), thresholds)
+	p.update(78, 78, start.Add(2*time.Minute), thresholds)
+	tr := p.update(40, 40, start.Add(5*time.Minute), thresholds)
+
+	if tr == nil || tr.severity != "" {
+		t.Fatalf("expected resolved transition, got %+v", tr)
+	}
+	if tr.peakPercent != 93 || tr.peakUsed != 93 {
+		t.Errorf("peak = %.0f%% (%d), want 93%% (93)", tr.peakPercent, tr.peakUsed)
+	}
+	if tr.duration != 5*time.Minute {
+		t.Errorf("duration = %s, want 5m", tr.duration)
+	}
+	if !tr.firingSince.Equal(start) {
+		t.Errorf("firingSince = %s, want %s", tr.firingSince, start)
+	}
+	if !p.firingSince.IsZero() || p.severity != "" {
+		t.Errorf("state not reset after resolving: %+v", p)
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/alerts"
+	"github.com/v0xg/pg-idle-guard/internal/secrets"
+)
+
+// buildReceivers creates a notifier for each enabled Slack and webhook
+// receiver and sends each a test message. The SLACK_WEBHOOK_URL and
+// WEBHOOK_URL environment variables only fill in for a lone receiver.
+func buildReceivers() []alerts.Receiver {
+	var receivers []alerts.Receiver
+
+	for _, s := range cfg.Alerts.Slack {
+		if !s.Enabled {
+			continue
+		}
+		webhookURL := s.WebhookURL
+		if webhookURL == "" && len(cfg.Alerts.Slack) == 1 {
+			webhookURL = os.Getenv("SLACK_WEBHOOK_URL")
+		}
+		// Try to resolve from Secrets Manager if webhook_secret is configured
+		if webhookURL == "" && s.WebhookSecret != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			resolvedURL, resolveErr := secrets.ResolveWebhookSecret(ctx, s.WebhookSecret, cfg.Connection.AWSRegion)
+			cancel()
+			if resolveErr != nil {
+				slog.Error("failed to resolve slack webhook from secrets manager", "receiver", s.Name, "error", resolveErr)
+			} else {
+				webhookURL = resolvedURL
+			}
+		}
+		if webhookURL == "" {
+			slog.Warn("slack enabled but no webhook URL configured", "receiver", s.Name)
+			continue
+		}
+
+		client := alerts.NewSlackClient(webhookURL, s.Channel, s.MentionUsers)
+		client.EmergencyMentions = s.EmergencyMentions
+		slog.Info("slack alerts enabled", "receiver", s.Name, "channel", s.Channel)
+		// Send test message
+		if err := client.TestConnection(); err != nil {
+			slog.Warn("slack test failed", "receiver", s.Name, "error", err)
+		}
+		receivers = append(receivers, alerts.Receiver{Name: s.Name, Kind: "slack", Notifier: client})
+	}
+
+	for _, w := range cfg.Alerts.Webhook {
+		if !w.Enabled {
+			continue
+		}
+		url := w.URL
+		if url == "" && len(cfg.Alerts.Webhook) == 1 {
+			url = os.Getenv("WEBHOOK_URL")
+		}
+		if url == "" {
+			slog.Warn("webhook enabled but no URL configured", "receiver", w.Name)
+			continue
+		}
+
+		client := alerts.NewWebhookClient(url, w.Method, w.Headers)
+		slog.Info("webhook alerts enabled", "receiver", w.Name, "url", url, "method", w.Method)
+		// Send test message
+		if err := client.TestConnection(); err != nil {
+			slog.Warn("webhook test failed", "receiver", w.Name, "error", err)
+		}
+		receivers = append(receivers, alerts.Receiver{Name: w.Name, Kind: "webhook", Notifier: client})
+	}
+
+	return receivers
+}
+
+// buildRoutes converts the configured routes for the router
+func buildRoutes() []alerts.Route {
+	routes := make([]alerts.Route, len(cfg.Alerts.Routes))
+	for i, r := range cfg.Alerts.Routes {
+		routes[i] = alerts.Route{
+			Kinds:      r.Types,
+			Severities: r.Severities,
+			App:        r.App,
+			User:       r.User,
+			Database:   r.Database,
+			Target:     r.Target,
+			Receivers:  r.Receivers,
+			Continue:   r.Continue,
+		}
+	}
+	return routes
+}
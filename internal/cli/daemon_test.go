@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/v0xg/pg-idle-guard/internal/alerts"
 	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/events"
 	"github.com/v0xg/pg-idle-guard/internal/postgres"
 	"github.com/v0xg/pg-idle-guard/internal/util"
 )
@@ -59,6 +61,28 @@ func TestFormatDuration(t *testing.T) {
 	}
 }
 
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KB"},
+		{1536, "1.5 KB"},
+		{5 << 20, "5.0 MB"},
+		{3 << 30, "3.0 GB"},
+		{2 << 40, "2.0 TB"},
+		{2048 << 40, "2048.0 TB"},
+	}
+
+	for _, tt := range tests {
+		if got := util.FormatBytes(tt.bytes); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.bytes, got, tt.want)
+		}
+	}
+}
+
 func TestTruncateQuery(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -108,6 +132,26 @@ func TestTruncateQuery(t *testing.T) {
 	}
 }
 
+func TestQueryFingerprint(t *testing.T) {
+	same := [][2]string{
+		{"SELECT * FROM orders WHERE id = 42", "select *\n  from orders where id = 7"},
+		{"UPDATE t SET name = 'bob' WHERE id = $1", "UPDATE t SET name = 'it''s' WHERE id = $2"},
+		{"SELECT 1 FROM t WHERE id IN (1, 2, 3)", "SELECT 1 FROM t WHERE id IN (4)"},
+	}
+	for _, pair := range same {
+		if util.QueryFingerprint(pair[0]) != util.QueryFingerprint(pair[1]) {
+			t.Errorf("fingerprints differ for %q and %q", pair[0], pair[1])
+		}
+	}
+
+	if util.QueryFingerprint("SELECT * FROM orders") == util.QueryFingerprint("SELECT * FROM orders1") {
+		t.Error("different tables should not share a fingerprint")
+	}
+	if got := util.QueryFingerprint("  "); got != "" {
+		t.Errorf("QueryFingerprint(blank) = %q, want empty", got)
+	}
+}
+
 func TestShouldTerminate(t *testing.T) {
 	// Save original config and restore after test
 	originalCfg := cfg
@@ -330,6 +374,102 @@ func TestTrackedIdle(t *testing.T) {
 	}
 }
 
+func TestTrackedIdleSeverity(t *testing.T) {
+	tests := []struct {
+		name string
+		tc   trackedIdle
+		want string
+	}{
+		{"nothing sent", trackedIdle{}, ""},
+		{"warning", trackedIdle{warningSent: true}, alerts.SeverityWarning},
+		{"critical", trackedIdle{warningSent: true, criticalSent: true}, alerts.SeverityCritical},
+		{"emergency", trackedIdle{warningSent: true, criticalSent: true, emergencySent: true}, alerts.SeverityEmergency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tc.severity(); got != tt.want {
+				t.Errorf("severity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrackIdlePIDReuse(t *testing.T) {
+	originalCfg, originalBroker := cfg, eventBroker
+	defer func() { cfg, eventBroker = originalCfg, originalBroker }()
+	cfg = config.DefaultConfig()
+	eventBroker = events.NewBroker(10)
+
+	now := time.Now()
+	oldStart := now.Add(-time.Hour)
+	tracked := map[int]*trackedIdle{
+		4242: {pid: 4242, backendStart: oldStart, appName: "billing", firstSeen: now.Add(-5 * time.Minute),
+			warningSent: true, criticalSent: true, lastNotified: now.Add(-time.Minute)},
+	}
+
+	same := trackIdle(tracked, &postgres.Connection{PID: 4242, BackendStart: oldStart, ApplicationName: "billing"}, now)
+	if !same.warningSent {
+		t.Fatal("same session lost its alert state")
+	}
+	if got := eventBroker.Since(0); len(got) != 0 {
+		t.Fatalf("events = %v, want none for the same session", got)
+	}
+
+	newStart := now.Add(-time.Second)
+	tc := trackIdle(tracked, &postgres.Connection{PID: 4242, BackendStart: newStart, ApplicationName: "worker"}, now)
+	if tc.warningSent || tc.criticalSent || !tc.lastNotified.IsZero() {
+		t.Errorf("new session inherited alert state: %+v", tc)
+	}
+	if tracked[4242] != tc || !tc.backendStart.Equal(newStart) || tc.appName != "worker" {
+		t.Errorf("tracked = %+v, want the new session", tracked[4242])
+	}
+
+	got := eventBroker.Since(0)
+	if len(got) != 1 || got[0].Type != events.TypeIdleResolved || got[0].Application != "billing" ||
+		got[0].Session.ID != events.NewSession(4242, oldStart).ID {
+		t.Errorf("events = %+v, want the old session resolved", got)
+	}
+}
+
+func TestRemindIdle(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+	cfg = config.DefaultConfig()
+	cfg.Alerts.Renotify = config.RenotifyConfig{Critical: 15 * time.Minute}
+
+	now := time.Now()
+	conn := &postgres.Connection{PID: 4242, ApplicationName: "billing"}
+
+	tests := []struct {
+		name          string
+		tc            trackedIdle
+		wantReminders int
+	}{
+		{"interval passed", trackedIdle{warningSent: true, criticalSent: true, lastNotified: now.Add(-16 * time.Minute)}, 1},
+		{"interval not passed", trackedIdle{warningSent: true, criticalSent: true, lastNotified: now.Add(-10 * time.Minute)}, 0},
+		{"no interval for warning", trackedIdle{warningSent: true, lastNotified: now.Add(-time.Hour)}, 0},
+		{"nothing sent", trackedIdle{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := tt.tc
+			lastNotified := tc.lastNotified
+			remindIdle(&tc, conn, time.Hour, now)
+			if tc.reminders != tt.wantReminders {
+				t.Errorf("reminders = %d, want %d", tc.reminders, tt.wantReminders)
+			}
+			if tt.wantReminders > 0 && !tc.lastNotified.Equal(now) {
+				t.Errorf("lastNotified = %v, want %v", tc.lastNotified, now)
+			}
+			if tt.wantReminders == 0 && !tc.lastNotified.Equal(lastNotified) {
+				t.Error("lastNotified changed without a reminder")
+			}
+		})
+	}
+}
+
 func TestReadLine(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -379,3 +519,121 @@ func TestReadLine(t *testing.T) {
 		})
 	}
 }
+
+func TestAlertCooldown(t *testing.T) {
+	c := &alertCooldown{}
+
+	if !c.canSend("xmin", "warning", time.Minute) {
+		t.Error("first xmin warning should be sent")
+	}
+	if c.canSend("xmin", "warning", time.Minute) {
+		t.Error("second xmin warning within cooldown should be suppressed")
+	}
+	if !c.canSend("xmin", "critical", time.Minute) {
+		t.Error("xmin critical should not share cooldown with warning")
+	}
+	if !c.canSend("slot", "warning", time.Minute) {
+		t.Error("slot warning should not share cooldown with xmin warning")
+	}
+	if !c.canSend("xmin", "warning", 0) {
+		t.Error("zero cooldown should always allow sending")
+	}
+}
+
+func TestPollFailures(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		limits    config.PollFailureConfig
+		failures  int
+		interval  time.Duration
+		wantAlert int // 1-based poll that alerts, 0 for none
+	}{
+		{"reaches failure count", config.PollFailureConfig{Failures: 3, After: time.Hour}, 5, 10 * time.Second, 3},
+		{"reaches duration first", config.PollFailureConfig{Failures: 100, After: time.Minute}, 10, 20 * time.Second, 4},
+		{"below both limits", config.PollFailureConfig{Failures: 3, After: time.Minute}, 2, 10 * time.Second, 0},
+		{"duration disabled", config.PollFailureConfig{Failures: 5, After: 0}, 4, time.Hour, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pollFailures{}
+			alertedAt := 0
+			for i := 0; i < tt.failures; i++ {
+				if p.failed(start.Add(time.Duration(i)*tt.interval), tt.limits) {
+					if alertedAt != 0 {
+						t.Fatalf("alerted twice, at poll %d and %d", alertedAt, i+1)
+					}
+					alertedAt = i + 1
+				}
+			}
+			if alertedAt != tt.wantAlert {
+				t.Errorf("alerted at poll %d, want %d", alertedAt, tt.wantAlert)
+			}
+
+			recovered, _, count := p.succeeded(start.Add(time.Hour))
+			if recovered != (tt.wantAlert != 0) {
+				t.Errorf("recovered = %v, want %v", recovered, tt.wantAlert != 0)
+			}
+			if count != tt.failures {
+				t.Errorf("failures = %d, want %d", count, tt.failures)
+			}
+			if recovered, _, _ := p.succeeded(start.Add(2 * time.Hour)); recovered {
+				t.Error("second success should not report recovery again")
+			}
+		})
+	}
+}
+
+func TestXminSeverity(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+
+	cfg = &config.Config{
+		Thresholds: config.ThresholdsConfig{
+			XminHorizon: config.XminHorizonThresholds{
+				Enabled:     true,
+				WarningAge:  1000,
+				CriticalAge: 5000,
+			},
+		},
+	}
+
+	tests := []struct {
+		age  int64
+		want string
+	}{
+		{0, ""},
+		{999, ""},
+		{1000, "warning"},
+		{4999, "warning"},
+		{5000, "critical"},
+		{1 << 40, "critical"},
+	}
+
+	for _, tt := range tests {
+		if got := xminSeverity(tt.age); got != tt.want {
+			t.Errorf("xminSeverity(%d) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
+
+func TestIsLoopbackListen(t *testing.T) {
+	tests := []struct {
+		listen string
+		want   bool
+	}{
+		{"127.0.0.1:9182", true},
+		{"localhost:9182", true},
+		{"[::1]:9182", true},
+		{"0.0.0.0:9182", false},
+		{":9182", false},
+		{"10.0.0.5:9182", false},
+		{"not-an-address", false},
+	}
+	for _, tt := range tests {
+		if got := isLoopbackListen(tt.listen); got != tt.want {
+			t.Errorf("isLoopbackListen(%q) = %v, want %v", tt.listen, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// Doctor check results
+const (
+	CheckPass = "pass"
+	CheckWarn = "warn"
+	CheckFail = "fail"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check privileges and server settings pguard depends on",
+	Long: `Connect with the current config and check that the monitoring role can do
+its job: role memberships (pg_monitor, pg_signal_backend, rds_iam), visibility
+of other sessions in pg_stat_activity, that it can terminate a test backend it
+spawns itself, and server settings that affect pguard.
+
+Each problem comes with the SQL to fix it. Exit codes:
+  0 - All checks passed
+  1 - Warnings
+  2 - Failures`,
+	RunE: runDoctor,
+}
+
+func init() {
+	doctorCmd.Flags().Bool("json", false, "Output as JSON")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// DoctorCheck is the result of a single doctor check
+type DoctorCheck struct {
+	Name        string `json:"name"`
+	Status      string `json:"status"` // "pass", "warn", "fail"
+	Message     string `json:"message"`
+	Remediation string `json:"remediation,omitempty"` // SQL to fix the problem
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	client, err := postgres.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	checks := runDoctorChecks(ctx, client)
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(checks, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printDoctorChecks(checks)
+	}
+
+	if code := doctorExitCode(checks); code != ExitOK {
+		os.Exit(code)
+	}
+	return nil
+}
+
+// runDoctorChecks runs every check. Checks after the connection check are
+// skipped if pguard cannot connect at all.
+func runDoctorChecks(ctx context.Context, client *postgres.Client) []DoctorCheck {
+	var checks []DoctorCheck
+
+	if err := client.Ping(ctx); err != nil {
+		return append(checks, DoctorCheck{
+			Name:    "connection",
+			Status:  CheckFail,
+			Message: fmt.Sprintf("cannot connect: %v", err),
+		})
+	}
+
+	settings, err := client.GetSettings(ctx,
+		"server_version",
+		"track_activities",
+		"track_activity_query_size",
+		"idle_in_transaction_session_timeout",
+		"idle_session_timeout",
+		"statement_timeout",
+		"max_connections",
+	)
+	if err != nil {
+		checks = append(checks, DoctorCheck{Name: "settings", Status: CheckFail, Message: err.Error()})
+		settings = map[string]*postgres.Setting{}
+	}
+
+	role, err := client.GetRoleInfo(ctx)
+	if err != nil {
+		return append(checks, DoctorCheck{Name: "connection", Status: CheckFail, Message: err.Error()})
+	}
+
+	version := "unknown"
+	if s, ok := settings["server_version"]; ok {
+		version = s.Setting
+	}
+	checks = append(checks, DoctorCheck{
+		Name:    "connection",
+		Status:  CheckPass,
+		Message: fmt.Sprintf("connected as %s (PostgreSQL %s)", role.Name, version),
+	})
+
+	checks = append(checks, checkRoles(role)...)
+	checks = append(checks, checkTerminate(role, client.TestTerminate(ctx)))
+
+	visibility, err := client.GetActivityVisibility(ctx)
+	if err != nil {
+		checks = append(checks, DoctorCheck{Name: "pg_stat_activity", Status: CheckFail, Message: err.Error()})
+	} else {
+		checks = append(checks, checkVisibility(role, visibility))
+	}
+
+	checks = append(checks, checkSettings(settings)...)
+
+	if stats, err := client.GetPoolStats(ctx); err == nil {
+		checks = append(checks, checkMaxConnections(stats))
+	}
+
+	return checks
+}
+
+// checkRoles checks the role memberships pguard relies on
+func checkRoles(role *postgres.RoleInfo) []DoctorCheck {
+	var checks []DoctorCheck
+	name := quoteIdent(role.Name)
+
+	if role.Superuser {
+		checks = append(checks, DoctorCheck{
+			Name:    "superuser",
+			Status:  CheckWarn,
+			Message: "connected as a superuser; a dedicated monitoring role is safer",
+			Remediation: "CREATE ROLE pguard LOGIN;\n" +
+				"GRANT pg_monitor, pg_signal_backend TO pguard;",
+		})
+	}
+
+	if role.IsMemberOf("pg_monitor") {
+		checks = append(checks, DoctorCheck{Name: "pg_monitor", Status: CheckPass, Message: "member of pg_monitor"})
+	} else if role.IsMemberOf("pg_read_all_stats") {
+		checks = append(checks, DoctorCheck{Name: "pg_monitor", Status: CheckPass, Message: "member of pg_read_all_stats"})
+	} else {
+		checks = append(checks, DoctorCheck{
+			Name:        "pg_monitor",
+			Status:      CheckFail,
+			Message:     "not a member of pg_monitor or pg_read_all_stats; other roles' queries are hidden",
+			Remediation: fmt.Sprintf("GRANT pg_monitor TO %s;", name),
+		})
+	}
+
+	// The terminate check only proves the role can signal its own backends;
+	// other roles' need membership or superuser
+	if role.Superuser {
+		checks = append(checks, DoctorCheck{Name: "pg_signal_backend", Status: CheckPass, Message: "superuser, can signal any non-superuser backend"})
+	} else if role.IsMemberOf("pg_signal_backend") {
+		checks = append(checks, DoctorCheck{Name: "pg_signal_backend", Status: CheckPass, Message: "member of pg_signal_backend"})
+	} else {
+		checks = append(checks, DoctorCheck{
+			Name:        "pg_signal_backend",
+			Status:      CheckWarn,
+			Message:     "not a member of pg_signal_backend; kill and auto-terminate only work on backends of the same role",
+			Remediation: fmt.Sprintf("GRANT pg_signal_backend TO %s;", name),
+		})
+	}
+
+	if cfg.Connection.AuthMethod == "iam" {
+		if role.IsMemberOf("rds_iam") {
+			checks = append(checks, DoctorCheck{Name: "rds_iam", Status: CheckPass, Message: "member of rds_iam"})
+		} else {
+			checks = append(checks, DoctorCheck{
+				Name:        "rds_iam",
+				Status:      CheckFail,
+				Message:     "auth_method is iam but the role is not a member of rds_iam",
+				Remediation: fmt.Sprintf("GRANT rds_iam TO %s;", name),
+			})
+		}
+	}
+
+	return checks
+}
+
+// checkTerminate reports the result of terminating doctor's own test backend.
+// Membership doesn't help when this fails: the function has been revoked, or
+// a pooler or proxy sits between pguard and the server.
+func checkTerminate(role *postgres.RoleInfo, err error) DoctorCheck {
+	if err != nil {
+		return DoctorCheck{
+			Name:    "terminate",
+			Status:  CheckFail,
+			Message: fmt.Sprintf("could not terminate a test backend: %v", err),
+			Remediation: fmt.Sprintf("GRANT EXECUTE ON FUNCTION pg_terminate_backend(integer, bigint) TO %s;\n", quoteIdent(role.Name)) +
+				"-- and connect directly, not through a pooler such as PgBouncer",
+		}
+	}
+	return DoctorCheck{Name: "terminate", Status: CheckPass, Message: "terminated a test backend spawned by doctor"}
+}
+
+// checkVisibility checks whether other roles' queries are readable
+func checkVisibility(role *postgres.RoleInfo, v *postgres.ActivityVisibility) DoctorCheck {
+	check := DoctorCheck{Name: "pg_stat_activity"}
+	switch {
+	case v.Hidden > 0:
+		check.Status = CheckFail
+		check.Message = fmt.Sprintf("%d of %d other sessions show <insufficient privilege>", v.Hidden, v.OtherSessions)
+		check.Remediation = fmt.Sprintf("GRANT pg_read_all_stats TO %s;", quoteIdent(role.Name))
+	case v.OtherSessions == 0 && !role.IsMemberOf("pg_read_all_stats"):
+		check.Status = CheckWarn
+		check.Message = "no other roles' sessions to verify visibility against"
+	default:
+		check.Status = CheckPass
+		check.Message = fmt.Sprintf("all %d other sessions are visible", v.OtherSessions)
+	}
+	return check
+}
+
+// checkSettings checks server settings that change what pguard can see or do
+func checkSettings(settings map[string]*postgres.Setting) []DoctorCheck {
+	var checks []DoctorCheck
+
+	if s, ok := settings["track_activities"]; ok {
+		if s.Setting == "on" {
+			checks = append(checks, DoctorCheck{Name: "track_activities", Status: CheckPass, Message: "on"})
+		} else {
+			checks = append(checks, DoctorCheck{
+				Name:        "track_activities",
+				Status:      CheckFail,
+				Message:     "off; pg_stat_activity shows no state or query",
+				Remediation: "ALTER SYSTEM SET track_activities = on;\nSELECT pg_reload_conf();",
+			})
+		}
+	}
+
+	if s, ok := settings["track_activity_query_size"]; ok {
+		if size := s.Int(); size < 4096 {
+			checks = append(checks, DoctorCheck{
+				Name:        "track_activity_query_size",
+				Status:      CheckWarn,
+				Message:     fmt.Sprintf("%d bytes; longer queries are truncated in alerts and explain", size),
+				Remediation: "ALTER SYSTEM SET track_activity_query_size = 4096; -- requires restart",
+			})
+		} else {
+			checks = append(checks, DoctorCheck{Name: "track_activity_query_size", Status: CheckPass,
+				Message: fmt.Sprintf("%d bytes", size)})
+		}
+	}
+
+	if s, ok := settings["idle_in_transaction_session_timeout"]; ok {
+		checks = append(checks, checkIdleTxTimeout(s.Duration()))
+	}
+
+	for _, name := range []string{"statement_timeout", "idle_session_timeout"} {
+		s, ok := settings[name]
+		if !ok {
+			continue
+		}
+		msg := "disabled"
+		if d := s.Duration(); d > 0 {
+			msg = util.FormatDuration(d)
+		}
+		checks = append(checks, DoctorCheck{Name: name, Status: CheckPass, Message: msg})
+	}
+
+	return checks
+}
+
+// checkIdleTxTimeout compares idle_in_transaction_session_timeout with
+// pguard's thresholds. A server timeout below them means PostgreSQL kills
+// idle transactions before pguard ever alerts.
+func checkIdleTxTimeout(timeout time.Duration) DoctorCheck {
+	check := DoctorCheck{Name: "idle_in_transaction_session_timeout"}
+	warning := cfg.Thresholds.IdleTransaction.Warning
+	critical := cfg.Thresholds.IdleTransaction.Critical
+	remediation := "-- or lower pguard's idle_transaction thresholds\n" +
+		"ALTER SYSTEM SET idle_in_transaction_session_timeout = 0;\nSELECT pg_reload_conf();"
+
+	switch {
+	case timeout == 0:
+		check.Status = CheckPass
+		check.Message = "disabled; pguard is the only guard against idle transactions"
+	case timeout <= warning:
+		check.Status = CheckWarn
+		check.Message = fmt.Sprintf("%s, at or below the warning threshold (%s); pguard will never alert",
+			util.FormatDuration(timeout), util.FormatDuration(warning))
+		check.Remediation = remediation
+	case timeout <= critical:
+		check.Status = CheckWarn
+		check.Message = fmt.Sprintf("%s, at or below the critical threshold (%s); critical alerts will never fire",
+			util.FormatDuration(timeout), util.FormatDuration(critical))
+		check.Remediation = remediation
+	default:
+		check.Status = CheckPass
+		check.Message = fmt.Sprintf("%s, above pguard's thresholds; PostgreSQL acts as a backstop",
+			util.FormatDuration(timeout))
+	}
+	return check
+}
+
+// checkMaxConnections reports capacity and current pressure
+func checkMaxConnections(stats *postgres.PoolStats) DoctorCheck {
+	check := DoctorCheck{
+		Name:   "max_connections",
+		Status: CheckPass,
+		Message: fmt.Sprintf("%d, %d usable by normal roles, %d in use (%.0f%%)",
+			stats.MaxConnections, stats.Capacity(), stats.UsedConnections(), stats.UsagePercent()),
+	}
+	if stats.UsagePercent() >= float64(cfg.Thresholds.ConnectionPool.WarningPercent) {
+		check.Status = CheckWarn
+	}
+	return check
+}
+
+// doctorExitCode maps check results to status-style exit codes
+func doctorExitCode(checks []DoctorCheck) int {
+	code := ExitOK
+	for _, c := range checks {
+		switch c.Status {
+		case CheckFail:
+			return ExitCritical
+		case CheckWarn:
+			code = ExitWarning
+		}
+	}
+	return code
+}
+
+func printDoctorChecks(checks []DoctorCheck) {
+	fmt.Println()
+	fmt.Println("pguard doctor")
+	fmt.Println(strings.Repeat("-", 44))
+
+	counts := make(map[string]int)
+	for _, c := range checks {
+		counts[c.Status]++
+		fmt.Printf("[%s] %-36s %s\n", strings.ToUpper(c.Status), c.Name, c.Message)
+		if c.Remediation != "" {
+			for _, line := range strings.Split(c.Remediation, "\n") {
+				fmt.Printf("       %s\n", line)
+			}
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("%d passed, %d warnings, %d failed\n", counts[CheckPass], counts[CheckWarn], counts[CheckFail])
+}
+
+// quoteIdent quotes a role name for use in remediation SQL
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
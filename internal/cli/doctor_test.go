@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestCheckIdleTxTimeout(t *testing.T) {
+	cfg = config.DefaultConfig() // warning 30s, critical 2m
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		want    string
+	}{
+		{"disabled", 0, CheckPass},
+		{"below warning", 10 * time.Second, CheckWarn},
+		{"between warning and critical", time.Minute, CheckWarn},
+		{"above critical", 10 * time.Minute, CheckPass},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkIdleTxTimeout(tt.timeout)
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q (%s)", got.Status, tt.want, got.Message)
+			}
+			if got.Status == CheckWarn && got.Remediation == "" {
+				t.Error("expected remediation SQL on warning")
+			}
+		})
+	}
+}
+
+func TestCheckRoles(t *testing.T) {
+	cfg = config.DefaultConfig()
+	cfg.Connection.AuthMethod = "iam"
+
+	statuses := func(checks []DoctorCheck) map[string]string {
+		m := make(map[string]string)
+		for _, c := range checks {
+			m[c.Name] = c.Status
+		}
+		return m
+	}
+
+	got := statuses(checkRoles(&postgres.RoleInfo{Name: "pguard"}))
+	if got["pg_monitor"] != CheckFail || got["pg_signal_backend"] != CheckWarn || got["rds_iam"] != CheckFail {
+		t.Errorf("unprivileged role: %v", got)
+	}
+
+	got = statuses(checkRoles(&postgres.RoleInfo{
+		Name:        "pguard",
+		Memberships: []string{"pg_monitor", "pg_signal_backend", "rds_iam"},
+	}))
+	if got["pg_monitor"] != CheckPass || got["pg_signal_backend"] != CheckPass || got["rds_iam"] != CheckPass {
+		t.Errorf("monitoring role: %v", got)
+	}
+	if _, ok := got["superuser"]; ok {
+		t.Error("unexpected superuser check for non-superuser")
+	}
+
+	got = statuses(checkRoles(&postgres.RoleInfo{Name: "postgres", Superuser: true}))
+	if got["superuser"] != CheckWarn || got["pg_monitor"] != CheckPass || got["pg_signal_backend"] != CheckPass {
+		t.Errorf("superuser: %v", got)
+	}
+}
+
+func TestCheckTerminate(t *testing.T) {
+	role := &postgres.RoleInfo{Name: "pguard"}
+	if got := checkTerminate(role, nil); got.Status != CheckPass {
+		t.Errorf("success: %+v", got)
+	}
+	got := checkTerminate(role, errors.New("permission denied for function pg_terminate_backend"))
+	if got.Status != CheckFail || !strings.Contains(got.Message, "permission denied") || got.Remediation == "" {
+		t.Errorf("failure: %+v", got)
+	}
+}
+
+func TestCheckVisibility(t *testing.T) {
+	role := &postgres.RoleInfo{Name: "pguard"}
+	tests := []struct {
+		name string
+		v    postgres.ActivityVisibility
+		want string
+	}{
+		{"hidden queries", postgres.ActivityVisibility{OtherSessions: 5, Hidden: 2}, CheckFail},
+		{"all visible", postgres.ActivityVisibility{OtherSessions: 5}, CheckPass},
+		{"nothing to verify", postgres.ActivityVisibility{}, CheckWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkVisibility(role, &tt.v); got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoctorExitCode(t *testing.T) {
+	tests := []struct {
+		statuses []string
+		want     int
+	}{
+		{[]string{CheckPass, CheckPass}, ExitOK},
+		{[]string{CheckPass, CheckWarn}, ExitWarning},
+		{[]string{CheckWarn, CheckFail, CheckPass}, ExitCritical},
+	}
+
+	for _, tt := range tests {
+		checks := make([]DoctorCheck, len(tt.statuses))
+		for i, s := range tt.statuses {
+			checks[i] = DoctorCheck{Status: s}
+		}
+		if got := doctorExitCode(checks); got != tt.want {
+			t.Errorf("doctorExitCode(%v) = %d, want %d", tt.statuses, got, tt.want)
+		}
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	if got := quoteIdent(`my"role`); got != `"my""role"` {
+		t.Errorf("quoteIdent() = %s", got)
+	}
+}
@@ -0,0 +1,364 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain <pid>",
+	Short: "Show everything known about a single backend",
+	Long: `Collect a full report on one PostgreSQL backend: the complete query text,
+backend/transaction/state ages, locks held and awaited, the sessions it blocks
+and is blocked by, wait event, xmin age, client info, its pg_stat_statements
+entry (if available) and which pguard policy rules apply to it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExplain,
+}
+
+func init() {
+	explainCmd.Flags().Bool("json", false, "Output as JSON")
+	rootCmd.AddCommand(explainCmd)
+}
+
+// ExplainOutput represents the JSON output of the explain command
+type ExplainOutput struct {
+	PID            int              `json:"pid"`
+	User           string           `json:"user"`
+	Database       string           `json:"database"`
+	Application    string           `json:"application"`
+	ClientAddr     string           `json:"client_addr"`
+	ClientHostname string           `json:"client_hostname,omitempty"`
+	ClientPort     int              `json:"client_port"`
+	State          string           `json:"state"`
+	BackendStart   string           `json:"backend_start"`
+	BackendAgeSec  float64          `json:"backend_age_seconds"`
+	XactStart      string           `json:"xact_start,omitempty"`
+	XactAgeSec     float64          `json:"xact_age_seconds,omitempty"`
+	StateChange    string           `json:"state_change"`
+	StateAgeSec    float64          `json:"state_age_seconds"`
+	WaitEventType  string           `json:"wait_event_type,omitempty"`
+	WaitEvent      string           `json:"wait_event,omitempty"`
+	XidAge         *int64           `json:"xid_age,omitempty"`
+	XminAge        *int64           `json:"xmin_age,omitempty"`
+	QueryID        *int64           `json:"query_id,omitempty"`
+	Query          string           `json:"query"`
+	LocksHeld      []LockStatus     `json:"locks_held"`
+	LocksAwaited   []LockStatus     `json:"locks_awaited"`
+	BlockedBy      []int            `json:"blocked_by"`
+	Blocking       []int            `json:"blocking"`
+	Statement      *StatementStatus `json:"statement,omitempty"`
+	Policies       []PolicyStatus   `json:"policies"`
+	Warnings       []string         `json:"warnings,omitempty"` // Parts of the report that could not be collected
+}
+
+// LockStatus represents a single lock held or awaited by a backend
+type LockStatus struct {
+	LockType string `json:"lock_type"`
+	Mode     string `json:"mode"`
+	Relation string `json:"relation,omitempty"`
+}
+
+// StatementStatus represents a pg_stat_statements entry
+type StatementStatus struct {
+	Calls          int64   `json:"calls"`
+	TotalExecMs    float64 `json:"total_exec_ms"`
+	MeanExecMs     float64 `json:"mean_exec_ms"`
+	Rows           int64   `json:"rows"`
+	SharedBlksHit  int64   `json:"shared_blks_hit"`
+	SharedBlksRead int64   `json:"shared_blks_read"`
+}
+
+// PolicyStatus describes how one pguard rule applies to a session
+type PolicyStatus struct {
+	Rule   string `json:"rule"`
+	Status string `json:"status"` // "matched", "pending", "exempt", "disabled", "n/a"
+	Detail string `json:"detail"`
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	pid, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid PID: %s", args[0])
+	}
+
+	client, err := postgres.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	detail, err := client.GetSessionDetail(ctx, pid)
+	if err != nil {
+		return err
+	}
+
+	output := buildExplainOutput(detail)
+
+	// Locks and statement stats are best-effort; report what we can
+	locks, err := client.GetLocks(ctx, pid)
+	if err != nil {
+		output.Warnings = append(output.Warnings, err.Error())
+	}
+	for _, l := range locks {
+		ls := LockStatus{LockType: l.LockType, Mode: l.Mode, Relation: l.Relation}
+		if l.Granted {
+			output.LocksHeld = append(output.LocksHeld, ls)
+		} else {
+			output.LocksAwaited = append(output.LocksAwaited, ls)
+		}
+	}
+
+	if detail.QueryID != nil && *detail.QueryID != 0 {
+		stmt, err := client.GetStatementStats(ctx, *detail.QueryID)
+		if err != nil {
+			output.Warnings = append(output.Warnings, err.Error())
+		} else if stmt != nil {
+			output.Statement = &StatementStatus{
+				Calls:          stmt.Calls,
+				TotalExecMs:    float64(stmt.TotalExecTime) / float64(time.Millisecond),
+				MeanExecMs:     float64(stmt.MeanExecTime) / float64(time.Millisecond),
+				Rows:           stmt.Rows,
+				SharedBlksHit:  stmt.SharedBlksHit,
+				SharedBlksRead: stmt.SharedBlksRead,
+			}
+		}
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	printExplain(output)
+	return nil
+}
+
+func buildExplainOutput(d *postgres.SessionDetail) *ExplainOutput {
+	output := &ExplainOutput{
+		PID:            d.PID,
+		User:           d.Username,
+		Database:       d.Database,
+		Application:    d.ApplicationName,
+		ClientAddr:     d.ClientAddr,
+		ClientHostname: d.ClientHostname,
+		ClientPort:     d.ClientPort,
+		State:          string(d.State),
+		BackendStart:   d.BackendStart.UTC().Format(time.RFC3339),
+		BackendAgeSec:  d.BackendDuration().Seconds(),
+		StateChange:    d.StateChange.UTC().Format(time.RFC3339),
+		StateAgeSec:    d.IdleDuration().Seconds(),
+		XidAge:         d.XidAge,
+		XminAge:        d.XminAge,
+		QueryID:        d.QueryID,
+		Query:          d.Query,
+		LocksHeld:      []LockStatus{},
+		LocksAwaited:   []LockStatus{},
+		BlockedBy:      []int{},
+		Blocking:       []int{},
+		Policies:       explainPolicies(&d.Connection, d.XminAge),
+	}
+	if d.XactStart != nil {
+		output.XactStart = d.XactStart.UTC().Format(time.RFC3339)
+		output.XactAgeSec = d.TransactionDuration().Seconds()
+	}
+	if d.WaitEventType != nil {
+		output.WaitEventType = *d.WaitEventType
+	}
+	if d.WaitEvent != nil {
+		output.WaitEvent = *d.WaitEvent
+	}
+	output.BlockedBy = append(output.BlockedBy, d.BlockedBy...)
+	output.Blocking = append(output.Blocking, d.Blocking...)
+	return output
+}
+
+// explainPolicies evaluates the idle transaction thresholds, auto-terminate
+// rules and xmin horizon thresholds against a session
+func explainPolicies(conn *postgres.Connection, xminAge *int64) []PolicyStatus {
+	var policies []PolicyStatus
+	idleTx := conn.IsIdleInTransaction()
+	duration := conn.IdleDuration()
+
+	thresholds := []struct {
+		rule  string
+		limit time.Duration
+	}{
+		{"idle_transaction.warning", cfg.Thresholds.IdleTransaction.Warning},
+		{"idle_transaction.critical", cfg.Thresholds.IdleTransaction.Critical},
+	}
+	for _, th := range thresholds {
+		switch {
+		case !idleTx:
+			policies = append(policies, PolicyStatus{Rule: th.rule, Status: "n/a", Detail: "session is not idle in transaction"})
+		case duration >= th.limit:
+			policies = append(policies, PolicyStatus{Rule: th.rule, Status: "matched",
+				Detail: fmt.Sprintf("idle %s >= %s", util.FormatDuration(duration), util.FormatDuration(th.limit))})
+		default:
+			policies = append(policies, PolicyStatus{Rule: th.rule, Status: "pending",
+				Detail: fmt.Sprintf("fires in %s", util.FormatDuration(th.limit-duration))})
+		}
+	}
+
+	switch {
+	case !cfg.AutoTerm.Enabled:
+		policies = append(policies, PolicyStatus{Rule: "auto_terminate", Status: "disabled", Detail: "auto_terminate.enabled is false"})
+	case !idleTx:
+		policies = append(policies, PolicyStatus{Rule: "auto_terminate", Status: "n/a", Detail: "session is not idle in transaction"})
+	default:
+		rule := autoTermRuleFor(conn)
+		after := cfg.AutoTerm.After
+		if rule.After > after {
+			after = rule.After
+		}
+		switch {
+		case rule.NeedsApproval && duration >= after && cfg.API.Enabled:
+			policies = append(policies, PolicyStatus{Rule: "auto_terminate", Status: "matched",
+				Detail: fmt.Sprintf("waiting for an operator to approve termination (%s)", rule.Name)})
+		case rule.Exempt:
+			policies = append(policies, PolicyStatus{Rule: "auto_terminate", Status: "exempt",
+				Detail: fmt.Sprintf("excluded by %s", rule.Name)})
+		case duration >= after:
+			verb := "would be terminated on the next poll"
+			if cfg.AutoTerm.DryRun {
+				verb = "would be reported by dry-run on the next poll"
+			}
+			policies = append(policies, PolicyStatus{Rule: "auto_terminate", Status: "matched",
+				Detail: fmt.Sprintf("%s (%s, after %s)", verb, rule.Name, util.FormatDuration(after))})
+		default:
+			policies = append(policies, PolicyStatus{Rule: "auto_terminate", Status: "pending",
+				Detail: fmt.Sprintf("eligible in %s (%s, after %s)", util.FormatDuration(after-duration), rule.Name, util.FormatDuration(after))})
+		}
+	}
+
+	switch {
+	case !cfg.Thresholds.XminHorizon.Enabled:
+		policies = append(policies, PolicyStatus{Rule: "xmin_horizon", Status: "disabled", Detail: "thresholds.xmin_horizon.enabled is false"})
+	case xminAge == nil:
+		policies = append(policies, PolicyStatus{Rule: "xmin_horizon", Status: "n/a", Detail: "session holds no snapshot"})
+	default:
+		if severity := xminSeverity(*xminAge); severity != "" {
+			policies = append(policies, PolicyStatus{Rule: "xmin_horizon." + severity, Status: "matched",
+				Detail: fmt.Sprintf("xmin age %d (alerts only if this is the oldest holder)", *xminAge)})
+		} else {
+			policies = append(policies, PolicyStatus{Rule: "xmin_horizon", Status: "pending",
+				Detail: fmt.Sprintf("xmin age %d < %d", *xminAge, cfg.Thresholds.XminHorizon.WarningAge)})
+		}
+	}
+
+	return policies
+}
+
+func printExplain(o *ExplainOutput) {
+	ageOf := func(sec float64) string {
+		return util.FormatDuration(time.Duration(sec * float64(time.Second)))
+	}
+
+	fmt.Println()
+	fmt.Printf("Session %d\n", o.PID)
+	fmt.Println(strings.Repeat("-", 44))
+	fmt.Printf("User:            %s\n", o.User)
+	fmt.Printf("Database:        %s\n", o.Database)
+	fmt.Printf("Application:     %s\n", o.Application)
+	client := fmt.Sprintf("%s:%d", o.ClientAddr, o.ClientPort)
+	if o.ClientHostname != "" {
+		client += fmt.Sprintf(" (%s)", o.ClientHostname)
+	}
+	fmt.Printf("Client:          %s\n", client)
+	fmt.Printf("State:           %s\n", o.State)
+	fmt.Println()
+
+	fmt.Println("Ages")
+	fmt.Println(strings.Repeat("-", 44))
+	fmt.Printf("Backend:         %s (since %s)\n", ageOf(o.BackendAgeSec), o.BackendStart)
+	if o.XactStart != "" {
+		fmt.Printf("Transaction:     %s (since %s)\n", ageOf(o.XactAgeSec), o.XactStart)
+	} else {
+		fmt.Printf("Transaction:     none\n")
+	}
+	fmt.Printf("State:           %s (since %s)\n", ageOf(o.StateAgeSec), o.StateChange)
+	if o.XidAge != nil {
+		fmt.Printf("Xid age:         %d\n", *o.XidAge)
+	}
+	if o.XminAge != nil {
+		fmt.Printf("Xmin age:        %d\n", *o.XminAge)
+	}
+	if o.WaitEventType != "" {
+		fmt.Printf("Wait event:      %s/%s\n", o.WaitEventType, o.WaitEvent)
+	}
+	fmt.Println()
+
+	fmt.Printf("Locks Held (%d)\n", len(o.LocksHeld))
+	fmt.Println(strings.Repeat("-", 44))
+	for _, l := range o.LocksHeld {
+		fmt.Printf("  %-24s %-14s %s\n", l.Mode, l.LockType, l.Relation)
+	}
+	if len(o.LocksAwaited) > 0 {
+		fmt.Println()
+		fmt.Printf("Locks Awaited (%d)\n", len(o.LocksAwaited))
+		fmt.Println(strings.Repeat("-", 44))
+		for _, l := range o.LocksAwaited {
+			fmt.Printf("  %-24s %-14s %s\n", l.Mode, l.LockType, l.Relation)
+		}
+	}
+	fmt.Println()
+
+	fmt.Println("Blocking")
+	fmt.Println(strings.Repeat("-", 44))
+	fmt.Printf("Blocked by:      %s\n", pidList(o.BlockedBy))
+	fmt.Printf("Blocking:        %s\n", pidList(o.Blocking))
+	fmt.Println()
+
+	if o.Statement != nil {
+		fmt.Printf("pg_stat_statements (query_id %d)\n", *o.QueryID)
+		fmt.Println(strings.Repeat("-", 44))
+		fmt.Printf("Calls:           %d\n", o.Statement.Calls)
+		fmt.Printf("Mean time:       %.2f ms\n", o.Statement.MeanExecMs)
+		fmt.Printf("Total time:      %.2f ms\n", o.Statement.TotalExecMs)
+		fmt.Printf("Rows:            %d\n", o.Statement.Rows)
+		fmt.Printf("Shared blocks:   %d hit, %d read\n", o.Statement.SharedBlksHit, o.Statement.SharedBlksRead)
+		fmt.Println()
+	}
+
+	fmt.Println("Policy")
+	fmt.Println(strings.Repeat("-", 44))
+	for _, p := range o.Policies {
+		fmt.Printf("  %-28s %-9s %s\n", p.Rule, p.Status, p.Detail)
+	}
+	fmt.Println()
+
+	fmt.Println("Query:")
+	for _, line := range strings.Split(strings.TrimSpace(o.Query), "\n") {
+		fmt.Printf("  %s\n", line)
+	}
+	fmt.Println()
+
+	for _, w := range o.Warnings {
+		fmt.Printf("Warning: %s\n", w)
+	}
+}
+
+func pidList(pids []int) string {
+	if len(pids) == 0 {
+		return "none"
+	}
+	return joinPIDs(pids)
+}
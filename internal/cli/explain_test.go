@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestExplainPolicies(t *testing.T) {
+	cfg = config.DefaultConfig()
+	cfg.AutoTerm.Enabled = true
+	cfg.AutoTerm.After = 5 * time.Minute
+	cfg.AutoTerm.ExcludeApps = []string{"pg_dump"}
+	cfg.AutoTerm.ProtectedApps = []config.ProtectedApp{
+		{Name: "billing", MinIdleDuration: 30 * time.Minute},
+	}
+
+	now := time.Now()
+	xmin := int64(2_000_000)
+
+	tests := []struct {
+		name    string
+		conn    *postgres.Connection
+		xminAge *int64
+		want    map[string]string // rule -> status
+	}{
+		{
+			name: "active session",
+			conn: &postgres.Connection{State: postgres.StateActive, StateChange: now},
+			want: map[string]string{
+				"idle_transaction.warning": "n/a",
+				"auto_terminate":           "n/a",
+				"xmin_horizon":             "n/a",
+			},
+		},
+		{
+			name: "idle tx past auto-terminate",
+			conn: &postgres.Connection{ApplicationName: "web", State: postgres.StateIdleInTransaction,
+				StateChange: now.Add(-10 * time.Minute)},
+			xminAge: &xmin,
+			want: map[string]string{
+				"idle_transaction.warning":  "matched",
+				"idle_transaction.critical": "matched",
+				"auto_terminate":            "matched",
+				"xmin_horizon.warning":      "matched",
+			},
+		},
+		{
+			name: "protected app waits for its own threshold",
+			conn: &postgres.Connection{ApplicationName: "billing", State: postgres.StateIdleInTransaction,
+				StateChange: now.Add(-10 * time.Minute)},
+			want: map[string]string{"auto_terminate": "pending"},
+		},
+		{
+			name: "excluded app",
+			conn: &postgres.Connection{ApplicationName: "pg_dump", State: postgres.StateIdleInTransaction,
+				StateChange: now.Add(-10 * time.Minute)},
+			want: map[string]string{"auto_terminate": "exempt"},
+		},
+		{
+			name: "below warning",
+			conn: &postgres.Connection{ApplicationName: "web", State: postgres.StateIdleInTransaction,
+				StateChange: now.Add(-10 * time.Second)},
+			want: map[string]string{
+				"idle_transaction.warning": "pending",
+				"auto_terminate":           "pending",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]string)
+			for _, p := range explainPolicies(tt.conn, tt.xminAge) {
+				got[p.Rule] = p.Status
+			}
+			for rule, status := range tt.want {
+				if got[rule] != status {
+					t.Errorf("policy %s = %q, want %q (all: %v)", rule, got[rule], status, got)
+				}
+			}
+		})
+	}
+}
+
+func TestExplainPolicies_AutoTermDisabled(t *testing.T) {
+	cfg = config.DefaultConfig()
+	conn := &postgres.Connection{State: postgres.StateIdleInTransaction, StateChange: time.Now().Add(-time.Hour)}
+
+	for _, p := range explainPolicies(conn, nil) {
+		if p.Rule == "auto_terminate" && p.Status != "disabled" {
+			t.Errorf("auto_terminate status = %q, want disabled", p.Status)
+		}
+	}
+}
@@ -5,35 +5,114 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/v0xg/pg-idle-guard/internal/audit"
 	"github.com/v0xg/pg-idle-guard/internal/postgres"
 	"github.com/v0xg/pg-idle-guard/internal/util"
 )
 
+// Exit codes for bulk kill
+const (
+	ExitKillOK      = 0 // Every matching session was signaled (or was already gone)
+	ExitKillPartial = 1 // Some sessions failed
+	ExitKillFailed  = 2 // Every session failed
+)
+
 var killCmd = &cobra.Command{
-	Use:   "kill <pid>",
-	Short: "Terminate a database connection by PID",
-	Long: `Terminate a PostgreSQL backend connection by PID.
+	Use:   "kill [pid]",
+	Short: "Terminate database connections by PID or filter",
+	Long: `Terminate a PostgreSQL backend connection by PID, or every connection
+matching a set of filters.
+
+This will rollback any uncommitted transaction on those connections.
 
-This will rollback any uncommitted transaction on that connection.`,
-	Args: cobra.ExactArgs(1),
+With filters, matching sessions are shown in a preview table and a single
+confirmation covers all of them. Sessions are signaled in parallel and a
+per-PID summary is printed. The exit code is 0 if every session was handled,
+1 if some failed and 2 if all failed.`,
+	Example: `  pguard kill 12345
+  pguard kill --app 'payment-*' --state idle-in-transaction --idle-longer-than 5m
+  pguard kill --client-cidr 10.0.3.0/24 --query-matches 'FOR UPDATE' --dry-run`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: runKill,
 }
 
 func init() {
 	killCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
 	killCmd.Flags().Bool("cancel", false, "Cancel current query instead of terminating")
+
+	killCmd.Flags().String("app", "", "Match application_name (glob)")
+	killCmd.Flags().String("user", "", "Match user name (glob)")
+	killCmd.Flags().String("database", "", "Match database name (glob)")
+	killCmd.Flags().String("client-cidr", "", "Match client address within a CIDR")
+	killCmd.Flags().String("state", "", "Match state (e.g. active, idle-in-transaction)")
+	killCmd.Flags().Duration("idle-longer-than", 0, "Match sessions in their current state for longer than this")
+	killCmd.Flags().String("query-matches", "", "Match query text (regular expression)")
+	killCmd.Flags().Bool("dry-run", false, "Show matching sessions without signaling them")
+	killCmd.Flags().Int("concurrency", 4, "Maximum sessions signaled in parallel")
+}
+
+// connectionFilterFromFlags builds a filter from kill's selection flags
+func connectionFilterFromFlags(cmd *cobra.Command) (*postgres.ConnectionFilter, error) {
+	filter := &postgres.ConnectionFilter{}
+	filter.App, _ = cmd.Flags().GetString("app")
+	filter.User, _ = cmd.Flags().GetString("user")
+	filter.Database, _ = cmd.Flags().GetString("database")
+	filter.IdleLongerThan, _ = cmd.Flags().GetDuration("idle-longer-than")
+
+	if cidr, _ := cmd.Flags().GetString("client-cidr"); cidr != "" {
+		network, err := postgres.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		filter.ClientCIDR = network
+	}
+
+	if state, _ := cmd.Flags().GetString("state"); state != "" {
+		parsed, err := postgres.ParseConnectionState(state)
+		if err != nil {
+			return nil, err
+		}
+		filter.State = parsed
+	}
+
+	if pattern, _ := cmd.Flags().GetString("query-matches"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --query-matches: %w", err)
+		}
+		filter.QueryMatches = re
+	}
+
+	return filter, nil
 }
 
 func runKill(cmd *cobra.Command, args []string) error {
 	force, _ := cmd.Flags().GetBool("force")
 	cancelOnly, _ := cmd.Flags().GetBool("cancel")
 
+	filter, err := connectionFilterFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 && !filter.IsEmpty() {
+		return fmt.Errorf("specify either a PID or filter flags, not both")
+	}
+	if len(args) == 0 {
+		if filter.IsEmpty() {
+			return fmt.Errorf("specify a PID or at least one filter (--app, --user, --database, --client-cidr, --state, --idle-longer-than, --query-matches)")
+		}
+		return runBulkKill(cmd, filter)
+	}
+
 	// Parse PID
 	pid, err := strconv.Atoi(args[0])
 	if err != nil {
@@ -56,14 +135,7 @@ func runKill(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("getting connections: %w", err)
 	}
 
-	var targetConn *postgres.Connection
-	for _, conn := range conns {
-		if conn.PID == pid {
-			targetConn = conn
-			break
-		}
-	}
-
+	targetConn := findConnection(conns, pid)
 	if targetConn == nil {
 		return fmt.Errorf("no connection found with PID %d", pid)
 	}
@@ -95,10 +167,8 @@ func runKill(cmd *cobra.Command, args []string) error {
 
 	// Confirmation
 	if !force {
-		if targetConn.IsIdleInTransaction() {
-			fmt.Printf("Warning: This will %s the backend and rollback any uncommitted work.\n", action)
-		} else if string(targetConn.State) == "active" {
-			fmt.Printf("Warning: This connection is actively running a query.\n")
+		if warning := killWarning(targetConn, action); warning != "" {
+			fmt.Printf("Warning: %s\n", warning)
 		}
 		fmt.Println()
 
@@ -117,13 +187,7 @@ func runKill(cmd *cobra.Command, args []string) error {
 	}
 
 	// Execute termination
-	var success bool
-	if cancelOnly {
-		success, err = client.CancelBackend(ctx, pid)
-	} else {
-		success, err = client.TerminateBackend(ctx, pid)
-	}
-
+	success, err := signalBackend(ctx, client, targetConn, cancelOnly, "cli", "")
 	if err != nil {
 		return fmt.Errorf("failed to %s backend: %w", action, err)
 	}
@@ -140,3 +204,260 @@ func runKill(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// findConnection returns the connection with the given PID, or nil
+func findConnection(conns []*postgres.Connection, pid int) *postgres.Connection {
+	for _, conn := range conns {
+		if conn.PID == pid {
+			return conn
+		}
+	}
+	return nil
+}
+
+// killWarning returns the safety warning to show before acting on conn,
+// or an empty string if there is nothing to warn about
+func killWarning(conn *postgres.Connection, action string) string {
+	if conn.IsIdleInTransaction() {
+		return fmt.Sprintf("This will %s the backend and rollback any uncommitted work.", action)
+	}
+	if conn.State == postgres.StateActive {
+		return "This connection is actively running a query."
+	}
+	return ""
+}
+
+// signalBackend cancels or terminates conn's backend and records the
+// action in the audit log. An empty actor is recorded as the OS user.
+func signalBackend(ctx context.Context, client *postgres.Client, conn *postgres.Connection, cancelOnly bool, source, actor string) (bool, error) {
+	var success bool
+	var err error
+	if cancelOnly {
+		success, err = client.CancelBackend(ctx, conn.PID)
+	} else {
+		success, err = client.TerminateBackend(ctx, conn.PID)
+	}
+
+	auditAction := "terminate"
+	if cancelOnly {
+		auditAction = "cancel"
+	}
+	result, errMsg := auditResult(err)
+	recordAudit(audit.Entry{
+		Action: auditAction,
+		Target: fmt.Sprintf("pid:%d", conn.PID),
+		Actor:  actor,
+		Source: source,
+		Result: result,
+		Error:  errMsg,
+		Details: map[string]interface{}{
+			"application": conn.ApplicationName,
+			"user":        conn.Username,
+			"state":       string(conn.State),
+		},
+	})
+
+	return success, err
+}
+
+// killResult is the outcome of signaling one session during a bulk kill
+type killResult struct {
+	conn   *postgres.Connection
+	status string // terminated, canceled, gone, failed
+	err    error
+}
+
+func runBulkKill(cmd *cobra.Command, filter *postgres.ConnectionFilter) error {
+	force, _ := cmd.Flags().GetBool("force")
+	cancelOnly, _ := cmd.Flags().GetBool("cancel")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	client, err := postgres.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conns, err := client.GetConnections(ctx)
+	if err != nil {
+		return fmt.Errorf("getting connections: %w", err)
+	}
+
+	matches := filter.Filter(conns)
+	if len(matches) == 0 {
+		fmt.Println("No connections match the filter.")
+		return nil
+	}
+
+	fmt.Println()
+	printKillPreview(matches)
+	fmt.Println()
+
+	action := "terminated"
+	verb := "terminate"
+	if cancelOnly {
+		action = "canceled"
+		verb = "cancel the current query on"
+	}
+
+	if dryRun {
+		fmt.Printf("Dry run: %d connection(s) would be %s.\n", len(matches), action)
+		return nil
+	}
+
+	if !force {
+		var idleTx, active int
+		for _, conn := range matches {
+			if conn.IsIdleInTransaction() {
+				idleTx++
+			} else if conn.State == postgres.StateActive {
+				active++
+			}
+		}
+		if idleTx > 0 {
+			fmt.Printf("Warning: %d session(s) are idle in transaction; uncommitted work will be rolled back.\n", idleTx)
+		}
+		if active > 0 {
+			fmt.Printf("Warning: %d session(s) are actively running a query.\n", active)
+		}
+
+		fmt.Printf("%s %d connection(s)? [y/N] ", strings.ToUpper(verb[:1])+verb[1:], len(matches))
+		reader := bufio.NewReader(os.Stdin)
+		response, readErr := readLine(reader)
+		if readErr != nil {
+			return readErr
+		}
+		response = strings.ToLower(response)
+
+		if response != "y" && response != "yes" {
+			fmt.Println("Canceled.")
+			return nil
+		}
+	}
+
+	execCtx, execCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer execCancel()
+
+	results := bulkSignal(execCtx, client, matches, cancelOnly, concurrency)
+
+	fmt.Println()
+	printKillResults(results)
+
+	if code := bulkKillExitCode(results); code != ExitKillOK {
+		os.Exit(code)
+	}
+	return nil
+}
+
+// bulkSignal signals each target with at most concurrency requests in
+// flight. The connection list is re-read first so a PID that has been
+// reused by a new session since the preview is skipped.
+func bulkSignal(ctx context.Context, client *postgres.Client, targets []*postgres.Connection, cancelOnly bool, concurrency int) []killResult {
+	results := make([]killResult, len(targets))
+
+	current, err := client.GetConnections(ctx)
+	if err != nil {
+		for i, conn := range targets {
+			results[i] = killResult{conn: conn, status: "failed", err: fmt.Errorf("getting connections: %w", err)}
+		}
+		return results
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, conn := range targets {
+		live := findConnection(current, conn.PID)
+		if live == nil || !live.BackendStart.Equal(conn.BackendStart) {
+			results[i] = killResult{conn: conn, status: "gone"}
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, conn *postgres.Connection) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			success, err := signalBackend(ctx, client, conn, cancelOnly, "cli", "")
+			switch {
+			case err != nil:
+				results[i] = killResult{conn: conn, status: "failed", err: err}
+			case !success:
+				results[i] = killResult{conn: conn, status: "gone"}
+			case cancelOnly:
+				results[i] = killResult{conn: conn, status: "canceled"}
+			default:
+				results[i] = killResult{conn: conn, status: "terminated"}
+			}
+		}(i, live)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// bulkKillExitCode summarizes bulk kill results as an exit code
+func bulkKillExitCode(results []killResult) int {
+	failed := 0
+	for _, r := range results {
+		if r.status == "failed" {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return ExitKillOK
+	case failed == len(results):
+		return ExitKillFailed
+	default:
+		return ExitKillPartial
+	}
+}
+
+func printKillPreview(conns []*postgres.Connection) {
+	fmt.Printf("Matching Connections (%d)\n", len(conns))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PID\tState\tDuration\tApplication\tUser\tDatabase\tClient\tQuery")
+	for _, conn := range conns {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			conn.PID,
+			conn.State,
+			util.FormatDuration(conn.IdleDuration()),
+			util.Truncate(conn.ApplicationName, 20),
+			conn.Username,
+			conn.Database,
+			conn.ClientAddr,
+			util.TruncateQuery(conn.Query, 40),
+		)
+	}
+	w.Flush()
+}
+
+func printKillResults(results []killResult) {
+	counts := make(map[string]int)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PID\tApplication\tResult\t")
+	for _, r := range results {
+		counts[r.status]++
+		detail := r.status
+		switch r.status {
+		case "gone":
+			detail = "already gone"
+		case "failed":
+			detail = fmt.Sprintf("failed: %v", r.err)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t\n", r.conn.PID, r.conn.ApplicationName, detail)
+	}
+	w.Flush()
+
+	fmt.Println()
+	fmt.Printf("%d terminated, %d canceled, %d already gone, %d failed\n",
+		counts["terminated"], counts["canceled"], counts["gone"], counts["failed"])
+}
@@ -65,13 +65,7 @@ func TestFindConnectionByPID(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			var targetConn *postgres.Connection
-			for _, conn := range conns {
-				if conn.PID == tt.pid {
-					targetConn = conn
-					break
-				}
-			}
+			targetConn := findConnection(conns, tt.pid)
 
 			found := targetConn != nil
 			if found != tt.wantFound {
@@ -211,3 +205,51 @@ func TestActionString(t *testing.T) {
 		})
 	}
 }
+
+func TestKillWarning(t *testing.T) {
+	tests := []struct {
+		name  string
+		state postgres.ConnectionState
+		want  string
+	}{
+		{"idle in transaction", postgres.StateIdleInTransaction, "This will terminate the backend and rollback any uncommitted work."},
+		{"aborted", postgres.StateIdleInTransactionAborted, "This will terminate the backend and rollback any uncommitted work."},
+		{"active", postgres.StateActive, "This connection is actively running a query."},
+		{"idle", postgres.StateIdle, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := killWarning(&postgres.Connection{State: tt.state}, "terminate")
+			if got != tt.want {
+				t.Errorf("killWarning() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBulkKillExitCode(t *testing.T) {
+	conn := &postgres.Connection{PID: 1}
+	tests := []struct {
+		name     string
+		statuses []string
+		want     int
+	}{
+		{"all terminated", []string{"terminated", "terminated"}, ExitKillOK},
+		{"gone is not a failure", []string{"terminated", "gone"}, ExitKillOK},
+		{"some failed", []string{"terminated", "failed"}, ExitKillPartial},
+		{"all failed", []string{"failed", "failed"}, ExitKillFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]killResult, len(tt.statuses))
+			for i, s := range tt.statuses {
+				results[i] = killResult{conn: conn, status: s}
+			}
+			if got := bulkKillExitCode(results); got != tt.want {
+				t.Errorf("bulkKillExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
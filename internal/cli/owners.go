@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/v0xg/pg-idle-guard/internal/owners"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// ownership maps connections to their owning teams; nil when owners.file is unset
+var ownership *owners.Map
+
+var ownersCmd = &cobra.Command{
+	Use:   "owners",
+	Short: "Inspect the ownership file mapping connections to teams",
+}
+
+var ownersCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "List live connections that no team owns",
+	Long: `Load the ownership file (owners.file) and match every live connection
+against it by application name, role and client address.
+
+Exit codes:
+  0 - Every connection has an owner
+  1 - Some connections have no owner`,
+	Args: cobra.NoArgs,
+	RunE: runOwnersCheck,
+}
+
+func init() {
+	ownersCheckCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	ownersCmd.AddCommand(ownersCheckCmd)
+	rootCmd.AddCommand(ownersCmd)
+}
+
+// loadOwnership loads the configured ownership file into ownership
+func loadOwnership() error {
+	if cfg.Owners.File == "" {
+		return nil
+	}
+	m, err := owners.Load(cfg.Owners.File)
+	if err != nil {
+		return err
+	}
+	ownership = m
+	return nil
+}
+
+// UnownedConnections is a set of live connections no team owns that share an
+// application, role and client address
+type UnownedConnections struct {
+	Application string `json:"application"`
+	User        string `json:"user"`
+	ClientAddr  string `json:"client_addr"`
+	Connections int    `json:"connections"`
+}
+
+func runOwnersCheck(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	if cfg.Owners.File == "" {
+		return fmt.Errorf("no ownership file configured (set owners.file)")
+	}
+	if err := loadOwnership(); err != nil {
+		return err
+	}
+
+	client, err := postgres.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	conns, err := client.GetConnections(ctx)
+	if err != nil {
+		return fmt.Errorf("getting connections: %w", err)
+	}
+
+	unowned := findUnowned(ownership, conns)
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(unowned, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	} else if len(unowned) == 0 {
+		fmt.Printf("All %d connections have an owner.\n", len(conns))
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Application\tUser\tClient\tConnections")
+		for _, u := range unowned {
+			app := u.Application
+			if app == "" {
+				app = "(none)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\n", util.Truncate(app, 30), u.User, u.ClientAddr, u.Connections)
+		}
+		w.Flush()
+	}
+
+	if len(unowned) > 0 {
+		os.Exit(ExitWarning)
+	}
+	return nil
+}
+
+// findUnowned groups the connections no team owns, most connections first
+func findUnowned(m *owners.Map, conns []*postgres.Connection) []UnownedConnections {
+	type key struct{ app, user, addr string }
+	counts := make(map[key]int)
+	for _, conn := range conns {
+		if m.Match(conn.ApplicationName, conn.Username, conn.ClientAddr) == nil {
+			counts[key{conn.ApplicationName, conn.Username, conn.ClientAddr}]++
+		}
+	}
+
+	unowned := make([]UnownedConnections, 0, len(counts))
+	for k, n := range counts {
+		unowned = append(unowned, UnownedConnections{Application: k.app, User: k.user, ClientAddr: k.addr, Connections: n})
+	}
+	sort.Slice(unowned, func(i, j int) bool {
+		a, b := unowned[i], unowned[j]
+		if a.Connections != b.Connections {
+			return a.Connections > b.Connections
+		}
+		if a.Application != b.Application {
+			return a.Application < b.Application
+		}
+		if a.User != b.User {
+			return a.User < b.User
+		}
+		return a.ClientAddr < b.ClientAddr
+	})
+	return unowned
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/v0xg/pg-idle-guard/internal/owners"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestFindUnowned(t *testing.T) {
+	m, err := owners.Parse([]byte(`
+teams:
+  - name: payments
+    apps: ["payment-*"]
+  - name: data
+    cidrs: ["10.20.0.0/16"]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conns := []*postgres.Connection{
+		{PID: 1, ApplicationName: "payment-api", Username: "app", ClientAddr: "10.1.0.5"},
+		{PID: 2, ApplicationName: "", Username: "etl", ClientAddr: "10.20.1.1"},
+		{PID: 3, ApplicationName: "billing", Username: "app", ClientAddr: "10.1.0.6"},
+		{PID: 4, ApplicationName: "cron", Username: "ops", ClientAddr: "local"},
+		{PID: 5, ApplicationName: "cron", Username: "ops", ClientAddr: "local"},
+	}
+
+	unowned := findUnowned(m, conns)
+	if len(unowned) != 2 {
+		t.Fatalf("got %d unowned groups, want 2: %+v", len(unowned), unowned)
+	}
+	if unowned[0].Application != "cron" || unowned[0].Connections != 2 {
+		t.Errorf("first group = %+v, want cron with 2 connections", unowned[0])
+	}
+	if unowned[1].Application != "billing" || unowned[1].Connections != 1 {
+		t.Errorf("second group = %+v, want billing with 1 connection", unowned[1])
+	}
+
+	if got := findUnowned(nil, conns); len(got) != 4 {
+		t.Errorf("without an ownership file every connection group is unowned, got %d", len(got))
+	}
+}
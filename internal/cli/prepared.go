@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/v0xg/pg-idle-guard/internal/audit"
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+var preparedCmd = &cobra.Command{
+	Use:   "prepared",
+	Short: "Inspect and roll back prepared (two-phase) transactions",
+	Long: `Prepared transactions created with PREPARE TRANSACTION hold their locks
+and xmin until they are committed or rolled back, and never show up as
+"idle in transaction" in pg_stat_activity.`,
+}
+
+var preparedListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List prepared transactions",
+	Args:  cobra.NoArgs,
+	RunE:  runPreparedList,
+}
+
+var preparedRollbackCmd = &cobra.Command{
+	Use:   "rollback <gid>",
+	Short: "Roll back a prepared transaction by GID",
+	Long: `Roll back a prepared transaction by its global identifier (GID).
+
+This discards all work done in the transaction and releases its locks.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runPreparedRollback,
+}
+
+// PreparedTransactionStatus represents a single prepared transaction in JSON output
+type PreparedTransactionStatus struct {
+	GID      string  `json:"gid"`
+	Database string  `json:"database"`
+	Owner    string  `json:"owner"`
+	Prepared string  `json:"prepared"`
+	Age      string  `json:"age"`
+	AgeSec   float64 `json:"age_seconds"`
+	XminAge  int64   `json:"xmin_age"`
+	Severity string  `json:"severity"` // "warning", "critical", or ""
+}
+
+func init() {
+	preparedListCmd.Flags().Bool("json", false, "Output in JSON format")
+	preparedRollbackCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
+
+	preparedCmd.AddCommand(preparedListCmd)
+	preparedCmd.AddCommand(preparedRollbackCmd)
+	rootCmd.AddCommand(preparedCmd)
+}
+
+func runPreparedList(cmd *cobra.Command, args []string) error {
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	client, err := postgres.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	prepared, err := client.GetPreparedTransactions(ctx)
+	if err != nil {
+		return fmt.Errorf("getting prepared transactions: %w", err)
+	}
+
+	if jsonOutput {
+		output := make([]PreparedTransactionStatus, 0, len(prepared))
+		for _, p := range prepared {
+			age := p.Age()
+			output = append(output, PreparedTransactionStatus{
+				GID:      p.GID,
+				Database: p.Database,
+				Owner:    p.Owner,
+				Prepared: p.Prepared.UTC().Format(time.RFC3339),
+				Age:      util.FormatDuration(age),
+				AgeSec:   age.Seconds(),
+				XminAge:  p.XminAge,
+				Severity: preparedSeverity(age, cfg),
+			})
+		}
+		data, err := json.MarshalIndent(output, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(prepared) == 0 {
+		fmt.Println("No prepared transactions.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GID\tDatabase\tOwner\tAge\tXmin Age\t")
+	for _, p := range prepared {
+		age := p.Age()
+		indicator := ""
+		switch preparedSeverity(age, cfg) {
+		case "critical":
+			indicator = "[CRIT]"
+		case "warning":
+			indicator = "[WARN]"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			util.Truncate(p.GID, 40),
+			p.Database,
+			p.Owner,
+			util.FormatDuration(age),
+			p.XminAge,
+			indicator,
+		)
+	}
+	w.Flush()
+
+	return nil
+}
+
+func runPreparedRollback(cmd *cobra.Command, args []string) error {
+	force, _ := cmd.Flags().GetBool("force")
+	gid := args[0]
+
+	client, err := postgres.NewClient(cfg)
+	if err != nil {
+		return fmt.Errorf("connecting to database: %w", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	prepared, err := client.GetPreparedTransactions(ctx)
+	if err != nil {
+		return fmt.Errorf("getting prepared transactions: %w", err)
+	}
+
+	target := findPrepared(prepared, gid)
+	if target == nil {
+		return fmt.Errorf("no prepared transaction found with GID %q", gid)
+	}
+
+	// Show transaction details
+	fmt.Println()
+	fmt.Println("Prepared Transaction Details")
+	fmt.Println(strings.Repeat("-", 44))
+	fmt.Printf("GID:             %s\n", target.GID)
+	fmt.Printf("Transaction:     %s\n", target.Transaction)
+	fmt.Printf("Database:        %s\n", target.Database)
+	fmt.Printf("Owner:           %s\n", target.Owner)
+	fmt.Printf("Prepared:        %s (%s ago)\n", target.Prepared.Format(time.RFC3339), util.FormatDuration(target.Age()))
+	fmt.Printf("Xmin age:        %d\n", target.XminAge)
+	fmt.Println()
+
+	// Confirmation
+	if !force {
+		fmt.Println("Warning: This will roll back the prepared transaction and discard its work.")
+		fmt.Println()
+
+		fmt.Printf("Proceed? [y/N] ")
+		reader := bufio.NewReader(os.Stdin)
+		response, readErr := readLine(reader)
+		if readErr != nil {
+			return readErr
+		}
+		response = strings.ToLower(response)
+
+		if response != "y" && response != "yes" {
+			fmt.Println("Canceled.")
+			return nil
+		}
+	}
+
+	err = client.RollbackPrepared(ctx, target.GID, target.Database)
+
+	result, errMsg := auditResult(err)
+	recordAudit(audit.Entry{
+		Action: "rollback_prepared",
+		Target: "gid:" + target.GID,
+		Source: "cli",
+		Result: result,
+		Error:  errMsg,
+		Details: map[string]interface{}{
+			"database":    target.Database,
+			"owner":       target.Owner,
+			"age_seconds": target.Age().Seconds(),
+		},
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to roll back prepared transaction: %w", err)
+	}
+
+	fmt.Printf("[+] Prepared transaction %s rolled back\n", target.GID)
+	return nil
+}
+
+// findPrepared returns the prepared transaction with the given GID, or nil
+func findPrepared(prepared []*postgres.PreparedTransaction, gid string) *postgres.PreparedTransaction {
+	for _, p := range prepared {
+		if p.GID == gid {
+			return p
+		}
+	}
+	return nil
+}
+
+// preparedSeverity returns "warning", "critical" or "" for a prepared transaction age
+func preparedSeverity(age time.Duration, cfg *config.Config) string {
+	if age >= cfg.Thresholds.PreparedXact.Critical {
+		return "critical"
+	}
+	if age >= cfg.Thresholds.PreparedXact.Warning {
+		return "warning"
+	}
+	return ""
+}
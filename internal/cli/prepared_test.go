@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestFindPrepared(t *testing.T) {
+	prepared := []*postgres.PreparedTransaction{
+		{GID: "order-41", Database: "shop"},
+		{GID: "order-42", Database: "shop"},
+	}
+
+	if got := findPrepared(prepared, "order-42"); got == nil || got.GID != "order-42" {
+		t.Errorf("findPrepared(order-42) = %v, want order-42", got)
+	}
+	if got := findPrepared(prepared, "order-4"); got != nil {
+		t.Errorf("findPrepared(order-4) = %v, want nil (no prefix match)", got)
+	}
+}
+
+func TestPreparedSeverity(t *testing.T) {
+	testCfg := &config.Config{
+		Thresholds: config.ThresholdsConfig{
+			PreparedXact: config.PreparedXactThresholds{
+				Warning:  5 * time.Minute,
+				Critical: 30 * time.Minute,
+			},
+		},
+	}
+
+	tests := []struct {
+		age  time.Duration
+		want string
+	}{
+		{time.Minute, ""},
+		{5 * time.Minute, "warning"},
+		{29 * time.Minute, "warning"},
+		{30 * time.Minute, "critical"},
+		{24 * time.Hour, "critical"},
+	}
+
+	for _, tt := range tests {
+		if got := preparedSeverity(tt.age, testCfg); got != tt.want {
+			t.Errorf("preparedSeverity(%v) = %q, want %q", tt.age, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/v0xg/pg-idle-guard/internal/api"
+	"github.com/v0xg/pg-idle-guard/internal/silence"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+var silenceCmd = &cobra.Command{
+	Use:   "silence",
+	Short: "Quiet alerts about a known issue for a while",
+	Long: `Manage alert silences on the running daemon through its API.
+
+A silence suppresses every alert matching all of its matchers (app, user,
+pid, session, fingerprint, target) until it expires. Silences are kept in
+the daemon's state file, so they survive restarts.
+
+The daemon is reached at api.listen unless --url is given. Set --token or
+PGUARD_API_TOKEN when the API has tokens; adding and expiring silences needs
+the operator role. Over TLS, --ca-cert trusts a private CA and --cert/--key
+present a client certificate when the daemon sets api.tls.client_ca_file.`,
+}
+
+var silenceAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a silence",
+	Example: `  pguard silence add --app payment-api --duration 2h --comment "PAY-123, fix deploying"
+  pguard silence add --fingerprint 3f2a9c1e0b7d4a55 --duration 24h --comment "nightly report"
+  pguard silence add --target orders-staging --duration 30m --comment "load test"`,
+	Args: cobra.NoArgs,
+	RunE: runSilenceAdd,
+}
+
+var silenceListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List silences",
+	Args:  cobra.NoArgs,
+	RunE:  runSilenceList,
+}
+
+var silenceExpireCmd = &cobra.Command{
+	Use:   "expire <id>",
+	Short: "End a silence now",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runSilenceExpire,
+}
+
+func init() {
+	silenceCmd.PersistentFlags().String("url", "", "Daemon address, e.g. https://pguard:9182 (default from api.listen)")
+	silenceCmd.PersistentFlags().String("token", "", "API token (default $PGUARD_API_TOKEN)")
+	silenceCmd.PersistentFlags().String("ca-cert", "", "CA certificate to trust for the daemon's TLS certificate")
+	silenceCmd.PersistentFlags().String("cert", "", "Client certificate, for a daemon that requires one")
+	silenceCmd.PersistentFlags().String("key", "", "Client certificate key")
+
+	silenceAddCmd.Flags().String("app", "", "Application name (glob pattern)")
+	silenceAddCmd.Flags().String("user", "", "Role (glob pattern)")
+	silenceAddCmd.Flags().Int("pid", 0, "Backend PID")
+	silenceAddCmd.Flags().String("session", "", "Session ID, as shown by status --json")
+	silenceAddCmd.Flags().String("fingerprint", "", "Query fingerprint")
+	silenceAddCmd.Flags().String("target", "", "Target name (glob pattern)")
+	silenceAddCmd.Flags().Duration("duration", time.Hour, "How long the silence lasts")
+	silenceAddCmd.Flags().String("comment", "", "Why the alerts are silenced (required)")
+	silenceAddCmd.Flags().String("created-by", os.Getenv("USER"), "Creator, when the API has no tokens")
+	silenceListCmd.Flags().Bool("all", false, "Include silences that expired in the last week")
+	silenceListCmd.Flags().Bool("json", false, "Output in JSON format")
+
+	silenceCmd.AddCommand(silenceAddCmd)
+	silenceCmd.AddCommand(silenceListCmd)
+	silenceCmd.AddCommand(silenceExpireCmd)
+	rootCmd.AddCommand(silenceCmd)
+}
+
+func runSilenceAdd(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	req := api.SilenceRequest{}
+	req.App, _ = flags.GetString("app")
+	req.User, _ = flags.GetString("user")
+	req.PID, _ = flags.GetInt("pid")
+	req.SessionID, _ = flags.GetString("session")
+	req.Fingerprint, _ = flags.GetString("fingerprint")
+	req.Target, _ = flags.GetString("target")
+	req.Comment, _ = flags.GetString("comment")
+	req.CreatedBy, _ = flags.GetString("created-by")
+	duration, _ := flags.GetDuration("duration")
+	req.Duration = duration.String()
+
+	if req.Comment == "" {
+		return fmt.Errorf("--comment is required")
+	}
+
+	var created silence.Silence
+	if err := daemonRequest(cmd, http.MethodPost, "/silences", req, &created); err != nil {
+		return err
+	}
+	fmt.Printf("Silence %s added: %s until %s\n",
+		created.ID, silenceMatchers(&created), created.ExpiresAt.Local().Format(time.RFC1123))
+	return nil
+}
+
+func runSilenceList(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+
+	path := "/silences"
+	if all {
+		path += "?all=true"
+	}
+	var list []silence.Silence
+	if err := daemonRequest(cmd, http.MethodGet, path, nil, &list); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(list, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(list) == 0 {
+		fmt.Println("No silences.")
+		return nil
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMatchers\tExpires\tCreated By\tSuppressed\tComment")
+	for i := range list {
+		s := &list[i]
+		expires := "in " + util.FormatDuration(s.ExpiresAt.Sub(now))
+		if !s.Active(now) {
+			expires = "expired"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			s.ID,
+			silenceMatchers(s),
+			expires,
+			s.CreatedBy,
+			s.Suppressed,
+			util.Truncate(s.Comment, 40),
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+func runSilenceExpire(cmd *cobra.Command, args []string) error {
+	var expired silence.Silence
+	if err := daemonRequest(cmd, http.MethodPost, "/silences/"+args[0]+"/expire", nil, &expired); err != nil {
+		return err
+	}
+	fmt.Printf("Silence %s expired (%s)\n", expired.ID, silenceMatchers(&expired))
+	return nil
+}
+
+// silenceMatchers describes what a silence matches, e.g. "app=payment-* target=prod"
+func silenceMatchers(s *silence.Silence) string {
+	var parts []string
+	add := func(name, value string) {
+		if value != "" {
+			parts = append(parts, name+"="+value)
+		}
+	}
+	add("app", s.App)
+	add("user", s.User)
+	if s.PID != 0 {
+		add("pid", fmt.Sprintf("%d", s.PID))
+	}
+	add("session", s.SessionID)
+	add("fingerprint", s.Fingerprint)
+	add("target", s.Target)
+	return strings.Join(parts, " ")
+}
+
+// daemonURL returns the base URL of the running daemon's API
+func daemonURL(cmd *cobra.Command) (string, error) {
+	if u, _ := cmd.Flags().GetString("url"); u != "" {
+		return strings.TrimSuffix(u, "/") + api.Prefix, nil
+	}
+
+	host, port, err := net.SplitHostPort(cfg.API.Listen)
+	if err != nil {
+		return "", fmt.Errorf("invalid api.listen %q: %w", cfg.API.Listen, err)
+	}
+	// A wildcard listener is reachable on loopback
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	scheme := "http"
+	if cfg.API.TLS.CertFile != "" {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port) + api.Prefix, nil
+}
+
+// daemonClient returns an HTTP client that trusts --ca-cert and presents
+// --cert/--key, if given
+func daemonClient(cmd *cobra.Command) (*http.Client, error) {
+	caFile, _ := cmd.Flags().GetString("ca-cert")
+	certFile, _ := cmd.Flags().GetString("cert")
+	keyFile, _ := cmd.Flags().GetString("key")
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return http.DefaultClient, nil
+	}
+
+	tlsConfig, err := api.NewClientTLSConfig(caFile, certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+// daemonRequest calls the daemon's API, encoding body (if any) as JSON and
+// decoding the response into out
+func daemonRequest(cmd *cobra.Command, method, path string, body, out interface{}) error {
+	base, err := daemonURL(cmd)
+	if err != nil {
+		return err
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("encoding request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, base+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	if method == http.MethodPost {
+		// The API only accepts POSTs sent as JSON, body or not
+		req.Header.Set("Content-Type", "application/json")
+	}
+	token, _ := cmd.Flags().GetString("token")
+	if token == "" {
+		token = os.Getenv("PGUARD_API_TOKEN")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client, err := daemonClient(cmd)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("contacting daemon (is it running with the API enabled?): %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var e api.ErrorResponse
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return fmt.Errorf("daemon: %s", e.Error)
+		}
+		return fmt.Errorf("daemon: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
@@ -1,16 +1,21 @@
 package cli
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
+	"github.com/v0xg/pg-idle-guard/internal/api"
 	"github.com/v0xg/pg-idle-guard/internal/config"
 	"github.com/v0xg/pg-idle-guard/internal/postgres"
 	"github.com/v0xg/pg-idle-guard/internal/util"
@@ -21,82 +26,80 @@ const (
 	ExitOK       = 0
 	ExitWarning  = 1
 	ExitCritical = 2
+	ExitUnknown  = 3 // Status could not be determined (nagios format only)
 )
 
-// StatusOutput represents the JSON output of the status command
-type StatusOutput struct {
-	Status           string                  `json:"status"` // "ok", "warning", "critical"
-	Pool             PoolStatus              `json:"pool"`
-	IdleTransactions []IdleTransactionStatus `json:"idle_transactions"`
-	Connections      []ConnectionStatus      `json:"connections,omitempty"` // Only with --verbose
-	Thresholds       ThresholdStatus         `json:"thresholds"`
-}
-
-// PoolStatus represents connection pool statistics
-type PoolStatus struct {
-	MaxConnections       int     `json:"max_connections"`
-	TotalConnections     int     `json:"total_connections"`
-	ActiveConnections    int     `json:"active_connections"`
-	IdleConnections      int     `json:"idle_connections"`
-	IdleInTransaction    int     `json:"idle_in_transaction"`
-	AvailableConnections int     `json:"available_connections"`
-	UsagePercent         float64 `json:"usage_percent"`
-}
-
-// IdleTransactionStatus represents a single idle transaction
-type IdleTransactionStatus struct {
-	PID         int     `json:"pid"`
-	Application string  `json:"application"`
-	Duration    string  `json:"duration"`
-	DurationSec float64 `json:"duration_seconds"`
-	Query       string  `json:"query"`
-	Severity    string  `json:"severity"` // "warning", "critical", or ""
-}
-
-// ConnectionStatus represents a single connection (for verbose output)
-type ConnectionStatus struct {
-	PID         int    `json:"pid"`
-	State       string `json:"state"`
-	Application string `json:"application"`
-	ClientAddr  string `json:"client_addr"`
-	Duration    string `json:"duration"`
-}
-
-// ThresholdStatus shows the configured thresholds
-type ThresholdStatus struct {
-	IdleWarning     string `json:"idle_warning"`
-	IdleCritical    string `json:"idle_critical"`
-	PoolWarningPct  int    `json:"pool_warning_percent"`
-	PoolCriticalPct int    `json:"pool_critical_percent"`
-}
+// Output formats for status
+var statusFormats = []string{"table", "json", "yaml", "csv", "prom", "nagios"}
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show current connection pool status",
 	Long: `Display the current state of PostgreSQL connections, including idle transactions.
 
+Output formats (--format):
+  table  - Human-readable tables (default)
+  json   - JSON document (same as --json)
+  yaml   - YAML document with the same fields as json
+  csv    - One row per connection
+  prom   - Prometheus text format for the node_exporter textfile collector
+  nagios - Nagios/Icinga plugin line with perfdata
+
 Exit codes:
   0 - All healthy (no thresholds exceeded)
   1 - Warning threshold exceeded
-  2 - Critical threshold exceeded`,
+  2 - Critical threshold exceeded
+  3 - Unknown; the database could not be queried (nagios format only)`,
 	RunE: runStatus,
 }
 
 func init() {
 	statusCmd.Flags().BoolP("verbose", "v", false, "Show all connections, not just idle transactions")
-	statusCmd.Flags().Bool("json", false, "Output in JSON format")
+	statusCmd.Flags().Bool("json", false, "Output in JSON format (same as --format json)")
+	statusCmd.Flags().String("format", "table", "Output format: "+strings.Join(statusFormats, ", "))
 	statusCmd.Flags().BoolP("quiet", "q", false, "No output, only exit code")
+	statusCmd.Flags().StringP("output", "o", "", "Write output to a file (atomically replaced) instead of stdout")
 }
 
 func runStatus(cmd *cobra.Command, args []string) error {
 	verbose, _ := cmd.Flags().GetBool("verbose")
-	jsonOutput, _ := cmd.Flags().GetBool("json")
 	quiet, _ := cmd.Flags().GetBool("quiet")
+	outputPath, _ := cmd.Flags().GetString("output")
+
+	format, err := statusFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	// Plugins must report failures to query as UNKNOWN rather than a generic
+	// error, and the textfile collector needs pguard_up 0 instead of stale data
+	fail := func(err error) error {
+		switch format {
+		case "nagios":
+			if !quiet {
+				if writeErr := writeStatusOutput(outputPath, []byte(formatNagiosUnknown(err)+"\n")); writeErr != nil {
+					return writeErr
+				}
+			}
+			os.Exit(ExitUnknown)
+		case "prom":
+			var buf bytes.Buffer
+			writePromDown(&buf)
+			if writeErr := writeStatusOutput(outputPath, buf.Bytes()); writeErr != nil {
+				return writeErr
+			}
+		}
+		return err
+	}
+
+	if err := loadOwnership(); err != nil {
+		return fail(err)
+	}
 
 	// Create PostgreSQL client
 	client, err := postgres.NewClient(cfg)
 	if err != nil {
-		return fmt.Errorf("connecting to database: %w", err)
+		return fail(fmt.Errorf("connecting to database: %w", err))
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -106,7 +109,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		cancel()
 		client.Close()
-		return fmt.Errorf("getting pool stats: %w", err)
+		return fail(fmt.Errorf("getting pool stats: %w", err))
 	}
 
 	// Get all connections
@@ -114,7 +117,37 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		cancel()
 		client.Close()
-		return fmt.Errorf("getting connections: %w", err)
+		return fail(fmt.Errorf("getting connections: %w", err))
+	}
+
+	// Get replication slots
+	var slots []*postgres.ReplicationSlot
+	if cfg.Thresholds.ReplicationSlot.Enabled {
+		slots, err = client.GetReplicationSlots(ctx)
+		if err != nil {
+			cancel()
+			client.Close()
+			return fail(fmt.Errorf("getting replication slots: %w", err))
+		}
+	}
+
+	// Get per-role and per-database usage against their own limits
+	var roles, databases []*postgres.LimitUsage
+	if cfg.Thresholds.RoleLimit.Enabled {
+		roles, err = client.GetRoleUsage(ctx)
+		if err != nil {
+			cancel()
+			client.Close()
+			return fail(fmt.Errorf("getting role usage: %w", err))
+		}
+	}
+	if cfg.Thresholds.DatabaseLimit.Enabled {
+		databases, err = client.GetDatabaseUsage(ctx)
+		if err != nil {
+			cancel()
+			client.Close()
+			return fail(fmt.Errorf("getting database usage: %w", err))
+		}
 	}
 
 	// Build idle transactions list
@@ -156,6 +189,41 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Check replication slot thresholds
+	for _, slot := range slots {
+		switch severity, _ := slotSeverity(slot, cfg); severity {
+		case "critical":
+			exitCode = ExitCritical
+			overallStatus = "critical"
+		case "warning":
+			if exitCode < ExitWarning {
+				exitCode = ExitWarning
+				overallStatus = "warning"
+			}
+		}
+	}
+
+	// Check per-role and per-database limits
+	limitSeverities := make([]string, 0, len(roles)+len(databases))
+	for _, u := range roles {
+		limitSeverities = append(limitSeverities, limitSeverity(u, cfg.Thresholds.RoleLimit))
+	}
+	for _, u := range databases {
+		limitSeverities = append(limitSeverities, limitSeverity(u, cfg.Thresholds.DatabaseLimit))
+	}
+	for _, severity := range limitSeverities {
+		switch severity {
+		case "critical":
+			exitCode = ExitCritical
+			overallStatus = "critical"
+		case "warning":
+			if exitCode < ExitWarning {
+				exitCode = ExitWarning
+				overallStatus = "warning"
+			}
+		}
+	}
+
 	// Quiet mode: just exit with code
 	if quiet {
 		cancel()
@@ -163,103 +231,140 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		os.Exit(exitCode)
 	}
 
-	// JSON output mode
-	if jsonOutput {
+	// Render into a buffer so --output can replace the file in one step
+	var buf bytes.Buffer
+	switch format {
+	case "nagios":
+		fmt.Fprintln(&buf, formatNagios(stats, idleConns, slots, roles, databases, exitCode, cfg))
+	case "json", "yaml":
 		output := buildStatusOutput(stats, conns, idleConns, overallStatus, verbose, cfg)
-		data, err := json.MarshalIndent(output, "", "  ")
-		if err != nil {
+		output.ReplicationSlots = buildSlotStatus(slots, cfg)
+		output.Roles = buildLimitStatus(roles, cfg.Thresholds.RoleLimit)
+		output.Databases = buildLimitStatus(databases, cfg.Thresholds.DatabaseLimit)
+		if err := encodeStatus(&buf, format, output); err != nil {
 			cancel()
 			client.Close()
-			return fmt.Errorf("marshaling JSON: %w", err)
+			return err
 		}
-		fmt.Println(string(data))
-		cancel()
-		client.Close()
-		os.Exit(exitCode)
+	case "csv":
+		if err := writeConnectionsCSV(&buf, conns, cfg); err != nil {
+			cancel()
+			client.Close()
+			return err
+		}
+	case "prom":
+		writePromStatus(&buf, stats, idleConns, slots, roles, databases, exitCode, cfg)
+	default:
+		printHumanStatus(&buf, stats, conns, idleConns, usagePercent, verbose, cfg)
+		printLimitStatus(&buf, "Busiest Roles", "Role", roles, cfg.Thresholds.RoleLimit)
+		printLimitStatus(&buf, "Busiest Databases", "Database", databases, cfg.Thresholds.DatabaseLimit)
+		printSlotStatus(&buf, slots, cfg)
 	}
 
-	// Human-readable output
-	printHumanStatus(stats, conns, idleConns, usagePercent, verbose, cfg)
-
 	cancel()
 	client.Close()
+
+	if err := writeStatusOutput(outputPath, buf.Bytes()); err != nil {
+		return err
+	}
 	os.Exit(exitCode)
 	return nil // unreachable but satisfies compiler
 }
 
-func buildStatusOutput(stats *postgres.PoolStats, conns, idleConns []*postgres.Connection, status string, verbose bool, cfg *config.Config) StatusOutput {
-	output := StatusOutput{
-		Status: status,
-		Pool: PoolStatus{
-			MaxConnections:       stats.MaxConnections,
-			TotalConnections:     stats.TotalConnections,
-			ActiveConnections:    stats.ActiveConnections,
-			IdleConnections:      stats.IdleConnections,
-			IdleInTransaction:    stats.IdleInTransaction,
-			AvailableConnections: stats.AvailableConnections,
-			UsagePercent:         stats.UsagePercent(),
-		},
-		Thresholds: ThresholdStatus{
-			IdleWarning:     cfg.Thresholds.IdleTransaction.Warning.String(),
-			IdleCritical:    cfg.Thresholds.IdleTransaction.Critical.String(),
-			PoolWarningPct:  cfg.Thresholds.ConnectionPool.WarningPercent,
-			PoolCriticalPct: cfg.Thresholds.ConnectionPool.CriticalPercent,
-		},
+// encodeStatus writes the status document as JSON or YAML
+func encodeStatus(w io.Writer, format string, output api.StatusOutput) error {
+	if format == "yaml" {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(output); err != nil {
+			return fmt.Errorf("marshaling YAML: %w", err)
+		}
+		return enc.Close()
 	}
 
-	// Build idle transactions list
-	output.IdleTransactions = make([]IdleTransactionStatus, 0, len(idleConns))
-	for _, conn := range idleConns {
-		duration := conn.IdleDuration()
-		severity := ""
-		if duration >= cfg.Thresholds.IdleTransaction.Critical {
-			severity = "critical"
-		} else if duration >= cfg.Thresholds.IdleTransaction.Warning {
-			severity = "warning"
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// writeStatusOutput writes to stdout, or atomically replaces path so readers
+// such as the node_exporter textfile collector never see a partial file
+func writeStatusOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := util.WriteFileAtomic(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// statusFormat resolves --format, treating --json as --format json
+func statusFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	if jsonOutput {
+		if cmd.Flags().Changed("format") && format != "json" {
+			return "", fmt.Errorf("--json conflicts with --format %s", format)
+		}
+		return "json", nil
+	}
+
+	for _, f := range statusFormats {
+		if format == f {
+			return format, nil
 		}
+	}
+	return "", fmt.Errorf("unknown format %q (valid: %s)", format, strings.Join(statusFormats, ", "))
+}
 
-		output.IdleTransactions = append(output.IdleTransactions, IdleTransactionStatus{
-			PID:         conn.PID,
-			Application: conn.ApplicationName,
-			Duration:    util.FormatDuration(duration),
-			DurationSec: duration.Seconds(),
-			Query:       util.TruncateQuery(conn.Query, 200),
-			Severity:    severity,
-		})
+func buildStatusOutput(stats *postgres.PoolStats, conns, idleConns []*postgres.Connection, status string, verbose bool, cfg *config.Config) api.StatusOutput {
+	output := api.StatusOutput{
+		Status:     status,
+		Pool:       api.NewPoolStatus(stats),
+		Thresholds: api.NewThresholdStatus(cfg),
+	}
+
+	// Build idle transactions list
+	output.IdleTransactions = make([]api.IdleTransactionStatus, 0, len(idleConns))
+	for _, conn := range idleConns {
+		status := api.NewIdleTransactionStatus(conn, cfg)
+		status.Team = ownership.TeamName(conn.ApplicationName, conn.Username, conn.ClientAddr)
+		output.IdleTransactions = append(output.IdleTransactions, status)
 	}
 
 	// Add all connections if verbose
 	if verbose {
-		output.Connections = make([]ConnectionStatus, 0, len(conns))
+		output.Connections = make([]api.ConnectionStatus, 0, len(conns))
 		for _, conn := range conns {
-			output.Connections = append(output.Connections, ConnectionStatus{
-				PID:         conn.PID,
-				State:       string(conn.State),
-				Application: conn.ApplicationName,
-				ClientAddr:  conn.ClientAddr,
-				Duration:    util.FormatDuration(conn.IdleDuration()),
-			})
+			status := api.NewConnectionStatus(conn)
+			status.Team = ownership.TeamName(conn.ApplicationName, conn.Username, conn.ClientAddr)
+			output.Connections = append(output.Connections, status)
 		}
 	}
 
 	return output
 }
 
-func printHumanStatus(stats *postgres.PoolStats, conns, idleConns []*postgres.Connection, usagePercent float64, verbose bool, cfg *config.Config) {
+func printHumanStatus(out io.Writer, stats *postgres.PoolStats, conns, idleConns []*postgres.Connection, usagePercent float64, verbose bool, cfg *config.Config) {
 	// Print pool status
-	fmt.Println()
-	fmt.Printf("Connection Pool (max: %d)\n", stats.MaxConnections)
-	fmt.Println(strings.Repeat("-", 44))
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Connection Pool (max: %d)\n", stats.MaxConnections)
+	fmt.Fprintln(out, strings.Repeat("-", 44))
 
-	fmt.Printf("Active:               %3d\n", stats.ActiveConnections)
-	fmt.Printf("Idle:                 %3d\n", stats.IdleConnections)
+	fmt.Fprintf(out, "Active:               %3d\n", stats.ActiveConnections)
+	fmt.Fprintf(out, "Idle:                 %3d\n", stats.IdleConnections)
 
 	idleIndicator := ""
 	if stats.IdleInTransaction > 0 {
 		idleIndicator = "  [!]"
 	}
-	fmt.Printf("Idle in transaction:  %3d%s\n", stats.IdleInTransaction, idleIndicator)
-	fmt.Printf("Available:            %3d\n", stats.AvailableConnections)
+	fmt.Fprintf(out, "Idle in transaction:  %3d%s\n", stats.IdleInTransaction, idleIndicator)
+	fmt.Fprintf(out, "Available:            %3d\n", stats.AvailableConnections)
 
 	// Usage percentage with indicator
 	usageIndicator := ""
@@ -268,16 +373,22 @@ func printHumanStatus(stats *postgres.PoolStats, conns, idleConns []*postgres.Co
 	} else if usagePercent >= float64(cfg.Thresholds.ConnectionPool.WarningPercent) {
 		usageIndicator = " [WARN]"
 	}
-	fmt.Printf("\nUsage: %.1f%% (%d/%d)%s\n", usagePercent, stats.TotalConnections, stats.MaxConnections-stats.ReservedSuperuser, usageIndicator)
+	fmt.Fprintf(out, "\nUsage: %.1f%% (%d/%d)%s\n", usagePercent, stats.UsedConnections(), stats.Capacity(), usageIndicator)
+
+	printCapacityBreakdown(out, stats, cfg)
 
 	// Print idle transactions
 	if len(idleConns) > 0 {
-		fmt.Println()
-		fmt.Println("Idle Transactions")
-		fmt.Println(strings.Repeat("-", 80))
-
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "PID\tAge\tApplication\tQuery")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Idle Transactions")
+		fmt.Fprintln(out, strings.Repeat("-", 80))
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		if ownership != nil {
+			fmt.Fprintln(w, "PID\tAge\tApplication\tTeam\tQuery")
+		} else {
+			fmt.Fprintln(w, "PID\tAge\tApplication\tQuery")
+		}
 
 		for _, conn := range idleConns {
 			duration := conn.IdleDuration()
@@ -285,34 +396,46 @@ func printHumanStatus(stats *postgres.PoolStats, conns, idleConns []*postgres.Co
 
 			query := util.TruncateQuery(conn.Query, 40)
 
+			app := util.Truncate(conn.ApplicationName, 15)
+			if ownership != nil {
+				app += "\t" + teamLabel(conn)
+			}
 			fmt.Fprintf(w, "%d\t%s\t%s\t%s %s\n",
 				conn.PID,
 				util.FormatDuration(duration),
-				util.Truncate(conn.ApplicationName, 15),
+				app,
 				query,
 				severity,
 			)
 		}
 		w.Flush()
 	} else {
-		fmt.Println()
-		fmt.Println("No idle transactions.")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "No idle transactions.")
 	}
 
 	// Show all connections if verbose
 	if verbose && len(conns) > 0 {
-		fmt.Println()
-		fmt.Println("All Connections")
-		fmt.Println(strings.Repeat("-", 80))
-
-		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "PID\tState\tApplication\tClient\tAge")
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "All Connections")
+		fmt.Fprintln(out, strings.Repeat("-", 80))
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		if ownership != nil {
+			fmt.Fprintln(w, "PID\tState\tApplication\tTeam\tClient\tAge")
+		} else {
+			fmt.Fprintln(w, "PID\tState\tApplication\tClient\tAge")
+		}
 
 		for _, conn := range conns {
+			app := util.Truncate(conn.ApplicationName, 15)
+			if ownership != nil {
+				app += "\t" + teamLabel(conn)
+			}
 			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
 				conn.PID,
 				conn.State,
-				util.Truncate(conn.ApplicationName, 15),
+				app,
 				conn.ClientAddr,
 				util.FormatDuration(conn.IdleDuration()),
 			)
@@ -320,7 +443,15 @@ func printHumanStatus(stats *postgres.PoolStats, conns, idleConns []*postgres.Co
 		w.Flush()
 	}
 
-	fmt.Println()
+	fmt.Fprintln(out)
+}
+
+// teamLabel returns the team owning conn for table output, or "-"
+func teamLabel(conn *postgres.Connection) string {
+	if team := ownership.TeamName(conn.ApplicationName, conn.Username, conn.ClientAddr); team != "" {
+		return team
+	}
+	return "-"
 }
 
 func getSeverity(duration time.Duration, cfg *config.Config) string {
@@ -332,3 +463,202 @@ func getSeverity(duration time.Duration, cfg *config.Config) string {
 	}
 	return ""
 }
+
+// slotSeverity returns the severity of a replication slot and the reason for it.
+// Retained WAL and xmin age are checked against their thresholds; an inactive
+// slot is at least a warning when alert_inactive is set.
+func slotSeverity(slot *postgres.ReplicationSlot, cfg *config.Config) (severity, reason string) {
+	t := cfg.Thresholds.ReplicationSlot
+
+	switch {
+	case slot.RetainedBytes >= int64(t.CriticalRetained):
+		return "critical", fmt.Sprintf("retaining %s of WAL", util.FormatBytes(slot.RetainedBytes))
+	case slot.XminAge >= t.CriticalXminAge:
+		return "critical", fmt.Sprintf("xmin age %d", slot.XminAge)
+	case slot.RetainedBytes >= int64(t.WarningRetained):
+		return "warning", fmt.Sprintf("retaining %s of WAL", util.FormatBytes(slot.RetainedBytes))
+	case slot.XminAge >= t.WarningXminAge:
+		return "warning", fmt.Sprintf("xmin age %d", slot.XminAge)
+	case t.AlertInactive && !slot.Active:
+		return "warning", "slot is inactive"
+	}
+	return "", ""
+}
+
+func buildSlotStatus(slots []*postgres.ReplicationSlot, cfg *config.Config) []api.ReplicationSlotStatus {
+	if len(slots) == 0 {
+		return nil
+	}
+
+	output := make([]api.ReplicationSlotStatus, 0, len(slots))
+	for _, slot := range slots {
+		severity, reason := slotSeverity(slot, cfg)
+		output = append(output, api.ReplicationSlotStatus{
+			Name:          slot.Name,
+			SlotType:      slot.SlotType,
+			Database:      slot.Database,
+			Active:        slot.Active,
+			RetainedBytes: slot.RetainedBytes,
+			XminAge:       slot.XminAge,
+			Severity:      severity,
+			Reason:        reason,
+		})
+	}
+	return output
+}
+
+func printSlotStatus(out io.Writer, slots []*postgres.ReplicationSlot, cfg *config.Config) {
+	if len(slots) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, "Replication Slots")
+	fmt.Fprintln(out, strings.Repeat("-", 80))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Slot\tType\tActive\tRetained\tXmin Age\t")
+
+	for _, slot := range slots {
+		active := "yes"
+		if !slot.Active {
+			active = "no"
+		}
+		indicator := ""
+		switch severity, _ := slotSeverity(slot, cfg); severity {
+		case "critical":
+			indicator = "[CRIT]"
+		case "warning":
+			indicator = "[WARN]"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n",
+			util.Truncate(slot.Name, 30),
+			slot.SlotType,
+			active,
+			util.FormatBytes(slot.RetainedBytes),
+			slot.XminAge,
+			indicator,
+		)
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+}
+
+// limitSeverity returns the severity of a role's or database's usage of its
+// own connection limit. Usage without a limit never has a severity.
+func limitSeverity(u *postgres.LimitUsage, t config.ConnectionLimitThresholds) string {
+	if !u.HasLimit() {
+		return ""
+	}
+	percent := u.UsagePercent()
+	if percent >= float64(t.CriticalPercent) {
+		return "critical"
+	}
+	if percent >= float64(t.WarningPercent) {
+		return "warning"
+	}
+	return ""
+}
+
+// busiestLimitUsage returns up to n entries, those closest to their own limit
+// first, then the rest by connection count
+func busiestLimitUsage(usage []*postgres.LimitUsage, n int) []*postgres.LimitUsage {
+	sorted := make([]*postgres.LimitUsage, len(usage))
+	copy(sorted, usage)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := sorted[i].UsagePercent(), sorted[j].UsagePercent()
+		if pi != pj {
+			return pi > pj
+		}
+		return sorted[i].Connections > sorted[j].Connections
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+func buildLimitStatus(usage []*postgres.LimitUsage, t config.ConnectionLimitThresholds) []api.LimitStatus {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	output := make([]api.LimitStatus, 0, len(usage))
+	for _, u := range busiestLimitUsage(usage, len(usage)) {
+		output = append(output, api.LimitStatus{
+			Name:         u.Name,
+			Connections:  u.Connections,
+			Limit:        u.Limit,
+			UsagePercent: u.UsagePercent(),
+			Severity:     limitSeverity(u, t),
+		})
+	}
+	return output
+}
+
+func printLimitStatus(out io.Writer, title, column string, usage []*postgres.LimitUsage, t config.ConnectionLimitThresholds) {
+	if len(usage) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out, title)
+	fmt.Fprintln(out, strings.Repeat("-", 44))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tConns\tLimit\tUsage\t\n", column)
+
+	for _, u := range busiestLimitUsage(usage, 5) {
+		limit, percent := "-", "-"
+		if u.HasLimit() {
+			limit = fmt.Sprintf("%d", u.Limit)
+			percent = fmt.Sprintf("%.0f%%", u.UsagePercent())
+		}
+		indicator := ""
+		switch limitSeverity(u, t) {
+		case "critical":
+			indicator = "[CRIT]"
+		case "warning":
+			indicator = "[WARN]"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n",
+			util.Truncate(u.Name, 30),
+			u.Connections,
+			limit,
+			percent,
+			indicator,
+		)
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+}
+
+// printCapacityBreakdown shows how max_connections is reduced to the slots a
+// normal role can use, and which backends are left out of usage
+func printCapacityBreakdown(out io.Writer, stats *postgres.PoolStats, cfg *config.Config) {
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Capacity")
+	fmt.Fprintln(out, strings.Repeat("-", 44))
+	fmt.Fprintf(out, "max_connections:                  %4d\n", stats.MaxConnections)
+	fmt.Fprintf(out, "superuser_reserved_connections:   %4d\n", -stats.ReservedSuperuser)
+	if stats.ServerVersionNum >= 160000 {
+		fmt.Fprintf(out, "reserved_connections:             %4d\n", -stats.ReservedConnections)
+	}
+	if stats.IsRDS {
+		fmt.Fprintf(out, "rds_superuser_reserved:           %4d\n", -stats.ReservedRDS)
+	}
+	fmt.Fprintf(out, "Usable by normal roles:           %4d\n", stats.Capacity())
+
+	if stats.OwnConnections > 0 || stats.RDSAdminConnections > 0 {
+		fmt.Fprintln(out)
+		fmt.Fprintf(out, "pguard backends:                  %4d%s\n", stats.OwnConnections, excludedMarker(cfg.Thresholds.ConnectionPool.ExcludeOwnConnections))
+		if stats.IsRDS || stats.RDSAdminConnections > 0 {
+			fmt.Fprintf(out, "rdsadmin backends:                %4d%s\n", stats.RDSAdminConnections, excludedMarker(cfg.Thresholds.ConnectionPool.ExcludeRDSAdmin))
+		}
+	}
+}
+
+func excludedMarker(excluded bool) string {
+	if excluded {
+		return "  (excluded from usage)"
+	}
+	return ""
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+// writeConnectionsCSV writes one row per connection
+func writeConnectionsCSV(w io.Writer, conns []*postgres.Connection, cfg *config.Config) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"pid", "state", "application", "user", "database", "client_addr",
+		"backend_start", "state_seconds", "xact_seconds", "wait_event", "severity", "team", "query",
+	}
+	if err := cw.Write(header); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+
+	for _, conn := range conns {
+		duration := conn.IdleDuration()
+		xact := ""
+		if conn.XactStart != nil {
+			xact = strconv.FormatFloat(conn.TransactionDuration().Seconds(), 'f', 0, 64)
+		}
+		wait := ""
+		if conn.WaitEventType != nil && conn.WaitEvent != nil {
+			wait = *conn.WaitEventType + ":" + *conn.WaitEvent
+		}
+		severity := ""
+		if conn.IsIdleInTransaction() {
+			if duration >= cfg.Thresholds.IdleTransaction.Critical {
+				severity = "critical"
+			} else if duration >= cfg.Thresholds.IdleTransaction.Warning {
+				severity = "warning"
+			}
+		}
+
+		record := []string{
+			strconv.Itoa(conn.PID),
+			string(conn.State),
+			conn.ApplicationName,
+			conn.Username,
+			conn.Database,
+			conn.ClientAddr,
+			conn.BackendStart.UTC().Format(time.RFC3339),
+			strconv.FormatFloat(duration.Seconds(), 'f', 0, 64),
+			xact,
+			wait,
+			severity,
+			ownership.TeamName(conn.ApplicationName, conn.Username, conn.ClientAddr),
+			strings.Join(strings.Fields(conn.Query), " "),
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("writing CSV: %w", err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	return nil
+}
+
+// promWriter writes metrics in the Prometheus text exposition format,
+// emitting HELP/TYPE once per metric family
+type promWriter struct {
+	w    io.Writer
+	seen map[string]bool
+}
+
+func newPromWriter(w io.Writer) *promWriter {
+	return &promWriter{w: w, seen: make(map[string]bool)}
+}
+
+// gauge writes one sample. labels alternate name, value.
+func (p *promWriter) gauge(name, help string, value float64, labels ...string) {
+	p.sample("gauge", name, help, value, labels...)
+}
+
+// counter writes one sample of a counter
+func (p *promWriter) counter(name, help string, value float64, labels ...string) {
+	p.sample("counter", name, help, value, labels...)
+}
+
+func (p *promWriter) sample(metricType, name, help string, value float64, labels ...string) {
+	if !p.seen[name] {
+		fmt.Fprintf(p.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+		p.seen[name] = true
+	}
+
+	var sb strings.Builder
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteString("{")
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			fmt.Fprintf(&sb, "%s=\"%s\"", labels[i], escapePromLabel(labels[i+1]))
+		}
+		sb.WriteString("}")
+	}
+	fmt.Fprintf(p.w, "%s %s\n", sb.String(), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// promLabelEscaper escapes the three characters the exposition format
+// requires in label values
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapePromLabel(s string) string {
+	return promLabelEscaper.Replace(s)
+}
+
+// writePromStatus writes status as metrics for the node_exporter textfile collector
+func writePromStatus(w io.Writer, stats *postgres.PoolStats, idleConns []*postgres.Connection, slots []*postgres.ReplicationSlot,
+	roles, databases []*postgres.LimitUsage, exitCode int, cfg *config.Config) {
+	p := newPromWriter(w)
+
+	p.gauge("pguard_up", "Whether pguard could query the database.", 1)
+	p.gauge("pguard_status", "Overall status: 0 ok, 1 warning, 2 critical.", float64(exitCode))
+	p.gauge("pguard_last_run_timestamp_seconds", "Unix time this file was written.", float64(time.Now().Unix()))
+
+	p.gauge("pguard_connections_max", "max_connections setting.", float64(stats.MaxConnections))
+	p.gauge("pguard_connections_reserved", "Connection slots reserved for special roles.", float64(stats.ReservedSuperuser), "class", "superuser")
+	p.gauge("pguard_connections_reserved", "Connection slots reserved for special roles.", float64(stats.ReservedConnections), "class", "reserved_connections")
+	p.gauge("pguard_connections_reserved", "Connection slots reserved for special roles.", float64(stats.ReservedRDS), "class", "rds")
+	p.gauge("pguard_connections_capacity", "Connection slots usable by normal roles.", float64(stats.Capacity()))
+	p.gauge("pguard_connections_total", "Client backends, including excluded ones.", float64(stats.TotalConnections))
+	p.gauge("pguard_connections_used", "Client backends counted against capacity.", float64(stats.UsedConnections()))
+	p.gauge("pguard_connections_available", "Connection slots still available to normal roles.", float64(stats.AvailableConnections))
+	p.gauge("pguard_connections", "Counted client backends by state.", float64(stats.ActiveConnections), "state", "active")
+	p.gauge("pguard_connections", "Counted client backends by state.", float64(stats.IdleConnections), "state", "idle")
+	p.gauge("pguard_connections", "Counted client backends by state.", float64(stats.IdleInTransaction), "state", "idle_in_transaction")
+	p.gauge("pguard_connections_excluded", "Backends excluded from usage by exclude_own_connections and exclude_rdsadmin.", float64(stats.ExcludedConnections))
+	p.gauge("pguard_connections_by_owner", "Client backends of pguard and rdsadmin, whether excluded or not.", float64(stats.OwnConnections), "owner", "pguard")
+	p.gauge("pguard_connections_by_owner", "Client backends of pguard and rdsadmin, whether excluded or not.", float64(stats.RDSAdminConnections), "owner", "rdsadmin")
+	p.gauge("pguard_pool_usage_ratio", "Used connections divided by capacity.", stats.UsagePercent()/100)
+
+	var oldest time.Duration
+	var warning, critical int
+	for _, conn := range idleConns {
+		d := conn.IdleDuration()
+		if d > oldest {
+			oldest = d
+		}
+		if d >= cfg.Thresholds.IdleTransaction.Critical {
+			critical++
+		} else if d >= cfg.Thresholds.IdleTransaction.Warning {
+			warning++
+		}
+	}
+	p.gauge("pguard_idle_transactions", "Idle-in-transaction sessions by severity.", float64(len(idleConns)-warning-critical), "severity", "none")
+	p.gauge("pguard_idle_transactions", "Idle-in-transaction sessions by severity.", float64(warning), "severity", "warning")
+	p.gauge("pguard_idle_transactions", "Idle-in-transaction sessions by severity.", float64(critical), "severity", "critical")
+	p.gauge("pguard_idle_transaction_oldest_seconds", "Age of the oldest idle-in-transaction session.", oldest.Seconds())
+
+	// Each family's samples must be contiguous, so every metric gets its own loop
+	for _, slot := range slots {
+		active := 0.0
+		if slot.Active {
+			active = 1
+		}
+		p.gauge("pguard_replication_slot_active", "Whether the replication slot is in use.", active, "slot", slot.Name, "type", slot.SlotType)
+	}
+	for _, slot := range slots {
+		p.gauge("pguard_replication_slot_retained_bytes", "WAL retained by the replication slot.", float64(slot.RetainedBytes), "slot", slot.Name, "type", slot.SlotType)
+	}
+	for _, slot := range slots {
+		p.gauge("pguard_replication_slot_xmin_age", "Age of the replication slot's xmin or catalog_xmin.", float64(slot.XminAge), "slot", slot.Name, "type", slot.SlotType)
+	}
+
+	for _, u := range roles {
+		p.gauge("pguard_role_connections", "Connections per role.", float64(u.Connections), "role", u.Name)
+	}
+	for _, u := range roles {
+		if u.HasLimit() {
+			p.gauge("pguard_role_connection_limit", "rolconnlimit per role, for roles with a limit.", float64(u.Limit), "role", u.Name)
+		}
+	}
+	for _, u := range databases {
+		p.gauge("pguard_database_connections", "Connections per database.", float64(u.Connections), "database", u.Name)
+	}
+	for _, u := range databases {
+		if u.HasLimit() {
+			p.gauge("pguard_database_connection_limit", "datconnlimit per database, for databases with a limit.", float64(u.Limit), "database", u.Name)
+		}
+	}
+}
+
+// writePromDown writes the metrics file for a failed run, so a stale file
+// from a previous success doesn't keep reporting healthy values
+func writePromDown(w io.Writer) {
+	p := newPromWriter(w)
+	p.gauge("pguard_up", "Whether pguard could query the database.", 0)
+	p.gauge("pguard_last_run_timestamp_seconds", "Unix time this file was written.", float64(time.Now().Unix()))
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/api"
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestWriteConnectionsCSV(t *testing.T) {
+	testCfg := config.DefaultConfig()
+	now := time.Now()
+	xact := now.Add(-3 * time.Minute)
+	conns := []*postgres.Connection{
+		{PID: 1, State: postgres.StateActive, ApplicationName: "web", StateChange: now, BackendStart: now,
+			Query: "SELECT 1"},
+		{PID: 2, State: postgres.StateIdleInTransaction, ApplicationName: "worker, v2", StateChange: now.Add(-3 * time.Minute),
+			BackendStart: now, XactStart: &xact, Query: "UPDATE t\n  SET x = 1"},
+	}
+
+	var buf bytes.Buffer
+	if err := writeConnectionsCSV(&buf, conns, testCfg); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d", len(records))
+	}
+	if records[0][0] != "pid" || records[0][len(records[0])-1] != "query" {
+		t.Errorf("unexpected header: %v", records[0])
+	}
+
+	row := records[2]
+	if row[2] != "worker, v2" {
+		t.Errorf("application = %q, want %q", row[2], "worker, v2")
+	}
+	if row[10] != "critical" {
+		t.Errorf("severity = %q, want critical", row[10])
+	}
+	if row[11] != "" {
+		t.Errorf("team = %q, want empty without an ownership file", row[11])
+	}
+	if row[12] != "UPDATE t SET x = 1" {
+		t.Errorf("query = %q, want whitespace normalized", row[12])
+	}
+	if records[1][8] != "" {
+		t.Errorf("xact_seconds = %q, want empty without a transaction", records[1][8])
+	}
+}
+
+func TestWritePromStatus(t *testing.T) {
+	testCfg := config.DefaultConfig()
+	stats := &postgres.PoolStats{MaxConnections: 103, ReservedSuperuser: 3, TotalConnections: 50, ActiveConnections: 20,
+		IdleConnections: 25, IdleInTransaction: 5, AvailableConnections: 50, OwnConnections: 2, RDSAdminConnections: 1,
+		ExcludedConnections: 2}
+	idle := []*postgres.Connection{
+		{PID: 1, State: postgres.StateIdleInTransaction, StateChange: time.Now().Add(-5 * time.Minute)},
+	}
+	slots := []*postgres.ReplicationSlot{
+		{Name: `my"slot`, SlotType: "logical", RetainedBytes: 1024},
+		{Name: "standby", SlotType: "physical", Active: true},
+	}
+	roles := []*postgres.LimitUsage{{Name: "app", Connections: 10, Limit: 20}, {Name: "other", Connections: 1, Limit: -1}}
+
+	var buf bytes.Buffer
+	writePromStatus(&buf, stats, idle, slots, roles, nil, ExitCritical, testCfg)
+	out := buf.String()
+
+	for _, want := range []string{
+		"pguard_up 1\n",
+		"pguard_status 2\n",
+		"pguard_connections_capacity 100\n",
+		`pguard_connections{state="idle_in_transaction"} 5` + "\n",
+		`pguard_idle_transactions{severity="critical"} 1` + "\n",
+		"pguard_connections_excluded 2\n",
+		`pguard_connections_by_owner{owner="rdsadmin"} 1` + "\n",
+		"pguard_pool_usage_ratio 0.48\n",
+		`pguard_replication_slot_retained_bytes{slot="my\"slot",type="logical"} 1024` + "\n",
+		`pguard_role_connection_limit{role="app"} 20` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, `pguard_role_connection_limit{role="other"}`) {
+		t.Error("roles without a limit should not export a limit")
+	}
+	if n := strings.Count(out, "# TYPE pguard_connections gauge\n"); n != 1 {
+		t.Errorf("expected one TYPE line per metric family, got %d", n)
+	}
+
+	// Samples of a family must not be interleaved with other families
+	done := make(map[string]bool)
+	current := ""
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		name := strings.FieldsFunc(line, func(r rune) bool { return r == '{' || r == ' ' })[0]
+		if name == current {
+			continue
+		}
+		if done[name] {
+			t.Errorf("samples of %s are not contiguous", name)
+		}
+		done[current] = true
+		current = name
+	}
+}
+
+func TestWritePromDown(t *testing.T) {
+	var buf bytes.Buffer
+	writePromDown(&buf)
+	if !strings.Contains(buf.String(), "pguard_up 0\n") {
+		t.Errorf("expected pguard_up 0, got:\n%s", buf.String())
+	}
+}
+
+func TestEncodeStatusYAML(t *testing.T) {
+	output := api.StatusOutput{Status: "warning", Pool: api.PoolStatus{MaxConnections: 100, UsagePercent: 80}}
+
+	var buf bytes.Buffer
+	if err := encodeStatus(&buf, "yaml", output); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"status: warning\n", "  max_connections: 100\n", "  usage_percent: 80\n"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q in YAML:\n%s", want, buf.String())
+		}
+	}
+	if strings.Contains(buf.String(), "replication_slots") {
+		t.Error("empty optional sections should be omitted")
+	}
+}
+
+func TestWriteStatusOutputAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pguard.prom")
+
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeStatusOutput(path, []byte("new\n")); err != nil {
+		t.Fatalf("writeStatusOutput() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new\n" {
+		t.Errorf("file contents = %q, want %q", data, "new\n")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected temp files to be cleaned up, found %d entries", len(entries))
+	}
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// nagiosStates maps status exit codes to plugin state names
+var nagiosStates = map[int]string{
+	ExitOK:       "OK",
+	ExitWarning:  "WARNING",
+	ExitCritical: "CRITICAL",
+	ExitUnknown:  "UNKNOWN",
+}
+
+// formatNagios renders status as a single Nagios/Icinga plugin line:
+// "PGUARD STATE - summary | perfdata"
+func formatNagios(stats *postgres.PoolStats, idleConns []*postgres.Connection, slots []*postgres.ReplicationSlot,
+	roles, databases []*postgres.LimitUsage, exitCode int, cfg *config.Config) string {
+	var problems []string
+
+	usagePercent := stats.UsagePercent()
+	if usagePercent >= float64(cfg.Thresholds.ConnectionPool.WarningPercent) {
+		problems = append(problems, fmt.Sprintf("pool %.0f%% (%d/%d)", usagePercent, stats.UsedConnections(), stats.Capacity()))
+	}
+
+	var oldest time.Duration
+	overWarning := 0
+	for _, conn := range idleConns {
+		d := conn.IdleDuration()
+		if d > oldest {
+			oldest = d
+		}
+		if d >= cfg.Thresholds.IdleTransaction.Warning {
+			overWarning++
+		}
+	}
+	if overWarning > 0 {
+		problems = append(problems, fmt.Sprintf("%d idle tx (oldest %s)", overWarning, util.FormatDuration(oldest)))
+	}
+
+	for _, slot := range slots {
+		if severity, reason := slotSeverity(slot, cfg); severity != "" {
+			problems = append(problems, fmt.Sprintf("slot %s %s", slot.Name, reason))
+		}
+	}
+	for _, u := range roles {
+		if limitSeverity(u, cfg.Thresholds.RoleLimit) != "" {
+			problems = append(problems, fmt.Sprintf("role %s %.0f%% of limit", u.Name, u.UsagePercent()))
+		}
+	}
+	for _, u := range databases {
+		if limitSeverity(u, cfg.Thresholds.DatabaseLimit) != "" {
+			problems = append(problems, fmt.Sprintf("database %s %.0f%% of limit", u.Name, u.UsagePercent()))
+		}
+	}
+
+	summary := strings.Join(problems, ", ")
+	if summary == "" {
+		summary = fmt.Sprintf("%d/%d connections (%.0f%%), %d idle tx",
+			stats.UsedConnections(), stats.Capacity(), usagePercent, len(idleConns))
+	}
+
+	return fmt.Sprintf("PGUARD %s - %s | %s", nagiosStates[exitCode], summary, nagiosPerfdata(stats, oldest, cfg))
+}
+
+// nagiosPerfdata renders every PoolStats field plus the oldest idle
+// transaction. Connection counts that have thresholds carry warn/crit levels
+// converted from percent of capacity.
+func nagiosPerfdata(stats *postgres.PoolStats, oldestIdle time.Duration, cfg *config.Config) string {
+	capacity := stats.Capacity()
+	warnConns := int(math.Ceil(float64(capacity) * float64(cfg.Thresholds.ConnectionPool.WarningPercent) / 100))
+	critConns := int(math.Ceil(float64(capacity) * float64(cfg.Thresholds.ConnectionPool.CriticalPercent) / 100))
+
+	perf := []string{
+		fmt.Sprintf("total=%d;%d;%d;0;%d", stats.TotalConnections, warnConns, critConns, stats.MaxConnections),
+		fmt.Sprintf("used=%d;%d;%d;0;%d", stats.UsedConnections(), warnConns, critConns, capacity),
+		fmt.Sprintf("usage=%.1f%%;%d;%d;0;100", stats.UsagePercent(),
+			cfg.Thresholds.ConnectionPool.WarningPercent, cfg.Thresholds.ConnectionPool.CriticalPercent),
+		fmt.Sprintf("active=%d", stats.ActiveConnections),
+		fmt.Sprintf("idle=%d", stats.IdleConnections),
+		fmt.Sprintf("idle_tx=%d", stats.IdleInTransaction),
+		fmt.Sprintf("available=%d;;;0;%d", stats.AvailableConnections, capacity),
+		fmt.Sprintf("max_connections=%d", stats.MaxConnections),
+		fmt.Sprintf("capacity=%d", capacity),
+		fmt.Sprintf("reserved_superuser=%d", stats.ReservedSuperuser),
+		fmt.Sprintf("reserved_connections=%d", stats.ReservedConnections),
+		fmt.Sprintf("reserved_rds=%d", stats.ReservedRDS),
+		fmt.Sprintf("own=%d", stats.OwnConnections),
+		fmt.Sprintf("rdsadmin=%d", stats.RDSAdminConnections),
+		fmt.Sprintf("excluded=%d", stats.ExcludedConnections),
+		fmt.Sprintf("oldest_idle=%ds;%d;%d;0", int(oldestIdle.Seconds()),
+			int(cfg.Thresholds.IdleTransaction.Warning.Seconds()), int(cfg.Thresholds.IdleTransaction.Critical.Seconds())),
+	}
+	return strings.Join(perf, " ")
+}
+
+// formatNagiosUnknown renders a failure to query the database
+func formatNagiosUnknown(err error) string {
+	// Plugin output is a single line; the first line of the error is enough
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	return fmt.Sprintf("PGUARD UNKNOWN - %s", strings.ReplaceAll(msg, "|", "/"))
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestFormatNagios(t *testing.T) {
+	testCfg := config.DefaultConfig() // pool 75/90, idle 30s/2m
+	testCfg.Thresholds.ReplicationSlot.Enabled = false
+
+	stats := &postgres.PoolStats{
+		MaxConnections:       103,
+		ReservedSuperuser:    3,
+		TotalConnections:     54,
+		ActiveConnections:    20,
+		IdleConnections:      31,
+		IdleInTransaction:    3,
+		AvailableConnections: 46,
+	}
+
+	now := time.Now()
+	idle := []*postgres.Connection{
+		{PID: 1, State: postgres.StateIdleInTransaction, StateChange: now.Add(-45 * time.Second)},
+		{PID: 2, State: postgres.StateIdleInTransaction, StateChange: now.Add(-40 * time.Second)},
+		{PID: 3, State: postgres.StateIdleInTransaction, StateChange: now.Add(-35 * time.Second)},
+	}
+
+	got := formatNagios(stats, idle, nil, nil, nil, ExitWarning, testCfg)
+
+	if !strings.HasPrefix(got, "PGUARD WARNING - 3 idle tx (oldest 45s) | ") {
+		t.Errorf("unexpected status line: %s", got)
+	}
+
+	for _, want := range []string{
+		"total=54;75;90;0;103",
+		"usage=54.0%;75;90;0;100",
+		"idle_tx=3",
+		"oldest_idle=45s;30;120;0",
+		"max_connections=103",
+		"reserved_superuser=3",
+		"available=46;;;0;100",
+		"excluded=0",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("perfdata missing %q in %s", want, got)
+		}
+	}
+
+	if strings.Count(got, "|") != 1 {
+		t.Errorf("expected exactly one perfdata separator: %s", got)
+	}
+}
+
+func TestFormatNagios_OK(t *testing.T) {
+	testCfg := config.DefaultConfig()
+	stats := &postgres.PoolStats{MaxConnections: 100, TotalConnections: 10, AvailableConnections: 90}
+
+	got := formatNagios(stats, nil, nil, nil, nil, ExitOK, testCfg)
+	if !strings.HasPrefix(got, "PGUARD OK - 10/100 connections (10%), 0 idle tx | ") {
+		t.Errorf("unexpected status line: %s", got)
+	}
+}
+
+func TestFormatNagiosUnknown(t *testing.T) {
+	got := formatNagiosUnknown(errors.New("connecting to database: dial tcp: timeout | retry\nmore detail"))
+	want := "PGUARD UNKNOWN - connecting to database: dial tcp: timeout / retry"
+	if got != want {
+		t.Errorf("formatNagiosUnknown() = %q, want %q", got, want)
+	}
+}
+
+func TestStatusFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{"default", nil, "table", false},
+		{"json flag", []string{"--json"}, "json", false},
+		{"format nagios", []string{"--format", "nagios"}, "nagios", false},
+		{"json with matching format", []string{"--json", "--format", "json"}, "json", false},
+		{"json conflicts", []string{"--json", "--format", "nagios"}, "", true},
+		{"unknown format", []string{"--format", "xml"}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().Bool("json", false, "")
+			cmd.Flags().String("format", "table", "")
+			if err := cmd.Flags().Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := statusFormat(cmd)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("statusFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("statusFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/v0xg/pg-idle-guard/internal/api"
 	"github.com/v0xg/pg-idle-guard/internal/config"
 	"github.com/v0xg/pg-idle-guard/internal/postgres"
 )
@@ -305,9 +306,9 @@ func TestExitCodeDetermination(t *testing.T) {
 
 func TestStatusOutput_JSON_Structure(t *testing.T) {
 	// Verify the struct tags are correct for JSON marshaling
-	output := StatusOutput{
+	output := api.StatusOutput{
 		Status: "ok",
-		Pool: PoolStatus{
+		Pool: api.PoolStatus{
 			MaxConnections:       100,
 			TotalConnections:     25,
 			ActiveConnections:    10,
@@ -316,7 +317,7 @@ func TestStatusOutput_JSON_Structure(t *testing.T) {
 			AvailableConnections: 72,
 			UsagePercent:         25.77,
 		},
-		IdleTransactions: []IdleTransactionStatus{
+		IdleTransactions: []api.IdleTransactionStatus{
 			{
 				PID:         12345,
 				Application: "test-app",
@@ -326,7 +327,7 @@ func TestStatusOutput_JSON_Structure(t *testing.T) {
 				Severity:    "warning",
 			},
 		},
-		Thresholds: ThresholdStatus{
+		Thresholds: api.ThresholdStatus{
 			IdleWarning:     "30s",
 			IdleCritical:    "2m0s",
 			PoolWarningPct:  75,
@@ -358,3 +359,125 @@ func TestExitCodes(t *testing.T) {
 		t.Errorf("ExitCritical = %d, want 2", ExitCritical)
 	}
 }
+
+func TestSlotSeverity(t *testing.T) {
+	testCfg := &config.Config{
+		Thresholds: config.ThresholdsConfig{
+			ReplicationSlot: config.ReplicationSlotThresholds{
+				Enabled:          true,
+				WarningRetained:  1 << 30,
+				CriticalRetained: 10 << 30,
+				WarningXminAge:   1000,
+				CriticalXminAge:  5000,
+				AlertInactive:    true,
+			},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		slot         postgres.ReplicationSlot
+		wantSeverity string
+		wantReason   string
+	}{
+		{
+			name:         "healthy active slot",
+			slot:         postgres.ReplicationSlot{Active: true, RetainedBytes: 1 << 20},
+			wantSeverity: "",
+		},
+		{
+			name:         "inactive slot is a warning",
+			slot:         postgres.ReplicationSlot{Active: false, RetainedBytes: 1 << 20},
+			wantSeverity: "warning",
+			wantReason:   "slot is inactive",
+		},
+		{
+			name:         "retained WAL warning",
+			slot:         postgres.ReplicationSlot{Active: true, RetainedBytes: 2 << 30},
+			wantSeverity: "warning",
+			wantReason:   "retaining 2.0 GB of WAL",
+		},
+		{
+			name:         "retained WAL critical beats inactive",
+			slot:         postgres.ReplicationSlot{Active: false, RetainedBytes: 12 << 30},
+			wantSeverity: "critical",
+			wantReason:   "retaining 12.0 GB of WAL",
+		},
+		{
+			name:         "xmin age critical",
+			slot:         postgres.ReplicationSlot{Active: true, XminAge: 6000},
+			wantSeverity: "critical",
+			wantReason:   "xmin age 6000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			severity, reason := slotSeverity(&tt.slot, testCfg)
+			if severity != tt.wantSeverity {
+				t.Errorf("severity = %q, want %q", severity, tt.wantSeverity)
+			}
+			if reason != tt.wantReason {
+				t.Errorf("reason = %q, want %q", reason, tt.wantReason)
+			}
+		})
+	}
+
+	t.Run("inactive ignored when alert_inactive is off", func(t *testing.T) {
+		quietCfg := *testCfg
+		quietCfg.Thresholds.ReplicationSlot.AlertInactive = false
+		severity, _ := slotSeverity(&postgres.ReplicationSlot{Active: false}, &quietCfg)
+		if severity != "" {
+			t.Errorf("severity = %q, want empty", severity)
+		}
+	})
+}
+
+func TestLimitSeverity(t *testing.T) {
+	thresholds := config.ConnectionLimitThresholds{Enabled: true, WarningPercent: 75, CriticalPercent: 90}
+
+	tests := []struct {
+		name  string
+		usage postgres.LimitUsage
+		want  string
+	}{
+		{"unlimited role is never flagged", postgres.LimitUsage{Connections: 500, Limit: -1}, ""},
+		{"below warning", postgres.LimitUsage{Connections: 7, Limit: 10}, ""},
+		{"at warning", postgres.LimitUsage{Connections: 15, Limit: 20}, "warning"},
+		{"at critical", postgres.LimitUsage{Connections: 9, Limit: 10}, "critical"},
+		{"over limit", postgres.LimitUsage{Connections: 12, Limit: 10}, "critical"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := limitSeverity(&tt.usage, thresholds); got != tt.want {
+				t.Errorf("limitSeverity() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBusiestLimitUsage(t *testing.T) {
+	usage := []*postgres.LimitUsage{
+		{Name: "reporting", Connections: 40, Limit: -1},
+		{Name: "myapp", Connections: 18, Limit: 20},
+		{Name: "batch", Connections: 5, Limit: 10},
+		{Name: "admin", Connections: 2, Limit: -1},
+	}
+
+	got := busiestLimitUsage(usage, 3)
+	if len(got) != 3 {
+		t.Fatalf("len = %d, want 3", len(got))
+	}
+	want := []string{"myapp", "batch", "reporting"}
+	for i, name := range want {
+		if got[i].Name != name {
+			t.Errorf("got[%d] = %s, want %s", i, got[i].Name, name)
+		}
+	}
+
+	// Input order must not change
+	if usage[0].Name != "reporting" {
+		t.Error("busiestLimitUsage should not reorder its input")
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
+	"github.com/v0xg/pg-idle-guard/internal/events"
 	"github.com/v0xg/pg-idle-guard/internal/postgres"
 	"github.com/v0xg/pg-idle-guard/internal/util"
 )
@@ -18,26 +20,54 @@ import (
 var watchCmd = &cobra.Command{
 	Use:   "watch",
 	Short: "Monitor connections in real-time",
-	Long:  `Watch PostgreSQL connections in real-time and report changes as they happen.`,
-	RunE:  runWatch,
+	Long: `Watch PostgreSQL connections in real-time.
+
+On a terminal this opens a full-screen table of all connections with a pool
+usage bar, sorting, filtering and a detail pane for the selected session,
+which can be canceled or terminated from the keyboard. When output is not a
+terminal, or with --plain, changes are reported as log lines instead.
+
+With --format jsonl, every new idle transaction, threshold crossing,
+resolution and pool pressure change is written to stdout as one JSON object
+per line, for jq, Vector or other tooling.`,
+	RunE: runWatch,
 }
 
 func init() {
 	watchCmd.Flags().DurationP("interval", "i", 5*time.Second, "Polling interval")
+	watchCmd.Flags().Bool("plain", false, "Print log lines instead of the interactive view")
+	watchCmd.Flags().String("format", "text", "Output format: text or jsonl")
 }
 
 // trackedConnection keeps state about connections we're watching
 type trackedConnection struct {
 	pid          int
+	backendStart time.Time
 	appName      string
+	username     string
+	database     string
+	clientAddr   string
 	query        string
 	firstSeen    time.Time
 	warningSent  bool
 	criticalSent bool
 }
 
+// watchState is carried between polls
+type watchState struct {
+	tracked      map[int]*trackedConnection
+	poolSeverity string // Last reported pool pressure: "", "warning" or "critical"
+	logPressure  bool   // Text output: report pool pressure on every poll while elevated
+}
+
 func runWatch(cmd *cobra.Command, args []string) error {
 	interval, _ := cmd.Flags().GetDuration("interval")
+	plain, _ := cmd.Flags().GetBool("plain")
+	format, _ := cmd.Flags().GetString("format")
+
+	if format != "text" && format != "jsonl" {
+		return fmt.Errorf("unknown format %q (valid: text, jsonl)", format)
+	}
 
 	// Create PostgreSQL client
 	client, err := postgres.NewClient(cfg)
@@ -46,6 +76,20 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	}
 	defer client.Close()
 
+	if format == "text" && !plain && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) {
+		return runWatchTUI(client, interval)
+	}
+
+	emit := printTextEvent
+	if format == "jsonl" {
+		writer := events.NewJSONLWriter(os.Stdout)
+		emit = func(e events.Event) {
+			if err := writer.Write(e); err != nil {
+				fmt.Fprintf(os.Stderr, "writing event: %v\n", err)
+			}
+		}
+	}
+
 	// Handle graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -54,42 +98,53 @@ func runWatch(cmd *cobra.Command, args []string) error {
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\nStopping...")
+		if format == "text" {
+			fmt.Println("\nStopping...")
+		}
 		cancel()
 	}()
 
-	fmt.Println("Watching PostgreSQL connections... (Ctrl+C to stop)")
-	fmt.Printf("Refresh: %s | Thresholds: warn=%s, crit=%s\n",
-		interval,
-		cfg.Thresholds.IdleTransaction.Warning,
-		cfg.Thresholds.IdleTransaction.Critical,
-	)
-	fmt.Println()
+	if format == "text" {
+		fmt.Println("Watching PostgreSQL connections... (Ctrl+C to stop)")
+		fmt.Printf("Refresh: %s | Thresholds: warn=%s, crit=%s\n",
+			interval,
+			cfg.Thresholds.IdleTransaction.Warning,
+			cfg.Thresholds.IdleTransaction.Critical,
+		)
+		fmt.Println()
+	}
 
 	// Track connections we've seen
-	tracked := make(map[int]*trackedConnection)
+	state := &watchState{tracked: make(map[int]*trackedConnection), logPressure: format == "text"}
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	// Run immediately, then on tick
-	if err := pollOnce(ctx, client, tracked); err != nil {
-		logEvent("ERROR", err.Error())
+	poll := func() {
+		if err := pollOnce(ctx, client, state, emit); err != nil && ctx.Err() == nil {
+			emit(events.Event{
+				Time:     time.Now().UTC(),
+				Type:     events.TypePollFailed,
+				Severity: "critical",
+				Message:  err.Error(),
+			})
+		}
 	}
 
+	// Run immediately, then on tick
+	poll()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
 		case <-ticker.C:
-			if err := pollOnce(ctx, client, tracked); err != nil {
-				logEvent("ERROR", err.Error())
-			}
+			poll()
 		}
 	}
 }
 
-func pollOnce(ctx context.Context, client *postgres.Client, tracked map[int]*trackedConnection) error {
+func pollOnce(ctx context.Context, client *postgres.Client, state *watchState, emit func(events.Event)) error {
 	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
@@ -105,42 +160,70 @@ func pollOnce(ctx context.Context, client *postgres.Client, tracked map[int]*tra
 		return err
 	}
 
+	trackIdleTransactions(state, conns, emit)
+	if state.logPressure {
+		logPoolPressure(stats, emit)
+	} else {
+		trackPoolPressure(state, stats, emit)
+	}
+	return nil
+}
+
+// trackIdleTransactions emits events for new idle transactions, threshold
+// crossings and resolutions. A PID whose backend start changed is a new
+// session that reused the PID.
+func trackIdleTransactions(state *watchState, conns []*postgres.Connection, emit func(events.Event)) {
+	tracked := state.tracked
+
 	// Track which PIDs we see this round
 	seenPIDs := make(map[int]bool)
 
 	for _, conn := range conns {
-		seenPIDs[conn.PID] = true
 		duration := conn.IdleDuration()
 
 		tc, exists := tracked[conn.PID]
+		if exists && !tc.backendStart.Equal(conn.BackendStart) {
+			emitResolved(tc, emit)
+			exists = false
+		}
+		seenPIDs[conn.PID] = true
+
 		if !exists {
 			// New idle transaction
 			tc = &trackedConnection{
-				pid:       conn.PID,
-				appName:   conn.ApplicationName,
-				query:     util.TruncateQuery(conn.Query, 60),
-				firstSeen: time.Now(),
+				pid:          conn.PID,
+				backendStart: conn.BackendStart,
+				appName:      conn.ApplicationName,
+				username:     conn.Username,
+				database:     conn.Database,
+				clientAddr:   conn.ClientAddr,
+				query:        util.TruncateQuery(conn.Query, 60),
+				firstSeen:    time.Now(),
 			}
 			tracked[conn.PID] = tc
 
+			severity := ""
 			if duration >= cfg.Thresholds.IdleTransaction.Warning {
-				logEvent("WARN", fmt.Sprintf("New idle transaction: PID %d (%s) idle for %s",
-					conn.PID, conn.ApplicationName, util.FormatDuration(duration)))
-				logEvent("    ", fmt.Sprintf("Query: %s", tc.query))
+				severity = "warning"
 				tc.warningSent = true
 			}
+			emit(events.ForConnection(events.TypeIdleStarted, severity, conn, duration,
+				fmt.Sprintf("New idle transaction: PID %d (%s) idle for %s",
+					conn.PID, conn.ApplicationName, util.FormatDuration(duration))))
 		}
 
 		// Check for threshold crossings
 		if !tc.warningSent && duration >= cfg.Thresholds.IdleTransaction.Warning {
-			logEvent("WARN", fmt.Sprintf("PID %d (%s) idle for %s",
-				conn.PID, conn.ApplicationName, util.FormatDuration(duration)))
+			emit(events.ForConnection(events.TypeIdleThreshold, "warning", conn, duration,
+				fmt.Sprintf("PID %d (%s) idle for %s",
+					conn.PID, conn.ApplicationName, util.FormatDuration(duration))))
 			tc.warningSent = true
 		}
 
 		if !tc.criticalSent && duration >= cfg.Thresholds.IdleTransaction.Critical {
-			logEvent("CRIT", fmt.Sprintf("PID %d (%s) idle for %s",
-				conn.PID, conn.ApplicationName, util.FormatDuration(duration)))
+			emit(events.ForConnection(events.TypeIdleThreshold, "critical", conn, duration,
+				fmt.Sprintf("PID %d (%s) idle for %s",
+					conn.PID, conn.ApplicationName, util.FormatDuration(duration))))
 			tc.criticalSent = true
 		}
 	}
@@ -148,24 +231,95 @@ func pollOnce(ctx context.Context, client *postgres.Client, tracked map[int]*tra
 	// Check for resolved transactions
 	for pid, tc := range tracked {
 		if !seenPIDs[pid] {
-			totalDuration := time.Since(tc.firstSeen)
-			logEvent("OK", fmt.Sprintf("Resolved: PID %d (%s) - was idle for %s",
-				pid, tc.appName, util.FormatDuration(totalDuration)))
+			emitResolved(tc, emit)
 			delete(tracked, pid)
 		}
 	}
+}
+
+func emitResolved(tc *trackedConnection, emit func(events.Event)) {
+	totalDuration := time.Since(tc.firstSeen)
+	emit(events.Event{
+		Time:        time.Now().UTC(),
+		Type:        events.TypeIdleResolved,
+		Session:     events.NewSession(tc.pid, tc.backendStart),
+		Application: tc.appName,
+		User:        tc.username,
+		Database:    tc.database,
+		ClientAddr:  tc.clientAddr,
+		DurationSec: totalDuration.Seconds(),
+		Message: fmt.Sprintf("Resolved: PID %d (%s) - was idle for %s",
+			tc.pid, tc.appName, util.FormatDuration(totalDuration)),
+	})
+}
+
+// trackPoolPressure emits an event when pool pressure moves between ok,
+// warning and critical
+func trackPoolPressure(state *watchState, stats *postgres.PoolStats, emit func(events.Event)) {
+	severity := watchPoolSeverity(stats)
+	if severity == state.poolSeverity {
+		return
+	}
+	emit(events.ForPool(severity, state.poolSeverity, stats, poolPressureMessage(severity, stats)))
+	state.poolSeverity = severity
+}
 
-	// Check connection pool pressure
+// logPoolPressure emits a pool pressure event on every poll while usage is
+// over a threshold, for the text log
+func logPoolPressure(stats *postgres.PoolStats, emit func(events.Event)) {
+	if severity := watchPoolSeverity(stats); severity != "" {
+		emit(events.ForPool(severity, "", stats, poolPressureMessage(severity, stats)))
+	}
+}
+
+func watchPoolSeverity(stats *postgres.PoolStats) string {
 	usagePercent := stats.UsagePercent()
 	if usagePercent >= float64(cfg.Thresholds.ConnectionPool.CriticalPercent) {
-		logEvent("CRIT", fmt.Sprintf("Connection pressure: %d/%d (%.0f%%) - approaching limit!",
-			stats.TotalConnections, stats.MaxConnections-stats.ReservedSuperuser, usagePercent))
-	} else if usagePercent >= float64(cfg.Thresholds.ConnectionPool.WarningPercent) {
-		logEvent("WARN", fmt.Sprintf("Connection pressure: %d/%d (%.0f%%)",
-			stats.TotalConnections, stats.MaxConnections-stats.ReservedSuperuser, usagePercent))
+		return "critical"
+	}
+	if usagePercent >= float64(cfg.Thresholds.ConnectionPool.WarningPercent) {
+		return "warning"
 	}
+	return ""
+}
 
-	return nil
+func poolPressureMessage(severity string, stats *postgres.PoolStats) string {
+	usagePercent := stats.UsagePercent()
+	switch severity {
+	case "critical":
+		return fmt.Sprintf("Connection pressure: %d/%d (%.0f%%) - approaching limit!",
+			stats.UsedConnections(), stats.Capacity(), usagePercent)
+	case "warning":
+		return fmt.Sprintf("Connection pressure: %d/%d (%.0f%%)",
+			stats.UsedConnections(), stats.Capacity(), usagePercent)
+	}
+	return fmt.Sprintf("Connection pressure resolved: %d/%d (%.0f%%)",
+		stats.UsedConnections(), stats.Capacity(), usagePercent)
+}
+
+// printTextEvent renders an event as a log line
+func printTextEvent(e events.Event) {
+	level := "INFO"
+	switch {
+	case e.Type == events.TypePollFailed:
+		level = "ERROR"
+	case e.Type == events.TypeIdleResolved:
+		level = "OK"
+	case e.Type == events.TypePoolPressure && e.Severity == "":
+		level = "OK"
+	case e.Type == events.TypeIdleStarted && e.Severity == "":
+		// Young idle transactions are only interesting once they cross a threshold
+		return
+	case e.Severity == "critical":
+		level = "CRIT"
+	case e.Severity == "warning":
+		level = "WARN"
+	}
+
+	logEvent(level, e.Message)
+	if e.Type == events.TypeIdleStarted {
+		logEvent("    ", fmt.Sprintf("Query: %s", util.TruncateQuery(e.Query, 60)))
+	}
 }
 
 func logEvent(level, message string) {
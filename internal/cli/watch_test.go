@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/config"
+	"github.com/v0xg/pg-idle-guard/internal/events"
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestTrackIdleTransactions(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+	cfg = config.DefaultConfig()
+	cfg.Thresholds.IdleTransaction.Warning = 30 * time.Second
+	cfg.Thresholds.IdleTransaction.Critical = 5 * time.Minute
+
+	start := time.Now().Add(-time.Hour)
+	conn := func(pid int, backendStart time.Time, idle time.Duration) *postgres.Connection {
+		return &postgres.Connection{
+			PID:          pid,
+			BackendStart: backendStart,
+			StateChange:  time.Now().Add(-idle),
+			State:        postgres.StateIdleInTransaction,
+		}
+	}
+
+	state := &watchState{tracked: make(map[int]*trackedConnection)}
+	var got []events.Event
+	emit := func(e events.Event) { got = append(got, e) }
+
+	type want struct {
+		eventType string
+		severity  string
+	}
+	polls := []struct {
+		name  string
+		conns []*postgres.Connection
+		want  []want
+	}{
+		{
+			name:  "new young transaction",
+			conns: []*postgres.Connection{conn(1, start, time.Second)},
+			want:  []want{{events.TypeIdleStarted, ""}},
+		},
+		{
+			name:  "still young",
+			conns: []*postgres.Connection{conn(1, start, 2*time.Second)},
+		},
+		{
+			name:  "crosses warning",
+			conns: []*postgres.Connection{conn(1, start, time.Minute)},
+			want:  []want{{events.TypeIdleThreshold, "warning"}},
+		},
+		{
+			name:  "crosses critical",
+			conns: []*postgres.Connection{conn(1, start, 10*time.Minute)},
+			want:  []want{{events.TypeIdleThreshold, "critical"}},
+		},
+		{
+			name:  "pid reused by a new backend",
+			conns: []*postgres.Connection{conn(1, start.Add(time.Minute), time.Minute)},
+			want:  []want{{events.TypeIdleResolved, ""}, {events.TypeIdleStarted, "warning"}},
+		},
+		{
+			name: "resolved",
+			want: []want{{events.TypeIdleResolved, ""}},
+		},
+	}
+
+	for _, poll := range polls {
+		got = nil
+		trackIdleTransactions(state, poll.conns, emit)
+		if len(got) != len(poll.want) {
+			t.Fatalf("%s: got %d events %+v, want %d", poll.name, len(got), got, len(poll.want))
+		}
+		for i, w := range poll.want {
+			if got[i].Type != w.eventType || got[i].Severity != w.severity {
+				t.Errorf("%s: event %d = %s/%q, want %s/%q", poll.name, i, got[i].Type, got[i].Severity, w.eventType, w.severity)
+			}
+			if got[i].Session == nil || got[i].Session.PID != 1 {
+				t.Errorf("%s: event %d missing session", poll.name, i)
+			}
+		}
+	}
+}
+
+func TestTrackPoolPressure(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+	cfg = config.DefaultConfig()
+	cfg.Thresholds.ConnectionPool.WarningPercent = 75
+	cfg.Thresholds.ConnectionPool.CriticalPercent = 90
+
+	state := &watchState{tracked: make(map[int]*trackedConnection)}
+	var got []events.Event
+	emit := func(e events.Event) { got = append(got, e) }
+
+	polls := []struct {
+		used         int
+		wantEvent    bool
+		wantSeverity string
+		wantPrevious string
+	}{
+		{used: 50},
+		{used: 80, wantEvent: true, wantSeverity: "warning"},
+		{used: 85},
+		{used: 95, wantEvent: true, wantSeverity: "critical", wantPrevious: "warning"},
+		{used: 40, wantEvent: true, wantSeverity: "", wantPrevious: "critical"},
+		{used: 40},
+	}
+
+	for i, poll := range polls {
+		got = nil
+		trackPoolPressure(state, &postgres.PoolStats{MaxConnections: 100, TotalConnections: poll.used}, emit)
+		if !poll.wantEvent {
+			if len(got) != 0 {
+				t.Errorf("poll %d: unexpected events %+v", i, got)
+			}
+			continue
+		}
+		if len(got) != 1 {
+			t.Fatalf("poll %d: got %d events, want 1", i, len(got))
+		}
+		if got[0].Severity != poll.wantSeverity || got[0].PreviousSeverity != poll.wantPrevious {
+			t.Errorf("poll %d: severity %q (was %q), want %q (was %q)",
+				i, got[0].Severity, got[0].PreviousSeverity, poll.wantSeverity, poll.wantPrevious)
+		}
+	}
+}
+
+func TestLogPoolPressure(t *testing.T) {
+	originalCfg := cfg
+	defer func() { cfg = originalCfg }()
+	cfg = config.DefaultConfig()
+	cfg.Thresholds.ConnectionPool.WarningPercent = 75
+	cfg.Thresholds.ConnectionPool.CriticalPercent = 90
+
+	var got []events.Event
+	emit := func(e events.Event) { got = append(got, e) }
+
+	// The text log repeats the pressure line every poll and has no resolved line
+	for _, used := range []int{80, 80, 95, 40} {
+		logPoolPressure(&postgres.PoolStats{MaxConnections: 100, TotalConnections: used}, emit)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("got %d events, want 3", len(got))
+	}
+	if got[1].Severity != "warning" || got[2].Severity != "critical" {
+		t.Errorf("severities = %q, %q", got[1].Severity, got[2].Severity)
+	}
+}
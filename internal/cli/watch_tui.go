@@ -0,0 +1,813 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// watchSort is the column the watch table is ordered by
+type watchSort int
+
+const (
+	sortByAge watchSort = iota
+	sortByState
+	sortByApp
+	sortByBlocked
+)
+
+var watchSortNames = []string{"age", "state", "app", "blocked"}
+
+func (s watchSort) String() string {
+	return watchSortNames[s]
+}
+
+// tuiMode is what the keyboard is currently driving
+type tuiMode int
+
+const (
+	modeNormal tuiMode = iota
+	modeFilter
+	modeConfirm
+)
+
+// detailPaneLines is the fixed height of the detail pane below the table
+const detailPaneLines = 10
+
+// watchRow is one connection in the watch table
+type watchRow struct {
+	conn      *postgres.Connection
+	blocks    int   // Number of sessions waiting on this backend
+	blockedBy []int // PIDs this backend is waiting on
+}
+
+// watchTUI is the full-screen interactive view used by watch on a terminal
+type watchTUI struct {
+	client   *postgres.Client
+	out      io.Writer
+	interval time.Duration
+
+	stats    *postgres.PoolStats
+	rows     []*watchRow
+	visible  []*watchRow
+	locks    []*postgres.Lock
+	locksPID int
+	lastPoll time.Time
+	pollErr  error
+
+	sortKey     watchSort
+	filter      string
+	idleTxOnly  bool
+	selectedPID int
+	offset      int
+
+	mode       tuiMode
+	input      string
+	pending    *postgres.Connection
+	cancelOnly bool
+	message    string
+}
+
+func runWatchTUI(client *postgres.Client, interval time.Duration) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM)
+	defer stop()
+
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("entering raw mode: %w", err)
+	}
+	defer func() { _ = term.Restore(fd, oldState) }()
+
+	t := &watchTUI{
+		client:   client,
+		out:      os.Stdout,
+		interval: interval,
+	}
+
+	// Alternate screen, hidden cursor
+	fmt.Fprint(t.out, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(t.out, "\x1b[?25h\x1b[?1049l")
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	t.refresh(ctx)
+	t.render()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			t.refresh(ctx)
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if t.handleKey(ctx, key) {
+				return nil
+			}
+		}
+		t.render()
+	}
+}
+
+// readKeys decodes raw terminal input into key names and sends them on ch
+func readKeys(r io.Reader, ch chan<- string) {
+	defer close(ch)
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			ch <- key
+		}
+	}
+}
+
+// parseKeys splits a chunk of raw terminal input into key names. Printable
+// characters are returned as themselves; control keys get descriptive names.
+func parseKeys(b []byte) []string {
+	var keys []string
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == 0x1b:
+			if i+2 < len(b) && b[i+1] == '[' {
+				seq := b[i+2]
+				switch {
+				case seq == 'A':
+					keys = append(keys, "up")
+				case seq == 'B':
+					keys = append(keys, "down")
+				case (seq == '5' || seq == '6') && i+3 < len(b) && b[i+3] == '~':
+					if seq == '5' {
+						keys = append(keys, "pgup")
+					} else {
+						keys = append(keys, "pgdown")
+					}
+					i++
+				}
+				i += 2
+				continue
+			}
+			keys = append(keys, "esc")
+		case c == '\r' || c == '\n':
+			keys = append(keys, "enter")
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, "backspace")
+		case c == 0x03:
+			keys = append(keys, "ctrl-c")
+		case c >= 0x20 && c < 0x7f:
+			keys = append(keys, string(c))
+		}
+	}
+	return keys
+}
+
+// refresh reloads pool stats, connections and blockers from the database
+func (t *watchTUI) refresh(ctx context.Context) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	t.pollErr = nil
+	stats, err := t.client.GetPoolStats(queryCtx)
+	if err != nil {
+		t.pollErr = err
+		return
+	}
+	conns, err := t.client.GetConnections(queryCtx)
+	if err != nil {
+		t.pollErr = err
+		return
+	}
+	blockedBy, err := t.client.GetBlockingPIDs(queryCtx)
+	if err != nil {
+		t.pollErr = err
+		return
+	}
+
+	blocks := postgres.BlockedCounts(blockedBy)
+	rows := make([]*watchRow, 0, len(conns))
+	for _, conn := range conns {
+		rows = append(rows, &watchRow{
+			conn:      conn,
+			blocks:    blocks[conn.PID],
+			blockedBy: blockedBy[conn.PID],
+		})
+	}
+
+	t.stats = stats
+	t.rows = rows
+	t.lastPoll = time.Now()
+	t.applyView()
+	t.locksPID = 0
+	t.loadLocks(queryCtx)
+}
+
+// applyView recomputes the visible rows after data, filter or sort changes
+func (t *watchTUI) applyView() {
+	t.visible = filterWatchRows(t.rows, t.filter, t.idleTxOnly)
+	sortWatchRows(t.visible, t.sortKey)
+
+	if len(t.visible) == 0 {
+		t.selectedPID = 0
+		return
+	}
+	if t.selectedIndex() < 0 {
+		t.selectedPID = t.visible[0].conn.PID
+	}
+}
+
+// loadLocks fetches locks for the selected PID if they aren't loaded yet
+func (t *watchTUI) loadLocks(ctx context.Context) {
+	if t.selectedPID == 0 || t.selectedPID == t.locksPID {
+		return
+	}
+	locks, err := t.client.GetLocks(ctx, t.selectedPID)
+	if err != nil {
+		t.message = err.Error()
+		return
+	}
+	t.locks = locks
+	t.locksPID = t.selectedPID
+}
+
+func (t *watchTUI) selectedIndex() int {
+	for i, row := range t.visible {
+		if row.conn.PID == t.selectedPID {
+			return i
+		}
+	}
+	return -1
+}
+
+func (t *watchTUI) moveSelection(delta int) {
+	if len(t.visible) == 0 {
+		return
+	}
+	i := t.selectedIndex() + delta
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(t.visible) {
+		i = len(t.visible) - 1
+	}
+	t.selectedPID = t.visible[i].conn.PID
+}
+
+// handleKey applies a key press and reports whether the TUI should exit
+func (t *watchTUI) handleKey(ctx context.Context, key string) bool {
+	if key == "ctrl-c" {
+		return true
+	}
+
+	switch t.mode {
+	case modeFilter:
+		switch key {
+		case "enter":
+			t.filter = strings.TrimSpace(t.input)
+			t.mode = modeNormal
+			t.applyView()
+		case "esc":
+			t.mode = modeNormal
+		case "backspace":
+			if t.input != "" {
+				t.input = t.input[:len(t.input)-1]
+			}
+		default:
+			if len(key) == 1 {
+				t.input += key
+			}
+		}
+		return false
+
+	case modeConfirm:
+		if key == "y" || key == "Y" {
+			t.execute(ctx)
+		} else {
+			t.message = "Canceled."
+		}
+		t.mode = modeNormal
+		t.pending = nil
+		return false
+	}
+
+	t.message = ""
+	switch key {
+	case "q":
+		return true
+	case "up", "k":
+		t.moveSelection(-1)
+	case "down", "j":
+		t.moveSelection(1)
+	case "pgup":
+		t.moveSelection(-t.tableHeight())
+	case "pgdown":
+		t.moveSelection(t.tableHeight())
+	case "s":
+		t.sortKey = (t.sortKey + 1) % watchSort(len(watchSortNames))
+		t.applyView()
+	case "1", "2", "3", "4":
+		t.sortKey = watchSort(key[0] - '1')
+		t.applyView()
+	case "/":
+		t.mode = modeFilter
+		t.input = t.filter
+	case "i":
+		t.idleTxOnly = !t.idleTxOnly
+		t.applyView()
+	case "r":
+		t.refresh(ctx)
+	case "c", "t":
+		idx := t.selectedIndex()
+		if idx < 0 {
+			t.message = "No session selected."
+			return false
+		}
+		t.pending = t.visible[idx].conn
+		t.cancelOnly = key == "c"
+		t.mode = modeConfirm
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	t.loadLocks(queryCtx)
+	return false
+}
+
+// execute runs the confirmed cancel/terminate. Like kill, it re-reads the
+// connection list first and refuses to act if the PID now belongs to a
+// different session.
+func (t *watchTUI) execute(ctx context.Context) {
+	target := t.pending
+	queryCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	conns, err := t.client.GetConnections(queryCtx)
+	if err != nil {
+		t.message = fmt.Sprintf("getting connections: %v", err)
+		return
+	}
+	current := findConnection(conns, target.PID)
+	if current == nil {
+		t.message = fmt.Sprintf("No connection found with PID %d", target.PID)
+		return
+	}
+	if !current.BackendStart.Equal(target.BackendStart) {
+		t.message = fmt.Sprintf("PID %d now belongs to a different session; nothing sent", target.PID)
+		return
+	}
+
+	success, err := signalBackend(queryCtx, t.client, current, t.cancelOnly, "cli", "")
+	switch {
+	case err != nil:
+		t.message = fmt.Sprintf("Failed: %v", err)
+	case !success:
+		t.message = fmt.Sprintf("Backend %d may have already terminated", target.PID)
+	case t.cancelOnly:
+		t.message = fmt.Sprintf("Query canceled on PID %d", target.PID)
+	default:
+		t.message = fmt.Sprintf("Backend %d terminated", target.PID)
+	}
+	t.refresh(ctx)
+}
+
+func (t *watchTUI) size() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// tableHeight is the number of connection rows that fit on screen
+func (t *watchTUI) tableHeight() int {
+	_, height := t.size()
+	// Title, pool bar, status line, table header, detail pane, footer
+	h := height - 4 - detailPaneLines - 1
+	if h < 1 {
+		return 1
+	}
+	return h
+}
+
+// render draws the whole screen in one write
+func (t *watchTUI) render() {
+	width, _ := t.size()
+	var lines []string
+	add := func(s string) { lines = append(lines, s) }
+
+	// Title
+	title := fmt.Sprintf("pguard watch | %s | refresh %s | sort: %s", time.Now().Format("15:04:05"), t.interval, t.sortKey)
+	if t.filter != "" {
+		title += fmt.Sprintf(" | filter: %s", t.filter)
+	}
+	if t.idleTxOnly {
+		title += " | idle tx only"
+	}
+	add(ansiBold + fit(title, width) + ansiReset)
+
+	// Pool usage
+	if t.stats != nil {
+		pct := t.stats.UsagePercent()
+		label := fmt.Sprintf(" %3.0f%% %d/%d  active %d  idle %d  idle tx %d",
+			pct, t.stats.UsedConnections(), t.stats.Capacity(),
+			t.stats.ActiveConnections, t.stats.IdleConnections, t.stats.IdleInTransaction)
+		barWidth := width - len(label) - 7
+		if barWidth > 40 {
+			barWidth = 40
+		}
+		add(fmt.Sprintf("Pool %s%s%s%s", poolColor(pct), usageBar(pct, barWidth), ansiReset, fit(label, width-barWidth-7)))
+	} else {
+		add("Pool (no data)")
+	}
+
+	// Status line
+	switch {
+	case t.pollErr != nil:
+		add(ansiRed + fit("Poll failed: "+t.pollErr.Error(), width) + ansiReset)
+	case !t.lastPoll.IsZero():
+		add(fit(fmt.Sprintf("%d connections, %d shown, last poll %s",
+			len(t.rows), len(t.visible), t.lastPoll.Format("15:04:05")), width))
+	default:
+		add("")
+	}
+
+	// Table
+	add(ansiBold + fit(fmt.Sprintf("%-7s %-14s %8s %8s %6s %-16s %-12s %s",
+		"PID", "STATE", "AGE", "XACT", "BLOCKS", "APP", "USER", "QUERY"), width) + ansiReset)
+
+	tableHeight := t.tableHeight()
+	selected := t.selectedIndex()
+	if selected < t.offset {
+		t.offset = selected
+	}
+	if selected >= t.offset+tableHeight {
+		t.offset = selected - tableHeight + 1
+	}
+	if t.offset < 0 {
+		t.offset = 0
+	}
+
+	for i := 0; i < tableHeight; i++ {
+		idx := t.offset + i
+		if idx >= len(t.visible) {
+			add("")
+			continue
+		}
+		row := t.visible[idx]
+		line := fit(formatWatchRow(row), width)
+		switch {
+		case idx == selected:
+			line = ansiReverse + padRight(line, width) + ansiReset
+		case row.conn.IsIdleInTransaction():
+			line = idleColor(row.conn.IdleDuration()) + line + ansiReset
+		}
+		add(line)
+	}
+
+	// Detail pane
+	for _, l := range t.detailLines(width) {
+		add(fit(l, width))
+	}
+
+	// Footer
+	switch t.mode {
+	case modeFilter:
+		add(fit("Filter (app:, user:, db:, state:, client: or free text): "+t.input, width))
+	case modeConfirm:
+		action := "Terminate"
+		verb := "terminate"
+		if t.cancelOnly {
+			action = "Cancel query on"
+			verb = "cancel the current query on"
+		}
+		prompt := fmt.Sprintf("%s PID %d (%s)?", action, t.pending.PID, printable(t.pending.ApplicationName))
+		if warning := killWarning(t.pending, verb); warning != "" {
+			prompt += " " + warning
+		}
+		add(ansiYellow + fit(prompt+" [y/N]", width) + ansiReset)
+	case modeNormal:
+		if t.message != "" {
+			add(fit(t.message, width))
+		} else {
+			add(fit("up/down select  s/1-4 sort  / filter  i idle tx only  c cancel  t terminate  r refresh  q quit", width))
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("\x1b[H")
+	for i, l := range lines {
+		if i > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString(l)
+		b.WriteString("\x1b[K")
+	}
+	b.WriteString("\x1b[J")
+	fmt.Fprint(t.out, b.String())
+}
+
+// detailLines builds the detail pane for the selected connection
+func (t *watchTUI) detailLines(width int) []string {
+	lines := make([]string, 0, detailPaneLines)
+	idx := t.selectedIndex()
+	if idx < 0 {
+		lines = append(lines, strings.Repeat("-", width))
+	} else {
+		row := t.visible[idx]
+		conn := row.conn
+		lines = append(lines, fit(fmt.Sprintf("-- PID %d %s", conn.PID, strings.Repeat("-", width)), width))
+		lines = append(lines, fmt.Sprintf("User: %s  Database: %s  Client: %s  App: %s",
+			printable(conn.Username), printable(conn.Database), conn.ClientAddr, printable(conn.ApplicationName)))
+
+		state := fmt.Sprintf("State: %s for %s", conn.State, util.FormatDuration(conn.IdleDuration()))
+		if conn.XactStart != nil {
+			state += fmt.Sprintf("  Transaction: %s", util.FormatDuration(conn.TransactionDuration()))
+		}
+		if conn.WaitEventType != nil && conn.WaitEvent != nil {
+			state += fmt.Sprintf("  Waiting: %s/%s", *conn.WaitEventType, *conn.WaitEvent)
+		}
+		lines = append(lines, state)
+
+		blocking := fmt.Sprintf("Blocks %d session(s)", row.blocks)
+		if len(row.blockedBy) > 0 {
+			blocking += fmt.Sprintf("  Blocked by: %s", joinPIDs(row.blockedBy))
+		}
+		lines = append(lines, blocking)
+
+		query := printable(conn.Query)
+		lines = append(lines, "Query:")
+		for _, l := range wrapText(query, width-2, 3) {
+			lines = append(lines, "  "+l)
+		}
+
+		if t.locksPID == conn.PID {
+			lines = append(lines, fmt.Sprintf("Locks (%d):", len(t.locks)))
+			for _, l := range t.locks {
+				if len(lines) >= detailPaneLines {
+					break
+				}
+				lines = append(lines, "  "+formatLock(l))
+			}
+		}
+	}
+
+	for len(lines) < detailPaneLines {
+		lines = append(lines, "")
+	}
+	return lines[:detailPaneLines]
+}
+
+// filterWatchRows returns the rows matching filter. The filter is a list of
+// space-separated terms that must all match; a term is either field:value
+// (app, user, db, state, client) or free text matched against app, user,
+// database, state and query. Matching is case-insensitive.
+func filterWatchRows(rows []*watchRow, filter string, idleTxOnly bool) []*watchRow {
+	terms := strings.Fields(strings.ToLower(filter))
+	result := make([]*watchRow, 0, len(rows))
+	for _, row := range rows {
+		if idleTxOnly && !row.conn.IsIdleInTransaction() {
+			continue
+		}
+		if matchesWatchFilter(row.conn, terms) {
+			result = append(result, row)
+		}
+	}
+	return result
+}
+
+func matchesWatchFilter(conn *postgres.Connection, terms []string) bool {
+	for _, term := range terms {
+		field, value, hasField := strings.Cut(term, ":")
+		var candidates []string
+		if hasField {
+			switch field {
+			case "app":
+				candidates = []string{conn.ApplicationName}
+			case "user":
+				candidates = []string{conn.Username}
+			case "db":
+				candidates = []string{conn.Database}
+			case "state":
+				candidates = []string{string(conn.State)}
+			case "client":
+				candidates = []string{conn.ClientAddr}
+			default:
+				// Not a known field; treat the whole term as text
+				hasField = false
+			}
+		}
+		if !hasField {
+			value = term
+			candidates = []string{conn.ApplicationName, conn.Username, conn.Database, string(conn.State), conn.Query}
+		}
+
+		matched := false
+		for _, c := range candidates {
+			if strings.Contains(strings.ToLower(c), value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// sortWatchRows orders rows in place. Age and blocked sort descending so the
+// worst offenders are on top; state and app sort alphabetically with age as
+// the tie-breaker.
+func sortWatchRows(rows []*watchRow, key watchSort) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i].conn, rows[j].conn
+		switch key {
+		case sortByState:
+			if a.State != b.State {
+				return a.State < b.State
+			}
+		case sortByApp:
+			if a.ApplicationName != b.ApplicationName {
+				return a.ApplicationName < b.ApplicationName
+			}
+		case sortByBlocked:
+			if rows[i].blocks != rows[j].blocks {
+				return rows[i].blocks > rows[j].blocks
+			}
+		}
+		return a.StateChange.Before(b.StateChange)
+	})
+}
+
+func formatWatchRow(row *watchRow) string {
+	conn := row.conn
+	xact := "-"
+	if conn.XactStart != nil {
+		xact = util.FormatDuration(conn.TransactionDuration())
+	}
+	blocks := "-"
+	if row.blocks > 0 {
+		blocks = fmt.Sprintf("%d", row.blocks)
+	}
+	query := printable(conn.Query)
+	return fmt.Sprintf("%-7d %-14s %8s %8s %6s %-16s %-12s %s",
+		conn.PID,
+		util.Truncate(stateLabel(conn.State), 14),
+		util.FormatDuration(conn.IdleDuration()),
+		xact,
+		blocks,
+		util.Truncate(printable(conn.ApplicationName), 16),
+		util.Truncate(printable(conn.Username), 12),
+		query,
+	)
+}
+
+// stateLabel shortens pg_stat_activity states to fit the table
+func stateLabel(state postgres.ConnectionState) string {
+	switch state {
+	case postgres.StateIdleInTransaction:
+		return "idle in tx"
+	case postgres.StateIdleInTransactionAborted:
+		return "idle tx (abrt)"
+	case postgres.StateFastpath:
+		return "fastpath"
+	}
+	return string(state)
+}
+
+func formatLock(l *postgres.Lock) string {
+	status := "granted"
+	if !l.Granted {
+		status = "WAITING"
+	}
+	target := l.LockType
+	if l.Relation != "" {
+		target += " " + printable(l.Relation)
+	}
+	return fmt.Sprintf("%-8s %-24s %s", status, l.Mode, target)
+}
+
+func joinPIDs(pids []int) string {
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = fmt.Sprintf("%d", pid)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// usageBar draws a fixed-width bar for a percentage
+func usageBar(percent float64, width int) string {
+	if width < 3 {
+		return ""
+	}
+	inner := width - 2
+	filled := int(percent / 100 * float64(inner))
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > inner {
+		filled = inner
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", inner-filled) + "]"
+}
+
+// printable collapses whitespace and replaces control characters, so text
+// from other sessions (queries, application names) can't move the cursor or
+// send escape sequences to the terminal
+func printable(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// wrapText splits s into at most maxLines lines of width characters
+func wrapText(s string, width, maxLines int) []string {
+	if width < 1 || s == "" {
+		return nil
+	}
+	runes := []rune(s)
+	var lines []string
+	for len(runes) > 0 && len(lines) < maxLines {
+		if len(runes) <= width {
+			lines = append(lines, string(runes))
+			return lines
+		}
+		lines = append(lines, string(runes[:width]))
+		runes = runes[width:]
+	}
+	if len(runes) > 0 && len(lines) > 0 {
+		last := lines[len(lines)-1]
+		lines[len(lines)-1] = util.Truncate(last+string(runes), width)
+	}
+	return lines
+}
+
+// fit cuts s to the terminal width
+func fit(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width])
+}
+
+func padRight(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+	ansiGreen   = "\x1b[32m"
+)
+
+func poolColor(percent float64) string {
+	switch {
+	case percent >= float64(cfg.Thresholds.ConnectionPool.CriticalPercent):
+		return ansiRed
+	case percent >= float64(cfg.Thresholds.ConnectionPool.WarningPercent):
+		return ansiYellow
+	}
+	return ansiGreen
+}
+
+func idleColor(d time.Duration) string {
+	switch {
+	case d >= cfg.Thresholds.IdleTransaction.Critical:
+		return ansiRed
+	case d >= cfg.Thresholds.IdleTransaction.Warning:
+		return ansiYellow
+	}
+	return ""
+}
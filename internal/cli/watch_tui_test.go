@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  []string
+	}{
+		{"printable", []byte("q"), []string{"q"}},
+		{"arrows", []byte("\x1b[A\x1b[B"), []string{"up", "down"}},
+		{"page keys", []byte("\x1b[5~\x1b[6~"), []string{"pgup", "pgdown"}},
+		{"lone escape", []byte{0x1b}, []string{"esc"}},
+		{"enter and backspace", []byte{'\r', 0x7f}, []string{"enter", "backspace"}},
+		{"ctrl-c", []byte{0x03}, []string{"ctrl-c"}},
+		{"mixed", []byte("a\x1b[Bb"), []string{"a", "down", "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseKeys(tt.input)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseKeys(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func testWatchRows() []*watchRow {
+	now := time.Now()
+	return []*watchRow{
+		{conn: &postgres.Connection{PID: 1, ApplicationName: "web", Username: "app", Database: "shop",
+			State: postgres.StateActive, StateChange: now.Add(-5 * time.Second), Query: "SELECT 1"}},
+		{conn: &postgres.Connection{PID: 2, ApplicationName: "worker", Username: "jobs", Database: "shop",
+			State: postgres.StateIdleInTransaction, StateChange: now.Add(-2 * time.Minute), Query: "UPDATE orders SET x = 1"}, blocks: 3},
+		{conn: &postgres.Connection{PID: 3, ApplicationName: "api", Username: "app", Database: "billing",
+			State: postgres.StateIdle, StateChange: now.Add(-30 * time.Second), Query: "COMMIT"}, blocks: 1},
+	}
+}
+
+func rowPIDs(rows []*watchRow) []int {
+	pids := make([]int, len(rows))
+	for i, r := range rows {
+		pids[i] = r.conn.PID
+	}
+	return pids
+}
+
+func TestFilterWatchRows(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     string
+		idleTxOnly bool
+		want       []int
+	}{
+		{"empty filter", "", false, []int{1, 2, 3}},
+		{"free text matches query", "orders", false, []int{2}},
+		{"field match", "user:app", false, []int{1, 3}},
+		{"all terms must match", "user:app db:billing", false, []int{3}},
+		{"case insensitive", "APP:WEB", false, []int{1}},
+		{"idle tx only", "", true, []int{2}},
+		{"no match", "app:nothing", false, []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := rowPIDs(filterWatchRows(testWatchRows(), tt.filter, tt.idleTxOnly))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filterWatchRows(%q) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortWatchRows(t *testing.T) {
+	tests := []struct {
+		key  watchSort
+		want []int
+	}{
+		{sortByAge, []int{2, 3, 1}},
+		{sortByState, []int{1, 3, 2}},
+		{sortByApp, []int{3, 1, 2}},
+		{sortByBlocked, []int{2, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key.String(), func(t *testing.T) {
+			rows := testWatchRows()
+			sortWatchRows(rows, tt.key)
+			if got := rowPIDs(rows); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sort by %s = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUsageBar(t *testing.T) {
+	tests := []struct {
+		percent float64
+		width   int
+		want    string
+	}{
+		{0, 12, "[..........]"},
+		{50, 12, "[#####.....]"},
+		{100, 12, "[##########]"},
+		{150, 12, "[##########]"},
+		{50, 2, ""},
+	}
+
+	for _, tt := range tests {
+		if got := usageBar(tt.percent, tt.width); got != tt.want {
+			t.Errorf("usageBar(%v, %d) = %q, want %q", tt.percent, tt.width, got, tt.want)
+		}
+	}
+}
+
+func TestPrintable(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"SELECT\n\t1", "SELECT 1"},
+		{"app\x1b[2J\x1b]0;pwned\x07", "app [2J ]0;pwned"},
+		{"c1\u009bcontrol", "c1 control"},
+		{"café", "café"},
+	}
+
+	for _, tt := range tests {
+		if got := printable(tt.in); got != tt.want {
+			t.Errorf("printable(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFitRunes(t *testing.T) {
+	if got := fit("héllo wörld", 5); got != "héllo" {
+		t.Errorf("fit() = %q, want %q", got, "héllo")
+	}
+	if got := padRight("né", 4); got != "né  " {
+		t.Errorf("padRight() = %q, want %q", got, "né  ")
+	}
+	if got := wrapText("ééééé", 2, 3); len(got) != 3 || got[0] != "éé" || got[2] != "é" {
+		t.Errorf("wrapText() = %q", got)
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize is a size in bytes that can be written in YAML as a plain number
+// or with a unit suffix, e.g. "512MB" or "10GB" (binary multiples)
+type ByteSize int64
+
+var byteUnits = []struct {
+	suffix string
+	mult   int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize parses strings like "1GB", "512 MB" or "1048576"
+func ParseByteSize(s string) (ByteSize, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	mult := int64(1)
+	for _, u := range byteUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			mult = u.mult
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(str, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid byte size: %q", s)
+	}
+	return ByteSize(n * float64(mult)), nil
+}
+
+// String formats the size using the largest unit that divides it evenly
+func (b ByteSize) String() string {
+	for _, u := range byteUnits {
+		if int64(b) >= u.mult && int64(b)%u.mult == 0 {
+			return fmt.Sprintf("%d%s", int64(b)/u.mult, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%dB", int64(b))
+}
+
+// UnmarshalYAML accepts either an integer or a string with a unit suffix
+func (b *ByteSize) UnmarshalYAML(value *yaml.Node) error {
+	parsed, err := ParseByteSize(value.Value)
+	if err != nil {
+		return err
+	}
+	*b = parsed
+	return nil
+}
+
+// MarshalYAML writes the size in its human-readable form
+func (b ByteSize) MarshalYAML() (interface{}, error) {
+	return b.String(), nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ByteSize
+		wantErr bool
+	}{
+		{"1024", 1024, false},
+		{"1KB", 1024, false},
+		{"512MB", 512 << 20, false},
+		{"10 GB", 10 << 30, false},
+		{"1.5gb", 3 << 29, false},
+		{"2TB", 2 << 40, false},
+		{"100B", 100, false},
+		{"lots", 0, true},
+		{"-1GB", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseByteSize(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseByteSize(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestByteSizeYAMLRoundTrip(t *testing.T) {
+	type wrapper struct {
+		Size ByteSize `yaml:"size"`
+	}
+
+	in := wrapper{Size: 10 << 30}
+	data, err := yaml.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "size: 10GB\n" {
+		t.Errorf("Marshal() = %q, want %q", data, "size: 10GB\n")
+	}
+
+	var out wrapper
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Size != in.Size {
+		t.Errorf("round trip = %d, want %d", out.Size, in.Size)
+	}
+
+	if err := yaml.Unmarshal([]byte("size: 2048\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Size != 2048 {
+		t.Errorf("plain integer = %d, want 2048", out.Size)
+	}
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"time"
@@ -18,9 +19,15 @@ type Config struct {
 	AutoTerm   AutoTermConfig   `yaml:"auto_terminate"`
 	API        APIConfig        `yaml:"api"`
 	Logging    LoggingConfig    `yaml:"logging"`
+	Audit      AuditConfig      `yaml:"audit"`
+	Owners     OwnersConfig     `yaml:"owners"`
+	State      StateConfig      `yaml:"state"`
 }
 
 type ConnectionConfig struct {
+	// Name labels this database in alerts; defaults to host/database
+	Name string `yaml:"name"`
+
 	// Connection string (alternative to individual fields)
 	URL string `yaml:"url"`
 
@@ -49,16 +56,77 @@ type ConnectionConfig struct {
 type ThresholdsConfig struct {
 	IdleTransaction IdleTransactionThresholds `yaml:"idle_transaction"`
 	ConnectionPool  ConnectionPoolThresholds  `yaml:"connection_pool"`
+	XminHorizon     XminHorizonThresholds     `yaml:"xmin_horizon"`
+	PreparedXact    PreparedXactThresholds    `yaml:"prepared_transaction"`
+	ReplicationSlot ReplicationSlotThresholds `yaml:"replication_slot"`
+	RoleLimit       ConnectionLimitThresholds `yaml:"role_limit"`     // rolconnlimit
+	DatabaseLimit   ConnectionLimitThresholds `yaml:"database_limit"` // datconnlimit
 }
 
 type IdleTransactionThresholds struct {
-	Warning  time.Duration `yaml:"warning"`
-	Critical time.Duration `yaml:"critical"`
+	Warning   time.Duration `yaml:"warning"`
+	Critical  time.Duration `yaml:"critical"`
+	Emergency time.Duration `yaml:"emergency"` // Optional tier above critical; 0 disables it
 }
 
 type ConnectionPoolThresholds struct {
 	WarningPercent  int `yaml:"warning_percent"`
 	CriticalPercent int `yaml:"critical_percent"`
+
+	// Hysteresis: a firing alert only clears once usage drops below these.
+	// Zero means 5 points under the matching threshold.
+	WarningClearPercent  int           `yaml:"warning_clear_percent"`
+	CriticalClearPercent int           `yaml:"critical_clear_percent"`
+	MinDuration          time.Duration `yaml:"min_duration"` // Pressure must last this long before alerting
+
+	// Backends excluded from usage. They still occupy slots, so they are
+	// always subtracted from available connections.
+	ExcludeOwnConnections bool `yaml:"exclude_own_connections"` // pguard's own backends
+	ExcludeRDSAdmin       bool `yaml:"exclude_rdsadmin"`        // RDS rdsadmin backends
+}
+
+// WarningClear returns the usage below which a warning clears
+func (t ConnectionPoolThresholds) WarningClear() float64 {
+	if t.WarningClearPercent > 0 {
+		return float64(t.WarningClearPercent)
+	}
+	return float64(t.WarningPercent - 5)
+}
+
+// CriticalClear returns the usage below which a critical alert drops back to warning
+func (t ConnectionPoolThresholds) CriticalClear() float64 {
+	if t.CriticalClearPercent > 0 {
+		return float64(t.CriticalClearPercent)
+	}
+	return float64(t.CriticalPercent - 5)
+}
+
+// ConnectionLimitThresholds apply to a single role's or database's own connection limit
+type ConnectionLimitThresholds struct {
+	Enabled         bool `yaml:"enabled"`
+	WarningPercent  int  `yaml:"warning_percent"`
+	CriticalPercent int  `yaml:"critical_percent"`
+}
+
+// XminHorizonThresholds are expressed in transactions (age(xmin)), not time
+type XminHorizonThresholds struct {
+	Enabled     bool  `yaml:"enabled"`
+	WarningAge  int64 `yaml:"warning_age"`
+	CriticalAge int64 `yaml:"critical_age"`
+}
+
+type PreparedXactThresholds struct {
+	Warning  time.Duration `yaml:"warning"`
+	Critical time.Duration `yaml:"critical"`
+}
+
+type ReplicationSlotThresholds struct {
+	Enabled          bool     `yaml:"enabled"`
+	WarningRetained  ByteSize `yaml:"warning_retained"` // WAL retained by the slot
+	CriticalRetained ByteSize `yaml:"critical_retained"`
+	WarningXminAge   int64    `yaml:"warning_xmin_age"` // age of the slot's xmin/catalog_xmin
+	CriticalXminAge  int64    `yaml:"critical_xmin_age"`
+	AlertInactive    bool     `yaml:"alert_inactive"` // Warn on any inactive slot regardless of size
 }
 
 type PollingConfig struct {
@@ -67,12 +135,73 @@ type PollingConfig struct {
 }
 
 type AlertsConfig struct {
-	Cooldown time.Duration `yaml:"cooldown"`
-	Slack    SlackConfig   `yaml:"slack"`
-	Webhook  WebhookConfig `yaml:"webhook"`
+	Cooldown    time.Duration     `yaml:"cooldown"`
+	Slack       SlackReceivers    `yaml:"slack"`
+	Webhook     WebhookReceivers  `yaml:"webhook"`
+	Routes      []RouteConfig     `yaml:"routes"` // Without routes, every receiver gets every alert
+	PollFailure PollFailureConfig `yaml:"poll_failure"`
+	Heartbeat   HeartbeatConfig   `yaml:"heartbeat"`
+	Grouping    GroupingConfig    `yaml:"grouping"`
+	Digest      DigestConfig      `yaml:"digest"`
+	Renotify    RenotifyConfig    `yaml:"renotify"`
+}
+
+// RenotifyConfig repeats idle transaction alerts while the transaction stays
+// open. The interval for the highest severity reached applies; 0 never repeats.
+type RenotifyConfig struct {
+	Warning   time.Duration `yaml:"warning"`
+	Critical  time.Duration `yaml:"critical"`
+	Emergency time.Duration `yaml:"emergency"`
+}
+
+// Interval returns the re-notify interval for a severity
+func (r RenotifyConfig) Interval(severity string) time.Duration {
+	switch severity {
+	case "warning":
+		return r.Warning
+	case "critical":
+		return r.Critical
+	case "emergency":
+		return r.Emergency
+	}
+	return 0
+}
+
+// GroupingConfig batches per-connection alerts with the same target,
+// severity and application into one message
+type GroupingConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	Window  time.Duration `yaml:"window"` // How long to collect a group; 0 sends each poll's groups at once
+}
+
+// DigestConfig sends a periodic summary of incidents, terminations and pool usage
+type DigestConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Interval time.Duration `yaml:"interval"` // e.g. 1h or 24h
+}
+
+// HeartbeatConfig pings external dead man's switch monitors after each poll
+type HeartbeatConfig struct {
+	Enabled     bool              `yaml:"enabled"`
+	Targets     []HeartbeatTarget `yaml:"targets"`
+	MinInterval time.Duration     `yaml:"min_interval"` // Minimum time between pings with the same status
+}
+
+type HeartbeatTarget struct {
+	URL     string `yaml:"url"`      // Pinged after each successful poll
+	FailURL string `yaml:"fail_url"` // Pinged when a poll fails (optional)
+	Method  string `yaml:"method"`   // POST (default) or GET
+}
+
+// PollFailureConfig controls the alert sent when pguard itself cannot poll.
+// It fires on whichever limit is reached first.
+type PollFailureConfig struct {
+	Failures int           `yaml:"failures"` // Consecutive failed polls
+	After    time.Duration `yaml:"after"`    // Time since the first failed poll
 }
 
 type WebhookConfig struct {
+	Name     string            `yaml:"name"`
 	Enabled  bool              `yaml:"enabled"`
 	URL      string            `yaml:"url"`
 	Method   string            `yaml:"method"` // POST (default) or GET
@@ -81,11 +210,15 @@ type WebhookConfig struct {
 }
 
 type SlackConfig struct {
+	Name          string   `yaml:"name"`
 	Enabled       bool     `yaml:"enabled"`
 	WebhookURL    string   `yaml:"webhook_url"`
 	WebhookSecret string   `yaml:"webhook_secret"` // ARN for secrets manager
 	Channel       string   `yaml:"channel"`
 	MentionUsers  []string `yaml:"mention_users"`
+
+	// Mentioned on emergency alerts along with the owning team; defaults to mention_users
+	EmergencyMentions []string `yaml:"emergency_mentions"`
 }
 
 type AutoTermConfig struct {
@@ -104,8 +237,39 @@ type ProtectedApp struct {
 }
 
 type APIConfig struct {
-	Enabled bool   `yaml:"enabled"`
-	Listen  string `yaml:"listen"`
+	Enabled     bool          `yaml:"enabled"`
+	Listen      string        `yaml:"listen"`
+	EventBuffer int           `yaml:"event_buffer"` // Events kept for /events reconnects
+	ReadyPolls  int           `yaml:"ready_polls"`  // Consecutive successful polls before /readyz passes
+	TLS         APITLSConfig  `yaml:"tls"`
+	Auth        APIAuthConfig `yaml:"auth"`
+}
+
+// APITLSConfig enables HTTPS. Certificate files are reloaded when they change.
+type APITLSConfig struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"` // Require client certificates signed by this CA (mTLS)
+}
+
+// APIAuthConfig configures bearer tokens. Without tokens the API is open.
+type APIAuthConfig struct {
+	TokensFile   string `yaml:"tokens_file"`   // YAML list of {name, token, role}
+	TokensSecret string `yaml:"tokens_secret"` // Secrets Manager ARN holding the same YAML
+}
+
+type AuditConfig struct {
+	Path string `yaml:"path"` // Defaults to audit.log in the config directory
+}
+
+// OwnersConfig points at the file mapping apps, roles and client networks to teams
+type OwnersConfig struct {
+	File string `yaml:"file"` // Empty disables ownership
+}
+
+// StateConfig locates the file the daemon keeps runtime state in, e.g. silences
+type StateConfig struct {
+	Path string `yaml:"path"` // Defaults to state.json in the config directory
 }
 
 type LoggingConfig struct {
@@ -129,6 +293,34 @@ func DefaultConfig() *Config {
 				Critical: 2 * time.Minute,
 			},
 			ConnectionPool: ConnectionPoolThresholds{
+				WarningPercent:        75,
+				CriticalPercent:       90,
+				ExcludeOwnConnections: true,
+				ExcludeRDSAdmin:       true,
+			},
+			XminHorizon: XminHorizonThresholds{
+				Enabled:     true,
+				WarningAge:  1_000_000,
+				CriticalAge: 10_000_000,
+			},
+			PreparedXact: PreparedXactThresholds{
+				Warning:  5 * time.Minute,
+				Critical: 30 * time.Minute,
+			},
+			ReplicationSlot: ReplicationSlotThresholds{
+				Enabled:          true,
+				WarningRetained:  1 << 30,  // 1GB
+				CriticalRetained: 10 << 30, // 10GB
+				WarningXminAge:   1_000_000,
+				CriticalXminAge:  10_000_000,
+			},
+			RoleLimit: ConnectionLimitThresholds{
+				Enabled:         true,
+				WarningPercent:  75,
+				CriticalPercent: 90,
+			},
+			DatabaseLimit: ConnectionLimitThresholds{
+				Enabled:         true,
 				WarningPercent:  75,
 				CriticalPercent: 90,
 			},
@@ -139,6 +331,13 @@ func DefaultConfig() *Config {
 		},
 		Alerts: AlertsConfig{
 			Cooldown: 5 * time.Minute,
+			PollFailure: PollFailureConfig{
+				Failures: 3,
+				After:    2 * time.Minute,
+			},
+			Digest: DigestConfig{
+				Interval: 24 * time.Hour,
+			},
 		},
 		AutoTerm: AutoTermConfig{
 			Enabled:     false,
@@ -147,8 +346,10 @@ func DefaultConfig() *Config {
 			ExcludeApps: []string{"pguard", "pg_dump"},
 		},
 		API: APIConfig{
-			Enabled: false,
-			Listen:  "127.0.0.1:9182",
+			Enabled:     false,
+			Listen:      "127.0.0.1:9182",
+			EventBuffer: 1000,
+			ReadyPolls:  3,
 		},
 		Logging: LoggingConfig{
 			Level:  "info",
@@ -176,6 +377,30 @@ func Path() (string, error) {
 	return filepath.Join(dir, "config.yaml"), nil
 }
 
+// AuditPath returns the configured audit log path, falling back to the config directory
+func (c *Config) AuditPath() (string, error) {
+	if c.Audit.Path != "" {
+		return c.Audit.Path, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "audit.log"), nil
+}
+
+// StatePath returns the configured state file path, falling back to the config directory
+func (c *Config) StatePath() (string, error) {
+	if c.State.Path != "" {
+		return c.State.Path, nil
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
 // Load reads config from the given path
 func Load(path string) (*Config, error) {
 	cfg := DefaultConfig()
@@ -235,8 +460,12 @@ func (c *Config) expandEnvVars() {
 	c.Connection.Password = os.ExpandEnv(c.Connection.Password)
 	c.Connection.User = os.ExpandEnv(c.Connection.User)
 	c.Connection.Database = os.ExpandEnv(c.Connection.Database)
-	c.Alerts.Slack.WebhookURL = os.ExpandEnv(c.Alerts.Slack.WebhookURL)
-	c.Alerts.Webhook.URL = os.ExpandEnv(c.Alerts.Webhook.URL)
+	for i := range c.Alerts.Slack {
+		c.Alerts.Slack[i].WebhookURL = os.ExpandEnv(c.Alerts.Slack[i].WebhookURL)
+	}
+	for i := range c.Alerts.Webhook {
+		c.Alerts.Webhook[i].URL = os.ExpandEnv(c.Alerts.Webhook[i].URL)
+	}
 }
 
 // ConnectionString builds a PostgreSQL connection string from config
@@ -263,6 +492,32 @@ func (c *Config) ConnectionString() string {
 	)
 }
 
+// TargetName returns the label for the monitored database used in alerts:
+// connection.name, or host/database from the connection settings. It never
+// includes credentials.
+func (c *Config) TargetName() string {
+	if c.Connection.Name != "" {
+		return c.Connection.Name
+	}
+
+	connURL := c.Connection.URL
+	if connURL == "" && c.Connection.Host == "" {
+		connURL = os.Getenv("DATABASE_URL")
+	}
+	if connURL != "" {
+		u, err := url.Parse(connURL)
+		if err != nil || u.Host == "" {
+			return "postgres"
+		}
+		return u.Hostname() + u.Path
+	}
+
+	if c.Connection.Database == "" {
+		return c.Connection.Host
+	}
+	return c.Connection.Host + "/" + c.Connection.Database
+}
+
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	if c.Connection.URL == "" && c.Connection.Host == "" {
@@ -275,9 +530,93 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("idle_transaction.warning must be less than critical")
 	}
 
+	if e := c.Thresholds.IdleTransaction.Emergency; e < 0 || (e > 0 && e <= c.Thresholds.IdleTransaction.Critical) {
+		return fmt.Errorf("idle_transaction.emergency must be greater than critical")
+	}
+
 	if c.Thresholds.ConnectionPool.WarningPercent >= c.Thresholds.ConnectionPool.CriticalPercent {
 		return fmt.Errorf("connection_pool.warning_percent must be less than critical_percent")
 	}
 
+	if c.Thresholds.ConnectionPool.WarningClearPercent > c.Thresholds.ConnectionPool.WarningPercent {
+		return fmt.Errorf("connection_pool.warning_clear_percent must not exceed warning_percent")
+	}
+
+	if c.Thresholds.ConnectionPool.CriticalClearPercent > c.Thresholds.ConnectionPool.CriticalPercent {
+		return fmt.Errorf("connection_pool.critical_clear_percent must not exceed critical_percent")
+	}
+
+	if c.Thresholds.XminHorizon.Enabled && c.Thresholds.XminHorizon.WarningAge >= c.Thresholds.XminHorizon.CriticalAge {
+		return fmt.Errorf("xmin_horizon.warning_age must be less than critical_age")
+	}
+
+	if c.Thresholds.PreparedXact.Warning >= c.Thresholds.PreparedXact.Critical {
+		return fmt.Errorf("prepared_transaction.warning must be less than critical")
+	}
+
+	if c.Thresholds.RoleLimit.Enabled && c.Thresholds.RoleLimit.WarningPercent >= c.Thresholds.RoleLimit.CriticalPercent {
+		return fmt.Errorf("role_limit.warning_percent must be less than critical_percent")
+	}
+
+	if c.Thresholds.DatabaseLimit.Enabled && c.Thresholds.DatabaseLimit.WarningPercent >= c.Thresholds.DatabaseLimit.CriticalPercent {
+		return fmt.Errorf("database_limit.warning_percent must be less than critical_percent")
+	}
+
+	if (c.API.TLS.CertFile == "") != (c.API.TLS.KeyFile == "") {
+		return fmt.Errorf("api.tls.cert_file and api.tls.key_file must be set together")
+	}
+
+	if c.API.TLS.ClientCAFile != "" && c.API.TLS.CertFile == "" {
+		return fmt.Errorf("api.tls.client_ca_file requires api.tls.cert_file and key_file")
+	}
+
+	if c.API.Auth.TokensFile != "" && c.API.Auth.TokensSecret != "" {
+		return fmt.Errorf("set only one of api.auth.tokens_file and api.auth.tokens_secret")
+	}
+
+	if c.Alerts.Heartbeat.Enabled {
+		if len(c.Alerts.Heartbeat.Targets) == 0 {
+			return fmt.Errorf("alerts.heartbeat.targets must not be empty when heartbeat is enabled")
+		}
+		for i, t := range c.Alerts.Heartbeat.Targets {
+			if t.URL == "" {
+				return fmt.Errorf("alerts.heartbeat.targets[%d].url is required", i)
+			}
+		}
+	}
+
+	if err := c.Alerts.validateRouting(); err != nil {
+		return err
+	}
+
+	if c.Alerts.Grouping.Window < 0 {
+		return fmt.Errorf("alerts.grouping.window must not be negative")
+	}
+
+	if c.Alerts.Digest.Enabled && c.Alerts.Digest.Interval < time.Minute {
+		return fmt.Errorf("alerts.digest.interval must be at least 1m")
+	}
+
+	if r := c.Alerts.Renotify; r.Warning < 0 || r.Critical < 0 || r.Emergency < 0 {
+		return fmt.Errorf("alerts.renotify intervals must not be negative")
+	}
+
+	if c.Alerts.PollFailure.Failures < 1 {
+		return fmt.Errorf("alerts.poll_failure.failures must be at least 1")
+	}
+
+	if c.API.ReadyPolls < 1 {
+		return fmt.Errorf("api.ready_polls must be at least 1")
+	}
+
+	if c.Thresholds.ReplicationSlot.Enabled {
+		if c.Thresholds.ReplicationSlot.WarningRetained >= c.Thresholds.ReplicationSlot.CriticalRetained {
+			return fmt.Errorf("replication_slot.warning_retained must be less than critical_retained")
+		}
+		if c.Thresholds.ReplicationSlot.WarningXminAge >= c.Thresholds.ReplicationSlot.CriticalXminAge {
+			return fmt.Errorf("replication_slot.warning_xmin_age must be less than critical_xmin_age")
+		}
+	}
+
 	return nil
 }
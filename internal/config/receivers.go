@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SlackReceivers is the list of named Slack receivers. The single mapping
+// used before receivers had names is still accepted, as a receiver named
+// "slack".
+type SlackReceivers []SlackConfig
+
+// WebhookReceivers is the list of named webhook receivers. A single mapping
+// is accepted as a receiver named "webhook".
+type WebhookReceivers []WebhookConfig
+
+// RouteConfig sends alerts matching every set field to the named receivers.
+// Routes are checked in order; the first match wins unless it sets continue.
+type RouteConfig struct {
+	Types      []string `yaml:"types"`      // Alert types, e.g. idle_transaction, connection_pool
+	Severities []string `yaml:"severities"` // warning, critical, emergency, info, resolved
+	App        string   `yaml:"app"`        // Glob pattern for application_name
+	User       string   `yaml:"user"`       // Glob pattern
+	Database   string   `yaml:"database"`   // Glob pattern
+	Target     string   `yaml:"target"`     // Glob pattern for connection.name
+	Receivers  []string `yaml:"receivers"`  // Empty drops the alert
+	Continue   bool     `yaml:"continue"`   // Keep checking later routes after a match
+}
+
+// UnmarshalYAML accepts a list of receivers or a single legacy mapping.
+// Receivers in a list are enabled unless they say otherwise.
+func (r *SlackReceivers) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.MappingNode {
+		var single SlackConfig
+		if err := value.Decode(&single); err != nil {
+			return err
+		}
+		if single.Name == "" {
+			single.Name = "slack"
+		}
+		*r = SlackReceivers{single}
+		return nil
+	}
+
+	var list SlackReceivers
+	for _, item := range value.Content {
+		receiver := SlackConfig{Enabled: true}
+		if err := item.Decode(&receiver); err != nil {
+			return err
+		}
+		list = append(list, receiver)
+	}
+	*r = list
+	return nil
+}
+
+// UnmarshalYAML accepts a list of receivers or a single legacy mapping.
+// Receivers in a list are enabled unless they say otherwise.
+func (r *WebhookReceivers) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.MappingNode {
+		var single WebhookConfig
+		if err := value.Decode(&single); err != nil {
+			return err
+		}
+		if single.Name == "" {
+			single.Name = "webhook"
+		}
+		*r = WebhookReceivers{single}
+		return nil
+	}
+
+	var list WebhookReceivers
+	for _, item := range value.Content {
+		receiver := WebhookConfig{Enabled: true}
+		if err := item.Decode(&receiver); err != nil {
+			return err
+		}
+		list = append(list, receiver)
+	}
+	*r = list
+	return nil
+}
+
+// validateRouting checks receiver names and that routes only use known receivers
+func (a *AlertsConfig) validateRouting() error {
+	names := make(map[string]bool)
+	add := func(kind, name string) error {
+		if name == "" {
+			return fmt.Errorf("alerts.%s: every receiver needs a name", kind)
+		}
+		if names[name] {
+			return fmt.Errorf("alerts: duplicate receiver name %q", name)
+		}
+		names[name] = true
+		return nil
+	}
+	for _, s := range a.Slack {
+		if err := add("slack", s.Name); err != nil {
+			return err
+		}
+	}
+	for _, w := range a.Webhook {
+		if err := add("webhook", w.Name); err != nil {
+			return err
+		}
+	}
+
+	for i, route := range a.Routes {
+		for _, name := range route.Receivers {
+			if !names[name] {
+				return fmt.Errorf("alerts.routes[%d]: unknown receiver %q", i, name)
+			}
+		}
+		for _, pattern := range []string{route.App, route.User, route.Database, route.Target} {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("alerts.routes[%d]: invalid pattern %q", i, pattern)
+			}
+		}
+	}
+	return nil
+}
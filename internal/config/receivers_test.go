@@ -0,0 +1,119 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestReceiversUnmarshal(t *testing.T) {
+	t.Run("legacy single blocks", func(t *testing.T) {
+		var a AlertsConfig
+		data := `
+slack:
+  enabled: true
+  channel: "#alerts"
+webhook:
+  url: https://example.com/hook
+`
+		if err := yaml.Unmarshal([]byte(data), &a); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if len(a.Slack) != 1 || a.Slack[0].Name != "slack" || !a.Slack[0].Enabled || a.Slack[0].Channel != "#alerts" {
+			t.Errorf("Slack = %+v", a.Slack)
+		}
+		if len(a.Webhook) != 1 || a.Webhook[0].Name != "webhook" || a.Webhook[0].Enabled {
+			t.Errorf("Webhook = %+v, want one disabled receiver named webhook", a.Webhook)
+		}
+	})
+
+	t.Run("named lists", func(t *testing.T) {
+		var a AlertsConfig
+		data := `
+slack:
+  - name: team
+    channel: "#db-alerts"
+  - name: payments
+    channel: "#payments-db"
+    enabled: false
+webhook:
+  - name: pager
+    url: https://example.com/page
+routes:
+  - app: "payments-*"
+    receivers: [payments]
+    continue: true
+  - severities: [critical]
+    receivers: [pager, team]
+`
+		if err := yaml.Unmarshal([]byte(data), &a); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+		if len(a.Slack) != 2 || !a.Slack[0].Enabled || a.Slack[1].Enabled {
+			t.Errorf("Slack = %+v, want team enabled and payments disabled", a.Slack)
+		}
+		if len(a.Webhook) != 1 || !a.Webhook[0].Enabled {
+			t.Errorf("Webhook = %+v", a.Webhook)
+		}
+		if len(a.Routes) != 2 || !a.Routes[0].Continue || a.Routes[1].Receivers[0] != "pager" {
+			t.Errorf("Routes = %+v", a.Routes)
+		}
+		if err := a.validateRouting(); err != nil {
+			t.Errorf("validateRouting() error = %v", err)
+		}
+	})
+}
+
+func TestValidateRouting(t *testing.T) {
+	tests := []struct {
+		name    string
+		alerts  AlertsConfig
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			alerts: AlertsConfig{
+				Slack:   SlackReceivers{{Name: "team"}},
+				Webhook: WebhookReceivers{{Name: "pager"}},
+				Routes:  []RouteConfig{{App: "pay*", Receivers: []string{"team", "pager"}}},
+			},
+		},
+		{
+			name:    "unnamed receiver",
+			alerts:  AlertsConfig{Slack: SlackReceivers{{Channel: "#alerts"}}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate name across kinds",
+			alerts: AlertsConfig{
+				Slack:   SlackReceivers{{Name: "ops"}},
+				Webhook: WebhookReceivers{{Name: "ops"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown receiver",
+			alerts: AlertsConfig{
+				Slack:  SlackReceivers{{Name: "team"}},
+				Routes: []RouteConfig{{Receivers: []string{"tema"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "bad pattern",
+			alerts: AlertsConfig{
+				Slack:  SlackReceivers{{Name: "team"}},
+				Routes: []RouteConfig{{App: "[pay", Receivers: []string{"team"}}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.alerts.validateRouting(); (err != nil) != tt.wantErr {
+				t.Errorf("validateRouting() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package events
+
+import "sync"
+
+// subscriberBuffer is how many events a subscriber may fall behind before it
+// is dropped. Dropped subscribers reconnect and catch up from the buffer.
+const subscriberBuffer = 64
+
+// Broker numbers events, keeps the most recent ones in a ring buffer and
+// fans them out to subscribers
+type Broker struct {
+	mu     sync.Mutex
+	ring   []Event
+	next   int // Ring index the next event is written to
+	full   bool
+	lastID uint64
+	subs   map[chan Event]struct{}
+	closed bool
+}
+
+// NewBroker creates a broker remembering up to size events
+func NewBroker(size int) *Broker {
+	if size < 1 {
+		size = 1
+	}
+	return &Broker{
+		ring: make([]Event, size),
+		subs: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish assigns the next ID to e, buffers it and delivers it to subscribers
+func (b *Broker) Publish(e Event) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e.ID = b.lastID
+	b.ring[b.next] = e
+	b.next = (b.next + 1) % len(b.ring)
+	if b.next == 0 {
+		b.full = true
+	}
+
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			// Too slow; closing lets the client reconnect with Last-Event-ID
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+	return e
+}
+
+// Since returns buffered events with an ID greater than lastID, oldest first.
+// An ID newer than anything published (e.g. from before a restart) returns
+// the whole buffer.
+func (b *Broker) Since(lastID uint64) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.since(lastID)
+}
+
+func (b *Broker) since(lastID uint64) []Event {
+	if lastID > b.lastID {
+		lastID = 0
+	}
+	var result []Event
+	for _, e := range b.buffered() {
+		if e.ID > lastID {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// buffered returns a copy of the ring in publish order
+func (b *Broker) buffered() []Event {
+	if !b.full {
+		return append([]Event(nil), b.ring[:b.next]...)
+	}
+	result := make([]Event, 0, len(b.ring))
+	result = append(result, b.ring[b.next:]...)
+	return append(result, b.ring[:b.next]...)
+}
+
+// Subscribe returns the buffered events after lastID and a channel receiving
+// every later event. The channel is closed if the subscriber falls behind.
+// Call the returned function to unsubscribe.
+func (b *Broker) Subscribe(lastID uint64) ([]Event, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	backlog := b.since(lastID)
+	if b.closed {
+		close(ch)
+		return backlog, ch, func() {}
+	}
+	b.subs[ch] = struct{}{}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[ch]; ok {
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, unsubscribe
+}
+
+// Close ends every subscription, so streams finish during server shutdown.
+// Later subscribers get a closed channel.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for ch := range b.subs {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
@@ -0,0 +1,105 @@
+package events
+
+import (
+	"testing"
+)
+
+func ids(events []Event) []uint64 {
+	var result []uint64
+	for _, e := range events {
+		result = append(result, e.ID)
+	}
+	return result
+}
+
+func equalIDs(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBrokerSince(t *testing.T) {
+	b := NewBroker(3)
+	for i := 0; i < 5; i++ {
+		b.Publish(Event{Type: TypeIdleThreshold})
+	}
+
+	tests := []struct {
+		name   string
+		lastID uint64
+		want   []uint64
+	}{
+		{name: "from start keeps only the buffer", lastID: 0, want: []uint64{3, 4, 5}},
+		{name: "resume inside buffer", lastID: 3, want: []uint64{4, 5}},
+		{name: "up to date", lastID: 5, want: nil},
+		{name: "older than buffer", lastID: 1, want: []uint64{3, 4, 5}},
+		{name: "from before a restart", lastID: 99, want: []uint64{3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(b.Since(tt.lastID)); !equalIDs(got, tt.want) {
+				t.Errorf("Since(%d) = %v, want %v", tt.lastID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBrokerSubscribe(t *testing.T) {
+	b := NewBroker(10)
+	b.Publish(Event{Type: TypeIdleThreshold})
+
+	backlog, ch, unsubscribe := b.Subscribe(0)
+	defer unsubscribe()
+	if len(backlog) != 1 || backlog[0].ID != 1 {
+		t.Fatalf("backlog = %v, want event 1", ids(backlog))
+	}
+
+	b.Publish(Event{Type: TypeIdleResolved})
+	if e := <-ch; e.ID != 2 || e.Type != TypeIdleResolved {
+		t.Errorf("received %+v, want event 2", e)
+	}
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(10)
+	_, ch, unsubscribe := b.Subscribe(0)
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		b.Publish(Event{Type: TypeIdleThreshold})
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	if received != subscriberBuffer {
+		t.Errorf("received %d events before close, want %d", received, subscriberBuffer)
+	}
+
+	// Unsubscribing after being dropped must not panic
+	unsubscribe()
+}
+
+func TestBrokerClose(t *testing.T) {
+	b := NewBroker(10)
+	_, ch, unsubscribe := b.Subscribe(0)
+	defer unsubscribe()
+
+	b.Close()
+	if _, ok := <-ch; ok {
+		t.Error("expected the subscription to be closed")
+	}
+
+	_, late, unsubscribeLate := b.Subscribe(0)
+	defer unsubscribeLate()
+	if _, ok := <-late; ok {
+		t.Error("expected subscriptions after Close to be closed")
+	}
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// Event types. These names are part of the output format and must not change.
+const (
+	TypeIdleStarted   = "idle_transaction_started"   // A new idle-in-transaction session was seen
+	TypeIdleThreshold = "idle_transaction_threshold" // An idle transaction crossed warning or critical
+	TypeIdleResolved  = "idle_transaction_resolved"  // An idle transaction ended
+	TypePoolPressure  = "pool_pressure"              // Pool pressure moved between ok, warning and critical
+	TypePollFailed    = "poll_failed"                // Querying the database failed
+	TypeTerminated    = "connection_terminated"      // The daemon terminated a backend
+	TypeDryRun        = "auto_terminate_dry_run"     // The daemon would have terminated a backend
+)
+
+// Session identifies a backend. PIDs are reused, so the backend start time
+// is part of the identity.
+type Session struct {
+	ID           string    `json:"id"` // "<pid>-<backend start in unix microseconds>"
+	PID          int       `json:"pid"`
+	BackendStart time.Time `json:"backend_start"`
+}
+
+// NewSession builds the identity for a backend
+func NewSession(pid int, backendStart time.Time) *Session {
+	return &Session{
+		ID:           fmt.Sprintf("%d-%d", pid, backendStart.UnixMicro()),
+		PID:          pid,
+		BackendStart: backendStart.UTC(),
+	}
+}
+
+// Pool is a snapshot of connection pool usage
+type Pool struct {
+	Used         int     `json:"used"`
+	Capacity     int     `json:"capacity"`
+	UsagePercent float64 `json:"usage_percent"`
+}
+
+// Event is one structured event. Field names are stable; fields that don't
+// apply to an event type are omitted.
+type Event struct {
+	ID               uint64    `json:"id,omitempty"` // Assigned by a Broker, increasing
+	Time             time.Time `json:"time"`
+	Type             string    `json:"type"`
+	Severity         string    `json:"severity,omitempty"` // "warning", "critical", "emergency", or empty
+	PreviousSeverity string    `json:"previous_severity,omitempty"`
+	Session          *Session  `json:"session,omitempty"`
+	Application      string    `json:"application,omitempty"`
+	User             string    `json:"user,omitempty"`
+	Database         string    `json:"database,omitempty"`
+	ClientAddr       string    `json:"client_addr,omitempty"`
+	DurationSec      float64   `json:"duration_seconds,omitempty"`
+	Query            string    `json:"query,omitempty"`
+	Fingerprint      string    `json:"fingerprint,omitempty"` // See util.QueryFingerprint; silences can match it
+	Pool             *Pool     `json:"pool,omitempty"`
+	Message          string    `json:"message"`
+}
+
+// ForConnection builds an event describing a session
+func ForConnection(eventType, severity string, conn *postgres.Connection, duration time.Duration, message string) Event {
+	return Event{
+		Time:        time.Now().UTC(),
+		Type:        eventType,
+		Severity:    severity,
+		Session:     NewSession(conn.PID, conn.BackendStart),
+		Application: conn.ApplicationName,
+		User:        conn.Username,
+		Database:    conn.Database,
+		ClientAddr:  conn.ClientAddr,
+		DurationSec: duration.Seconds(),
+		Query:       util.TruncateQuery(conn.Query, 500),
+		Fingerprint: util.QueryFingerprint(conn.Query),
+		Message:     message,
+	}
+}
+
+// ForPool builds a pool pressure event
+func ForPool(severity, previous string, stats *postgres.PoolStats, message string) Event {
+	return Event{
+		Time:             time.Now().UTC(),
+		Type:             TypePoolPressure,
+		Severity:         severity,
+		PreviousSeverity: previous,
+		Pool: &Pool{
+			Used:         stats.UsedConnections(),
+			Capacity:     stats.Capacity(),
+			UsagePercent: stats.UsagePercent(),
+		},
+		Message: message,
+	}
+}
+
+// JSONLWriter writes events as JSON Lines, one event per line
+type JSONLWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewJSONLWriter creates a writer emitting to w
+func NewJSONLWriter(w io.Writer) *JSONLWriter {
+	return &JSONLWriter{enc: json.NewEncoder(w)}
+}
+
+// Write emits a single event
+func (j *JSONLWriter) Write(e Event) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.enc.Encode(e); err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/postgres"
+)
+
+func TestNewSession(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 6000, time.FixedZone("CET", 3600))
+	s := NewSession(4242, start)
+
+	if s.ID != "4242-1704161045000006" {
+		t.Errorf("ID = %q", s.ID)
+	}
+	if s.BackendStart.Location() != time.UTC {
+		t.Errorf("BackendStart not in UTC: %v", s.BackendStart)
+	}
+
+	// The same backend always gets the same identity; a reused PID does not
+	if other := NewSession(4242, start.Add(time.Second)); other.ID == s.ID {
+		t.Error("reused PID produced the same session ID")
+	}
+}
+
+func TestJSONLWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLWriter(&buf)
+
+	conn := &postgres.Connection{
+		PID:             100,
+		Username:        "app",
+		Database:        "orders",
+		ApplicationName: "billing",
+		BackendStart:    time.Unix(1700000000, 0),
+		Query:           "UPDATE accounts SET balance = 0",
+	}
+	if err := w.Write(ForConnection(TypeIdleThreshold, "critical", conn, 90*time.Second, "idle")); err != nil {
+		t.Fatal(err)
+	}
+	stats := &postgres.PoolStats{MaxConnections: 100, TotalConnections: 85}
+	if err := w.Write(ForPool("warning", "", stats, "pressure")); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+
+	var first map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"time", "type", "severity", "session", "application", "user", "database", "duration_seconds", "query", "message"} {
+		if _, ok := first[field]; !ok {
+			t.Errorf("connection event missing %q: %s", field, lines[0])
+		}
+	}
+	if _, ok := first["pool"]; ok {
+		t.Errorf("connection event should not carry pool: %s", lines[0])
+	}
+	session := first["session"].(map[string]any)
+	if session["id"] != "100-1700000000000000" || session["pid"] != float64(100) {
+		t.Errorf("session = %v", session)
+	}
+
+	var second Event
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatal(err)
+	}
+	if second.Type != TypePoolPressure || second.Session != nil || second.Pool == nil || second.Pool.Used != 85 {
+		t.Errorf("pool event = %+v", second)
+	}
+}
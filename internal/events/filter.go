@@ -0,0 +1,91 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Filter selects events. Zero-valued fields match everything.
+type Filter struct {
+	Types      []string
+	Severities []string
+	App        string // Glob pattern for the application name
+	User       string // Glob pattern
+	Database   string // Glob pattern
+	PID        int
+}
+
+// ParseFilter reads a filter from query parameters: type and severity take
+// comma-separated lists, app, user and database take globs
+func ParseFilter(q url.Values) (*Filter, error) {
+	f := &Filter{
+		Types:      splitList(q.Get("type")),
+		Severities: splitList(q.Get("severity")),
+		App:        q.Get("app"),
+		User:       q.Get("user"),
+		Database:   q.Get("database"),
+	}
+	for _, pattern := range []string{f.App, f.User, f.Database} {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	if s := q.Get("pid"); s != "" {
+		pid, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pid %q", s)
+		}
+		f.PID = pid
+	}
+	return f, nil
+}
+
+// Matches returns true if e satisfies every criterion in the filter
+func (f *Filter) Matches(e Event) bool {
+	if len(f.Types) > 0 && !contains(f.Types, e.Type) {
+		return false
+	}
+	if len(f.Severities) > 0 && !contains(f.Severities, e.Severity) {
+		return false
+	}
+	if f.App != "" && !globMatch(f.App, e.Application) {
+		return false
+	}
+	if f.User != "" && !globMatch(f.User, e.User) {
+		return false
+	}
+	if f.Database != "" && !globMatch(f.Database, e.Database) {
+		return false
+	}
+	if f.PID != 0 && (e.Session == nil || e.Session.PID != f.PID) {
+		return false
+	}
+	return true
+}
+
+func splitList(s string) []string {
+	var result []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func globMatch(pattern, s string) bool {
+	ok, err := filepath.Match(pattern, s)
+	return err == nil && ok
+}
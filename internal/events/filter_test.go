@@ -0,0 +1,65 @@
+package events
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestFilterMatches(t *testing.T) {
+	event := Event{
+		Type:        TypeIdleThreshold,
+		Severity:    "critical",
+		Session:     &Session{PID: 42},
+		Application: "payment-api",
+		User:        "app",
+		Database:    "orders",
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{name: "no filter", query: "", want: true},
+		{name: "type in list", query: "type=pool_pressure,idle_transaction_threshold", want: true},
+		{name: "type not in list", query: "type=pool_pressure", want: false},
+		{name: "severity", query: "severity=critical", want: true},
+		{name: "severity mismatch", query: "severity=warning", want: false},
+		{name: "app glob", query: "app=payment-*", want: true},
+		{name: "app glob mismatch", query: "app=billing-*", want: false},
+		{name: "user and database", query: "user=app&database=ord*", want: true},
+		{name: "pid", query: "pid=42", want: true},
+		{name: "pid mismatch", query: "pid=43", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := ParseFilter(q)
+			if err != nil {
+				t.Fatalf("ParseFilter: %v", err)
+			}
+			if got := f.Matches(event); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Events without a session never match a PID filter
+	f := &Filter{PID: 42}
+	if f.Matches(Event{Type: TypePoolPressure}) {
+		t.Error("pool event matched a pid filter")
+	}
+}
+
+func TestParseFilterErrors(t *testing.T) {
+	for _, query := range []string{"pid=abc", "app=[", "database=[a-"} {
+		q, _ := url.ParseQuery(query)
+		if _, err := ParseFilter(q); err == nil {
+			t.Errorf("ParseFilter(%q) should fail", query)
+		}
+	}
+}
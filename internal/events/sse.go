@@ -0,0 +1,103 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// keepAliveInterval keeps proxies from closing idle streams
+const keepAliveInterval = 15 * time.Second
+
+// SSEHandler streams events from b as Server-Sent Events. Clients resume with
+// the Last-Event-ID header (or a last_event_id query parameter) and narrow
+// the stream with filter query parameters (see ParseFilter).
+func SSEHandler(b *Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := ParseFilter(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		lastEventID := r.Header.Get("Last-Event-ID")
+		if lastEventID == "" {
+			lastEventID = r.URL.Query().Get("last_event_id")
+		}
+		var lastID uint64
+		if lastEventID != "" {
+			lastID, err = strconv.ParseUint(lastEventID, 10, 64)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid Last-Event-ID %q", lastEventID), http.StatusBadRequest)
+				return
+			}
+		}
+
+		// The server's write timeout would otherwise end the stream
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil && err != http.ErrNotSupported {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		backlog, ch, unsubscribe := b.Subscribe(lastID)
+		defer unsubscribe()
+
+		for _, e := range backlog {
+			if filter.Matches(e) {
+				if err := writeSSE(w, e); err != nil {
+					return
+				}
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		keepAlive := time.NewTicker(keepAliveInterval)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, ok := <-ch:
+				if !ok {
+					return
+				}
+				if !filter.Matches(e) {
+					continue
+				}
+				if err := writeSSE(w, e); err != nil {
+					return
+				}
+			case <-keepAlive.C:
+				if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeSSE writes one event in text/event-stream framing
+func writeSSE(w io.Writer, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSSEHandler(t *testing.T) {
+	b := NewBroker(10)
+	b.Publish(Event{Type: TypeIdleThreshold, Application: "billing", Message: "one"})
+	b.Publish(Event{Type: TypePoolPressure, Message: "two"})
+	b.Publish(Event{Type: TypeIdleThreshold, Application: "billing", Message: "three"})
+
+	server := httptest.NewServer(SSEHandler(b))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"?type=idle_transaction_threshold", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "1")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	// Event 3 is replayed from the buffer; 4 arrives live
+	b.Publish(Event{Type: TypeIdleThreshold, Message: "four"})
+
+	reader := bufio.NewReader(resp.Body)
+	var frames []string
+	var frame strings.Builder
+	for len(frames) < 2 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading stream: %v (frames so far: %q)", err, frames)
+		}
+		if line == "\n" {
+			frames = append(frames, frame.String())
+			frame.Reset()
+			continue
+		}
+		frame.WriteString(line)
+	}
+
+	if !strings.HasPrefix(frames[0], "id: 3\nevent: idle_transaction_threshold\ndata: {") ||
+		!strings.Contains(frames[0], `"message":"three"`) {
+		t.Errorf("first frame = %q", frames[0])
+	}
+	if !strings.HasPrefix(frames[1], "id: 4\n") || !strings.Contains(frames[1], `"message":"four"`) {
+		t.Errorf("second frame = %q", frames[1])
+	}
+}
+
+func TestSSEHandlerBadRequest(t *testing.T) {
+	handler := SSEHandler(NewBroker(1))
+
+	for _, target := range []string{"/?pid=x", "/?last_event_id=abc"} {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, rec.Code)
+		}
+	}
+}
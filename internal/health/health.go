@@ -0,0 +1,207 @@
+// Package health implements the daemon's probes. Liveness only asks whether
+// the poll loop is still turning, so a database outage makes pguard unready
+// but never gets it restarted.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Component statuses reported by /healthz?verbose
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+)
+
+// Component is a dependency checked by /healthz
+type Component struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// ComponentStatus is one entry of the verbose /healthz response
+type ComponentStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Report is the verbose /healthz response
+type Report struct {
+	Status     string            `json:"status"`
+	Components []ComponentStatus `json:"components"`
+}
+
+// Monitor tracks poll loop progress and poll outcomes
+type Monitor struct {
+	mu            sync.Mutex
+	stallAfter    time.Duration
+	readyPolls    int
+	lastProgress  time.Time
+	recent        []error // Outcomes of the last readyPolls polls, oldest first
+	alertingReady bool
+	components    []Component
+	now           func() time.Time
+}
+
+// NewMonitor creates a monitor. The loop counts as stuck once it has made no
+// progress for stallAfter, and as ready once the last readyPolls polls
+// succeeded.
+func NewMonitor(stallAfter time.Duration, readyPolls int) *Monitor {
+	if readyPolls < 1 {
+		readyPolls = 1
+	}
+	m := &Monitor{
+		stallAfter: stallAfter,
+		readyPolls: readyPolls,
+		now:        time.Now,
+	}
+	m.lastProgress = m.now()
+	return m
+}
+
+// Progress records that the poll loop is alive
+func (m *Monitor) Progress() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastProgress = m.now()
+}
+
+// PollDone records the outcome of a poll. It also counts as progress.
+func (m *Monitor) PollDone(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastProgress = m.now()
+	m.recent = append(m.recent, err)
+	if len(m.recent) > m.readyPolls {
+		m.recent = m.recent[len(m.recent)-m.readyPolls:]
+	}
+}
+
+// SetAlertingReady records that notifiers have been set up
+func (m *Monitor) SetAlertingReady() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alertingReady = true
+}
+
+// AddComponent registers a dependency for /healthz
+func (m *Monitor) AddComponent(name string, check func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.components = append(m.components, Component{Name: name, Check: check})
+}
+
+// Live returns an error if the poll loop has stopped making progress
+func (m *Monitor) Live() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if since := m.now().Sub(m.lastProgress); since > m.stallAfter {
+		return fmt.Errorf("poll loop has made no progress for %s", since.Round(time.Second))
+	}
+	return nil
+}
+
+// Ready returns an error unless alerting is set up and the last readyPolls
+// polls succeeded
+func (m *Monitor) Ready() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.alertingReady {
+		return fmt.Errorf("alerting is not initialized")
+	}
+	if len(m.recent) < m.readyPolls {
+		return fmt.Errorf("%d of %d polls completed", len(m.recent), m.readyPolls)
+	}
+	for i := len(m.recent) - 1; i >= 0; i-- {
+		if m.recent[i] != nil {
+			return fmt.Errorf("poll failed: %w", m.recent[i])
+		}
+	}
+	return nil
+}
+
+// Check runs every component check. The poll loop and readiness are
+// reported first, then the registered components in order.
+func (m *Monitor) Check(ctx context.Context) Report {
+	m.mu.Lock()
+	components := append([]Component(nil), m.components...)
+	m.mu.Unlock()
+
+	checks := append([]Component{
+		{Name: "poll_loop", Check: func(context.Context) error { return m.Live() }},
+		{Name: "polls", Check: func(context.Context) error { return m.Ready() }},
+	}, components...)
+
+	report := Report{Status: StatusOK}
+	for _, c := range checks {
+		status := ComponentStatus{Name: c.Name, Status: StatusOK}
+		if err := c.Check(ctx); err != nil {
+			status.Status = StatusFailing
+			status.Error = err.Error()
+			report.Status = StatusFailing
+		}
+		report.Components = append(report.Components, status)
+	}
+	return report
+}
+
+// The plain probes are unauthenticated, so they name the failing component
+// but never include its error, which can contain hosts, users or URLs.
+
+// Livez serves the liveness probe
+func (m *Monitor) Livez(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, "poll_loop", m.Live())
+}
+
+// Readyz serves the readiness probe
+func (m *Monitor) Readyz(w http.ResponseWriter, r *http.Request) {
+	writeProbe(w, "polls", m.Ready())
+}
+
+// Healthz checks every component. With ?verbose it returns the per-component
+// Report, errors included, as JSON; otherwise just "ok" or the name of the
+// first failing component.
+func (m *Monitor) Healthz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	report := m.Check(ctx)
+	code := http.StatusOK
+	if report.Status != StatusOK {
+		code = http.StatusServiceUnavailable
+	}
+
+	if r.URL.Query().Has("verbose") {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(report)
+		return
+	}
+
+	for _, c := range report.Components {
+		if c.Status != StatusOK {
+			writeProbe(w, c.Name, errors.New(c.Error))
+			return
+		}
+	}
+	writeProbe(w, "", nil)
+}
+
+// writeProbe writes "ok", or the failing component and its status
+func writeProbe(w http.ResponseWriter, component string, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "unhealthy: %s %s", component, StatusFailing)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "ok")
+}
@@ -0,0 +1,140 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func testMonitor(readyPolls int) (*Monitor, *time.Time) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMonitor(time.Minute, readyPolls)
+	m.now = func() time.Time { return now }
+	m.lastProgress = now
+	return m, &now
+}
+
+func TestLive(t *testing.T) {
+	m, now := testMonitor(1)
+	if err := m.Live(); err != nil {
+		t.Fatalf("Live() at start = %v", err)
+	}
+
+	*now = now.Add(2 * time.Minute)
+	if err := m.Live(); err == nil {
+		t.Fatal("Live() after a stall = nil, want error")
+	}
+
+	m.Progress()
+	if err := m.Live(); err != nil {
+		t.Errorf("Live() after progress = %v", err)
+	}
+}
+
+func TestReady(t *testing.T) {
+	failed := errors.New("connection refused")
+	tests := []struct {
+		name     string
+		alerting bool
+		polls    []error
+		wantErr  bool
+	}{
+		{"alerting not initialized", false, []error{nil, nil, nil}, true},
+		{"too few polls", true, []error{nil, nil}, true},
+		{"last polls succeeded", true, []error{nil, nil, nil}, false},
+		{"recent failure", true, []error{nil, failed, nil}, true},
+		{"failure aged out", true, []error{failed, nil, nil, nil}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := testMonitor(3)
+			if tt.alerting {
+				m.SetAlertingReady()
+			}
+			for _, err := range tt.polls {
+				m.PollDone(err)
+			}
+			if err := m.Ready(); (err != nil) != tt.wantErr {
+				t.Errorf("Ready() = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHealthzVerbose(t *testing.T) {
+	m, _ := testMonitor(1)
+	m.SetAlertingReady()
+	m.PollDone(nil)
+	m.AddComponent("database", func(context.Context) error { return nil })
+	m.AddComponent("slack", func(context.Context) error { return errors.New("slack returned status 500") })
+
+	rec := httptest.NewRecorder()
+	m.Healthz(rec, httptest.NewRequest(http.MethodGet, "/healthz?verbose", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decoding report: %v", err)
+	}
+	want := map[string]string{
+		"poll_loop": StatusOK,
+		"polls":     StatusOK,
+		"database":  StatusOK,
+		"slack":     StatusFailing,
+	}
+	if len(report.Components) != len(want) {
+		t.Fatalf("got %d components, want %d", len(report.Components), len(want))
+	}
+	for _, c := range report.Components {
+		if c.Status != want[c.Name] {
+			t.Errorf("%s status = %q, want %q", c.Name, c.Status, want[c.Name])
+		}
+	}
+}
+
+func TestProbeHandlers(t *testing.T) {
+	m, _ := testMonitor(1)
+
+	rec := httptest.NewRecorder()
+	m.Livez(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/livez status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	rec = httptest.NewRecorder()
+	m.Readyz(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz before any poll status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestProbesHideErrors(t *testing.T) {
+	m, _ := testMonitor(1)
+	m.SetAlertingReady()
+	m.PollDone(errors.New(`failed to connect to host=db.internal user=pguard`))
+	m.AddComponent("slack/team", func(context.Context) error {
+		return errors.New(`Post "https://hooks.slack.com/services/T0/B0/secret": dial tcp: timeout`)
+	})
+
+	for _, tt := range []struct {
+		path    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{"/readyz", m.Readyz, "unhealthy: polls failing"},
+		{"/healthz", m.Healthz, "unhealthy: polls failing"},
+	} {
+		rec := httptest.NewRecorder()
+		tt.handler(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if body := rec.Body.String(); body != tt.want {
+			t.Errorf("%s body = %q, want %q", tt.path, body, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package owners maps connections to the teams that own them, so alerts and
+// status output can say who to talk to about a session.
+package owners
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Team is one owning team and the connections it claims. A connection
+// belongs to the team if its application, role or client address matches
+// any of the team's patterns.
+type Team struct {
+	Name       string `yaml:"name" json:"name"`
+	Slack      string `yaml:"slack" json:"slack,omitempty"`           // Handle mentioned on critical alerts, e.g. <!subteam^S0123>
+	Escalation string `yaml:"escalation" json:"escalation,omitempty"` // Who to page when the team doesn't respond
+	Runbook    string `yaml:"runbook" json:"runbook,omitempty"`
+
+	Apps  []string `yaml:"apps" json:"-"`  // Glob patterns for application_name
+	Roles []string `yaml:"roles" json:"-"` // Glob patterns for the connecting role
+	CIDRs []string `yaml:"cidrs" json:"-"` // Client networks, e.g. 10.1.0.0/16
+
+	nets []*net.IPNet
+}
+
+// Map is a parsed ownership file. A nil Map owns nothing.
+type Map struct {
+	Teams []*Team `yaml:"teams"`
+}
+
+// Parse reads an ownership file
+func Parse(data []byte) (*Map, error) {
+	var m Map
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing ownership file: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for i, t := range m.Teams {
+		if t.Name == "" {
+			return nil, fmt.Errorf("team %d has no name", i+1)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("duplicate team %q", t.Name)
+		}
+		seen[t.Name] = true
+
+		if len(t.Apps) == 0 && len(t.Roles) == 0 && len(t.CIDRs) == 0 {
+			return nil, fmt.Errorf("team %q needs at least one of apps, roles or cidrs", t.Name)
+		}
+		for _, pattern := range append(append([]string{}, t.Apps...), t.Roles...) {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("team %q: invalid pattern %q", t.Name, pattern)
+			}
+		}
+		for _, cidr := range t.CIDRs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("team %q: invalid CIDR %q", t.Name, cidr)
+			}
+			t.nets = append(t.nets, ipNet)
+		}
+	}
+	return &m, nil
+}
+
+// Load reads an ownership file from disk
+func Load(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading ownership file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Match returns the team owning a connection, or nil. Teams are checked in
+// file order and the first match wins.
+func (m *Map) Match(app, role, clientAddr string) *Team {
+	if m == nil {
+		return nil
+	}
+	ip := net.ParseIP(clientAddr)
+	for _, t := range m.Teams {
+		if t.matches(app, role, ip) {
+			return t
+		}
+	}
+	return nil
+}
+
+// TeamName returns the name of the team owning a connection, or ""
+func (m *Map) TeamName(app, role, clientAddr string) string {
+	if t := m.Match(app, role, clientAddr); t != nil {
+		return t.Name
+	}
+	return ""
+}
+
+func (t *Team) matches(app, role string, ip net.IP) bool {
+	if app != "" && anyGlob(t.Apps, app) {
+		return true
+	}
+	if role != "" && anyGlob(t.Roles, role) {
+		return true
+	}
+	if ip != nil {
+		for _, n := range t.nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func anyGlob(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
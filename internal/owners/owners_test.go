@@ -0,0 +1,75 @@
+package owners
+
+import (
+	"strings"
+	"testing"
+)
+
+const testOwners = `
+teams:
+  - name: payments
+    slack: "<!subteam^S01PAY>"
+    escalation: payments-oncall@example.com
+    runbook: https://wiki.example.com/payments-db
+    apps: ["payment-*", "checkout"]
+  - name: data
+    roles: ["etl_*"]
+    cidrs: ["10.20.0.0/16"]
+  - name: platform
+    apps: ["*"]
+`
+
+func TestMatch(t *testing.T) {
+	m, err := Parse([]byte(testOwners))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name                  string
+		app, role, clientAddr string
+		want                  string
+	}{
+		{"app glob", "payment-api", "app", "10.1.2.3", "payments"},
+		{"exact app", "checkout", "app", "", "payments"},
+		{"role", "", "etl_nightly", "local", "data"},
+		{"cidr", "", "reporting", "10.20.4.5", "data"},
+		{"first team wins", "payment-worker", "etl_x", "10.20.4.5", "payments"},
+		{"catch-all app", "billing", "app", "", "platform"},
+		{"nothing to match", "", "reporting", "local", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.TeamName(tt.app, tt.role, tt.clientAddr); got != tt.want {
+				t.Errorf("TeamName(%q, %q, %q) = %q, want %q", tt.app, tt.role, tt.clientAddr, got, tt.want)
+			}
+		})
+	}
+
+	var none *Map
+	if none.Match("payment-api", "", "") != nil {
+		t.Error("nil map should own nothing")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"no name", "teams: [{apps: [a]}]", "no name"},
+		{"duplicate", "teams: [{name: a, apps: [a]}, {name: a, apps: [b]}]", "duplicate"},
+		{"no matchers", "teams: [{name: a}]", "at least one"},
+		{"bad pattern", "teams: [{name: a, apps: ['[']}]", "invalid pattern"},
+		{"bad cidr", "teams: [{name: a, cidrs: [10.0.0.0/99]}]", "invalid CIDR"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -15,8 +17,9 @@ import (
 
 // Client wraps a PostgreSQL connection pool
 type Client struct {
-	pool *pgxpool.Pool
-	cfg  *config.Config
+	pool    *pgxpool.Pool
+	cfg     *config.Config
+	connCfg *pgx.ConnConfig // Template for standalone connections outside the pool
 }
 
 // NewClient creates a new PostgreSQL client
@@ -41,18 +44,7 @@ func NewClient(cfg *config.Config) (*Client, error) {
 	// For IAM auth, we need to refresh the token before each connection
 	if cfg.Connection.AuthMethod == "iam" {
 		poolCfg.BeforeConnect = func(ctx context.Context, connCfg *pgx.ConnConfig) error {
-			token, tokenErr := GetRDSAuthToken(
-				ctx,
-				cfg.Connection.Host,
-				cfg.Connection.Port,
-				cfg.Connection.User,
-				cfg.Connection.AWSRegion,
-			)
-			if tokenErr != nil {
-				return fmt.Errorf("getting IAM auth token: %w", tokenErr)
-			}
-			connCfg.Password = token
-			return nil
+			return setIAMPassword(ctx, cfg, connCfg)
 		}
 	}
 
@@ -75,7 +67,44 @@ func NewClient(cfg *config.Config) (*Client, error) {
 		return nil, fmt.Errorf("connecting to database: %w", err)
 	}
 
-	return &Client{pool: pool, cfg: cfg}, nil
+	return &Client{pool: pool, cfg: cfg, connCfg: poolCfg.ConnConfig.Copy()}, nil
+}
+
+// setIAMPassword sets a fresh RDS IAM auth token as the connection password
+func setIAMPassword(ctx context.Context, cfg *config.Config, connCfg *pgx.ConnConfig) error {
+	token, err := GetRDSAuthToken(
+		ctx,
+		cfg.Connection.Host,
+		cfg.Connection.Port,
+		cfg.Connection.User,
+		cfg.Connection.AWSRegion,
+	)
+	if err != nil {
+		return fmt.Errorf("getting IAM auth token: %w", err)
+	}
+	connCfg.Password = token
+	return nil
+}
+
+// connect opens a standalone connection outside the pool, to database if it is
+// set or to the configured database otherwise. The caller must close it.
+func (c *Client) connect(ctx context.Context, database string) (*pgx.Conn, error) {
+	connCfg := c.connCfg.Copy()
+	if database != "" {
+		connCfg.Database = database
+	}
+
+	if c.cfg.Connection.AuthMethod == "iam" {
+		if err := setIAMPassword(ctx, c.cfg, connCfg); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := pgx.ConnectConfig(ctx, connCfg)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to database %s: %w", connCfg.Database, err)
+	}
+	return conn, nil
 }
 
 // buildConnectionString creates a connection string based on config
@@ -136,8 +165,9 @@ func (c *Client) GetConnections(ctx context.Context) ([]*Connection, error) {
 		SELECT
 			pid,
 			COALESCE(usename, '') as usename,
+			COALESCE(datname, '') as datname,
 			COALESCE(application_name, '') as application_name,
-			COALESCE(client_addr::text, 'local') as client_addr,
+			COALESCE(host(client_addr), 'local') as client_addr,
 			COALESCE(client_port, 0) as client_port,
 			backend_start,
 			xact_start,
@@ -166,6 +196,7 @@ func (c *Client) GetConnections(ctx context.Context) ([]*Connection, error) {
 		err := rows.Scan(
 			&conn.PID,
 			&conn.Username,
+			&conn.Database,
 			&conn.ApplicationName,
 			&conn.ClientAddr,
 			&conn.ClientPort,
@@ -192,65 +223,154 @@ func (c *Client) GetConnections(ctx context.Context) ([]*Connection, error) {
 	return connections, nil
 }
 
-// GetPoolStats returns aggregate statistics about the connection pool
+// GetPoolStats returns aggregate statistics about the connection pool.
+// Reserved slots are read per server version and platform: PG16 added
+// reserved_connections and RDS reserves slots for rds_superuser members.
 func (c *Client) GetPoolStats(ctx context.Context) (*PoolStats, error) {
 	stats := &PoolStats{}
 
-	// Get max_connections
+	// Settings that do not exist on this version or platform scan as NULL
+	var reserved, rdsReserved *int
 	err := c.pool.QueryRow(ctx, `
-		SELECT setting::int FROM pg_settings WHERE name = 'max_connections'
-	`).Scan(&stats.MaxConnections)
+		SELECT
+			current_setting('server_version_num')::int,
+			current_setting('max_connections')::int,
+			current_setting('superuser_reserved_connections')::int,
+			(SELECT setting::int FROM pg_settings WHERE name = 'reserved_connections'),
+			(SELECT setting::int FROM pg_settings WHERE name = 'rds.rds_superuser_reserved_connections')
+	`).Scan(&stats.ServerVersionNum, &stats.MaxConnections, &stats.ReservedSuperuser, &reserved, &rdsReserved)
 	if err != nil {
-		return nil, fmt.Errorf("getting max_connections: %w", err)
+		return nil, fmt.Errorf("getting connection settings: %w", err)
 	}
-
-	// Get superuser_reserved_connections
-	err = c.pool.QueryRow(ctx, `
-		SELECT setting::int FROM pg_settings WHERE name = 'superuser_reserved_connections'
-	`).Scan(&stats.ReservedSuperuser)
-	if err != nil {
-		return nil, fmt.Errorf("getting superuser_reserved_connections: %w", err)
+	if reserved != nil && stats.ServerVersionNum >= 160000 {
+		stats.ReservedConnections = *reserved
+	}
+	if rdsReserved != nil {
+		stats.IsRDS = true
+		stats.ReservedRDS = *rdsReserved
 	}
 
-	// Get counts by state
+	// Get counts by state, tagging pguard's own and rdsadmin backends
 	rows, err := c.pool.Query(ctx, `
 		SELECT 
 			COALESCE(state, 'unknown') as state,
+			CASE
+				WHEN pid = pg_backend_pid() OR application_name = 'pguard' THEN 'pguard'
+				WHEN usename = 'rdsadmin' THEN 'rdsadmin'
+				ELSE ''
+			END as owner,
 			COUNT(*) as count
 		FROM pg_stat_activity
 		WHERE backend_type = 'client backend'
-		  AND pid != pg_backend_pid()
-		GROUP BY state
+		GROUP BY 1, 2
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("getting connection counts: %w", err)
 	}
 	defer rows.Close()
 
+	poolCfg := c.cfg.Thresholds.ConnectionPool
 	for rows.Next() {
-		var state string
+		var state, owner string
 		var count int
-		if err := rows.Scan(&state, &count); err != nil {
+		if err := rows.Scan(&state, &owner, &count); err != nil {
 			return nil, fmt.Errorf("scanning state count: %w", err)
 		}
 
 		stats.TotalConnections += count
 
+		switch owner {
+		case "pguard":
+			stats.OwnConnections += count
+			if poolCfg.ExcludeOwnConnections {
+				stats.ExcludedConnections += count
+				continue
+			}
+		case "rdsadmin":
+			stats.RDSAdminConnections += count
+			if poolCfg.ExcludeRDSAdmin {
+				stats.ExcludedConnections += count
+				continue
+			}
+		}
+
 		switch ConnectionState(state) {
 		case StateActive:
-			stats.ActiveConnections = count
+			stats.ActiveConnections += count
 		case StateIdle:
-			stats.IdleConnections = count
+			stats.IdleConnections += count
 		case StateIdleInTransaction, StateIdleInTransactionAborted:
 			stats.IdleInTransaction += count
 		}
 	}
 
-	stats.AvailableConnections = stats.MaxConnections - stats.ReservedSuperuser - stats.TotalConnections
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	// Every client backend occupies a slot, excluded or not
+	stats.AvailableConnections = stats.Capacity() - stats.TotalConnections
+	if stats.AvailableConnections < 0 {
+		stats.AvailableConnections = 0
+	}
 
 	return stats, nil
 }
 
+// GetRoleUsage returns connection counts per login role with their rolconnlimit.
+// Roles without connections are only included when they have a limit.
+// Superusers are left out because rolconnlimit does not apply to them.
+func (c *Client) GetRoleUsage(ctx context.Context) ([]*LimitUsage, error) {
+	return c.queryLimitUsage(ctx, "role", `
+		SELECT r.rolname, COUNT(a.pid)::int, r.rolconnlimit
+		FROM pg_roles r
+		LEFT JOIN pg_stat_activity a
+			ON a.usesysid = r.oid AND a.backend_type = 'client backend'
+		WHERE r.rolcanlogin AND NOT r.rolsuper
+		GROUP BY r.rolname, r.rolconnlimit
+		HAVING COUNT(a.pid) > 0 OR r.rolconnlimit >= 0
+		ORDER BY COUNT(a.pid) DESC
+	`)
+}
+
+// GetDatabaseUsage returns connection counts per database with their datconnlimit.
+// Databases without connections are only included when they have a limit.
+func (c *Client) GetDatabaseUsage(ctx context.Context) ([]*LimitUsage, error) {
+	return c.queryLimitUsage(ctx, "database", `
+		SELECT d.datname, COUNT(a.pid)::int, d.datconnlimit
+		FROM pg_database d
+		LEFT JOIN pg_stat_activity a
+			ON a.datid = d.oid AND a.backend_type = 'client backend'
+		WHERE d.datallowconn
+		GROUP BY d.datname, d.datconnlimit
+		HAVING COUNT(a.pid) > 0 OR d.datconnlimit >= 0
+		ORDER BY COUNT(a.pid) DESC
+	`)
+}
+
+func (c *Client) queryLimitUsage(ctx context.Context, scope, query string) ([]*LimitUsage, error) {
+	rows, err := c.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("getting %s connection usage: %w", scope, err)
+	}
+	defer rows.Close()
+
+	var usage []*LimitUsage
+	for rows.Next() {
+		u := &LimitUsage{}
+		if err := rows.Scan(&u.Name, &u.Connections, &u.Limit); err != nil {
+			return nil, fmt.Errorf("scanning %s usage: %w", scope, err)
+		}
+		usage = append(usage, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return usage, nil
+}
+
 // GetServerInfo returns information about the PostgreSQL server
 func (c *Client) GetServerInfo(ctx context.Context) (*ServerInfo, error) {
 	info := &ServerInfo{}
@@ -307,6 +427,518 @@ func (c *Client) GetIdleTransactions(ctx context.Context) ([]*Connection, error)
 	return idle, nil
 }
 
+// GetSessionDetail returns the full detail for one client backend, or an
+// error if no such backend exists. The query text is not truncated.
+func (c *Client) GetSessionDetail(ctx context.Context, pid int) (*SessionDetail, error) {
+	d := &SessionDetail{}
+	var blockedBy []int32
+	// query_id only exists on PG14+, so read it through to_jsonb to stay
+	// compatible with older servers
+	err := c.pool.QueryRow(ctx, `
+		SELECT
+			a.pid,
+			COALESCE(a.usename, ''),
+			COALESCE(a.datname, ''),
+			COALESCE(a.application_name, ''),
+			COALESCE(host(a.client_addr), 'local'),
+			COALESCE(a.client_hostname, ''),
+			COALESCE(a.client_port, 0),
+			a.backend_start,
+			a.xact_start,
+			a.query_start,
+			a.state_change,
+			COALESCE(a.state, 'unknown'),
+			a.wait_event_type,
+			a.wait_event,
+			COALESCE(a.query, ''),
+			COALESCE(a.backend_type, ''),
+			age(a.backend_xid),
+			age(a.backend_xmin),
+			(to_jsonb(a)->>'query_id')::bigint,
+			pg_blocking_pids(a.pid)
+		FROM pg_stat_activity a
+		WHERE a.pid = $1
+		  AND a.backend_type = 'client backend'
+	`, pid).Scan(
+		&d.PID,
+		&d.Username,
+		&d.Database,
+		&d.ApplicationName,
+		&d.ClientAddr,
+		&d.ClientHostname,
+		&d.ClientPort,
+		&d.BackendStart,
+		&d.XactStart,
+		&d.QueryStart,
+		&d.StateChange,
+		&d.State,
+		&d.WaitEventType,
+		&d.WaitEvent,
+		&d.Query,
+		&d.BackendType,
+		&d.XidAge,
+		&d.XminAge,
+		&d.QueryID,
+		&blockedBy,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("no connection found with PID %d", pid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying session %d: %w", pid, err)
+	}
+	for _, b := range blockedBy {
+		d.BlockedBy = append(d.BlockedBy, int(b))
+	}
+
+	rows, err := c.pool.Query(ctx, `
+		SELECT pid
+		FROM pg_stat_activity
+		WHERE $1 = ANY(pg_blocking_pids(pid))
+		ORDER BY pid
+	`, pid)
+	if err != nil {
+		return nil, fmt.Errorf("querying sessions blocked by %d: %w", pid, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var blocked int
+		if err := rows.Scan(&blocked); err != nil {
+			return nil, fmt.Errorf("scanning blocked pid: %w", err)
+		}
+		d.Blocking = append(d.Blocking, blocked)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return d, nil
+}
+
+// GetStatementStats returns the pg_stat_statements entry for a query_id.
+// It returns nil without an error if the extension is not installed or has
+// no entry for the query.
+func (c *Client) GetStatementStats(ctx context.Context, queryID int64) (*StatementStats, error) {
+	var installed bool
+	if err := c.pool.QueryRow(ctx, `SELECT to_regclass('pg_stat_statements') IS NOT NULL`).Scan(&installed); err != nil {
+		return nil, fmt.Errorf("checking for pg_stat_statements: %w", err)
+	}
+	if !installed {
+		return nil, nil
+	}
+
+	st := &StatementStats{QueryID: queryID}
+	var totalMs float64
+	err := c.pool.QueryRow(ctx, `
+		SELECT
+			COALESCE(SUM(calls), 0)::bigint,
+			COALESCE(SUM(total_exec_time), 0),
+			COALESCE(SUM(rows), 0)::bigint,
+			COALESCE(SUM(shared_blks_hit), 0)::bigint,
+			COALESCE(SUM(shared_blks_read), 0)::bigint
+		FROM pg_stat_statements
+		WHERE queryid = $1
+	`, queryID).Scan(&st.Calls, &totalMs, &st.Rows, &st.SharedBlksHit, &st.SharedBlksRead)
+	if err != nil {
+		return nil, fmt.Errorf("querying pg_stat_statements: %w", err)
+	}
+	if st.Calls == 0 {
+		return nil, nil
+	}
+
+	st.TotalExecTime = time.Duration(totalMs * float64(time.Millisecond))
+	st.MeanExecTime = st.TotalExecTime / time.Duration(st.Calls)
+	return st, nil
+}
+
+// GetBlockingPIDs returns, for every backend waiting on a lock, the PIDs
+// of the backends blocking it
+func (c *Client) GetBlockingPIDs(ctx context.Context) (map[int][]int, error) {
+	rows, err := c.pool.Query(ctx, `
+		SELECT pid, pg_blocking_pids(pid)
+		FROM pg_stat_activity
+		WHERE wait_event_type = 'Lock'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying blocking pids: %w", err)
+	}
+	defer rows.Close()
+
+	blockedBy := make(map[int][]int)
+	for rows.Next() {
+		var pid int
+		var blockers []int32
+		if err := rows.Scan(&pid, &blockers); err != nil {
+			return nil, fmt.Errorf("scanning blocking pids: %w", err)
+		}
+		for _, b := range blockers {
+			blockedBy[pid] = append(blockedBy[pid], int(b))
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return blockedBy, nil
+}
+
+// GetLocks returns the locks held or awaited by a backend. Relation names
+// can only be resolved for relations in the database pguard is connected to;
+// others are shown by OID.
+func (c *Client) GetLocks(ctx context.Context, pid int) ([]*Lock, error) {
+	locks, err := c.queryLocks(ctx, "WHERE l.pid = $1", pid)
+	if err != nil {
+		return nil, fmt.Errorf("querying locks for pid %d: %w", pid, err)
+	}
+	return locks, nil
+}
+
+// GetAllLocks returns the locks held or awaited by every client backend
+// except pguard's own
+func (c *Client) GetAllLocks(ctx context.Context) ([]*Lock, error) {
+	locks, err := c.queryLocks(ctx, `
+		JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE a.backend_type = 'client backend' AND l.pid <> pg_backend_pid()`)
+	if err != nil {
+		return nil, fmt.Errorf("querying locks: %w", err)
+	}
+	return locks, nil
+}
+
+func (c *Client) queryLocks(ctx context.Context, where string, args ...interface{}) ([]*Lock, error) {
+	rows, err := c.pool.Query(ctx, `
+		SELECT
+			l.pid,
+			l.locktype,
+			l.mode,
+			l.granted,
+			CASE
+				WHEN l.relation IS NULL THEN ''
+				WHEN l.database = (SELECT oid FROM pg_database WHERE datname = current_database())
+					THEN l.relation::regclass::text
+				ELSE l.relation::text
+			END as relation
+		FROM pg_locks l
+		`+where+`
+		ORDER BY l.pid, l.granted, l.locktype, relation
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locks []*Lock
+	for rows.Next() {
+		l := &Lock{}
+		if err := rows.Scan(&l.PID, &l.LockType, &l.Mode, &l.Granted, &l.Relation); err != nil {
+			return nil, fmt.Errorf("scanning lock: %w", err)
+		}
+		locks = append(locks, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return locks, nil
+}
+
+// BlockedCounts inverts a blocked-by map into the number of backends each PID blocks
+func BlockedCounts(blockedBy map[int][]int) map[int]int {
+	counts := make(map[int]int)
+	for _, blockers := range blockedBy {
+		for _, b := range blockers {
+			counts[b]++
+		}
+	}
+	return counts
+}
+
+// GetXminHorizon returns every session, replication slot and prepared
+// transaction that pins an xmin, ordered from oldest to newest
+func (c *Client) GetXminHorizon(ctx context.Context) (*XminHorizon, error) {
+	query := `
+		SELECT
+			'session' as kind,
+			'' as name,
+			pid,
+			COALESCE(usename, '') as usename,
+			COALESCE(application_name, '') as application_name,
+			COALESCE(datname, '') as datname,
+			COALESCE(state, '') as state,
+			GREATEST(age(backend_xmin), age(backend_xid))::bigint as xmin_age,
+			false as catalog_only,
+			xact_start as since
+		FROM pg_stat_activity
+		WHERE (backend_xmin IS NOT NULL OR backend_xid IS NOT NULL)
+		  AND pid != pg_backend_pid()
+		UNION ALL
+		SELECT
+			'replication_slot', slot_name, COALESCE(active_pid, 0), '', '',
+			COALESCE(database, ''), '', age(xmin)::bigint, false, NULL
+		FROM pg_replication_slots
+		WHERE xmin IS NOT NULL
+		UNION ALL
+		SELECT
+			'replication_slot', slot_name, COALESCE(active_pid, 0), '', '',
+			COALESCE(database, ''), '', age(catalog_xmin)::bigint, true, NULL
+		FROM pg_replication_slots
+		WHERE catalog_xmin IS NOT NULL
+		UNION ALL
+		SELECT
+			'prepared_transaction', gid, 0, owner, '',
+			database, '', age(transaction)::bigint, false, prepared
+		FROM pg_prepared_xacts
+		ORDER BY xmin_age DESC
+	`
+
+	rows, err := c.pool.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("querying xmin horizon: %w", err)
+	}
+	defer rows.Close()
+
+	horizon := &XminHorizon{}
+	for rows.Next() {
+		h := &XminHolder{}
+		var kind, state string
+		err := rows.Scan(
+			&kind,
+			&h.Name,
+			&h.PID,
+			&h.Username,
+			&h.ApplicationName,
+			&h.Database,
+			&state,
+			&h.XminAge,
+			&h.CatalogOnly,
+			&h.Since,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning xmin holder: %w", err)
+		}
+		h.Kind = XminHolderKind(kind)
+		h.State = ConnectionState(state)
+		horizon.Holders = append(horizon.Holders, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return horizon, nil
+}
+
+// GetReplicationSlots returns all replication slots with the WAL they retain.
+// On a standby, retained WAL is measured against the last received LSN.
+func (c *Client) GetReplicationSlots(ctx context.Context) ([]*ReplicationSlot, error) {
+	rows, err := c.pool.Query(ctx, `
+		SELECT
+			slot_name,
+			COALESCE(plugin, '') as plugin,
+			slot_type,
+			COALESCE(database, '') as database,
+			active,
+			COALESCE(active_pid, 0) as active_pid,
+			COALESCE(pg_wal_lsn_diff(
+				CASE WHEN pg_is_in_recovery() THEN pg_last_wal_receive_lsn() ELSE pg_current_wal_lsn() END,
+				restart_lsn
+			), 0)::bigint as retained_bytes,
+			COALESCE(GREATEST(age(xmin), age(catalog_xmin)), 0)::bigint as xmin_age
+		FROM pg_replication_slots
+		ORDER BY retained_bytes DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying pg_replication_slots: %w", err)
+	}
+	defer rows.Close()
+
+	var slots []*ReplicationSlot
+	for rows.Next() {
+		s := &ReplicationSlot{}
+		err := rows.Scan(
+			&s.Name,
+			&s.Plugin,
+			&s.SlotType,
+			&s.Database,
+			&s.Active,
+			&s.ActivePID,
+			&s.RetainedBytes,
+			&s.XminAge,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning replication slot: %w", err)
+		}
+		slots = append(slots, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return slots, nil
+}
+
+// GetPreparedTransactions returns all two-phase transactions in pg_prepared_xacts
+func (c *Client) GetPreparedTransactions(ctx context.Context) ([]*PreparedTransaction, error) {
+	rows, err := c.pool.Query(ctx, `
+		SELECT
+			gid,
+			transaction::text,
+			owner,
+			database,
+			prepared,
+			age(transaction)::bigint
+		FROM pg_prepared_xacts
+		ORDER BY prepared
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying pg_prepared_xacts: %w", err)
+	}
+	defer rows.Close()
+
+	var prepared []*PreparedTransaction
+	for rows.Next() {
+		p := &PreparedTransaction{}
+		if err := rows.Scan(&p.GID, &p.Transaction, &p.Owner, &p.Database, &p.Prepared, &p.XminAge); err != nil {
+			return nil, fmt.Errorf("scanning prepared transaction: %w", err)
+		}
+		prepared = append(prepared, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return prepared, nil
+}
+
+// RollbackPrepared rolls back a prepared transaction. ROLLBACK PREPARED must run
+// in the database the transaction was prepared in, so a dedicated connection
+// to that database is opened when it differs from the configured one.
+func (c *Client) RollbackPrepared(ctx context.Context, gid, database string) error {
+	if strings.ContainsRune(gid, 0) {
+		return fmt.Errorf("invalid GID")
+	}
+
+	conn, err := c.connect(ctx, database)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	// ROLLBACK PREPARED takes no bind parameters, so the server quotes the
+	// GID itself rather than relying on standard_conforming_strings
+	var stmt string
+	if err := conn.QueryRow(ctx, "SELECT format('ROLLBACK PREPARED %L', $1::text)", gid).Scan(&stmt); err != nil {
+		return fmt.Errorf("quoting GID: %w", err)
+	}
+	if _, err := conn.Exec(ctx, stmt); err != nil {
+		return fmt.Errorf("rolling back prepared transaction %s: %w", gid, err)
+	}
+	return nil
+}
+
+// GetRoleInfo returns the current role and its role memberships
+func (c *Client) GetRoleInfo(ctx context.Context) (*RoleInfo, error) {
+	info := &RoleInfo{}
+	err := c.pool.QueryRow(ctx, `
+		SELECT
+			current_user,
+			rolsuper,
+			COALESCE(ARRAY(
+				SELECT m.rolname
+				FROM pg_roles m
+				WHERE m.rolname <> current_user
+				  AND pg_has_role(current_user, m.oid, 'MEMBER')
+				ORDER BY m.rolname
+			), '{}')
+		FROM pg_roles
+		WHERE rolname = current_user
+	`).Scan(&info.Name, &info.Superuser, &info.Memberships)
+	if err != nil {
+		return nil, fmt.Errorf("querying role info: %w", err)
+	}
+	return info, nil
+}
+
+// GetActivityVisibility counts other roles' sessions and how many of them
+// hide their query from pguard
+func (c *Client) GetActivityVisibility(ctx context.Context) (*ActivityVisibility, error) {
+	v := &ActivityVisibility{}
+	err := c.pool.QueryRow(ctx, `
+		SELECT
+			count(*),
+			count(*) FILTER (WHERE query = '<insufficient privilege>')
+		FROM pg_stat_activity
+		WHERE backend_type = 'client backend'
+		  AND usename IS DISTINCT FROM current_user
+	`).Scan(&v.OtherSessions, &v.Hidden)
+	if err != nil {
+		return nil, fmt.Errorf("querying pg_stat_activity visibility: %w", err)
+	}
+	return v, nil
+}
+
+// GetSettings returns the named settings from pg_settings. Settings the
+// server doesn't know are omitted from the result.
+func (c *Client) GetSettings(ctx context.Context, names ...string) (map[string]*Setting, error) {
+	rows, err := c.pool.Query(ctx, `
+		SELECT name, setting, COALESCE(unit, '')
+		FROM pg_settings
+		WHERE name = ANY($1)
+	`, names)
+	if err != nil {
+		return nil, fmt.Errorf("querying settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]*Setting)
+	for rows.Next() {
+		s := &Setting{}
+		if err := rows.Scan(&s.Name, &s.Setting, &s.Unit); err != nil {
+			return nil, fmt.Errorf("scanning setting: %w", err)
+		}
+		settings[s.Name] = s
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating rows: %w", err)
+	}
+
+	return settings, nil
+}
+
+// TestTerminate opens a throwaway backend and terminates it through the pool,
+// proving the monitoring role can signal backends end to end
+func (c *Client) TestTerminate(ctx context.Context) error {
+	conn, err := c.connect(ctx, "")
+	if err != nil {
+		return fmt.Errorf("spawning test backend: %w", err)
+	}
+	defer conn.Close(context.Background())
+
+	var pid int
+	if err := conn.QueryRow(ctx, `SELECT pg_backend_pid()`).Scan(&pid); err != nil {
+		return fmt.Errorf("reading test backend pid: %w", err)
+	}
+	if _, err := conn.Exec(ctx, `SET application_name = 'pguard-doctor'`); err != nil {
+		return fmt.Errorf("labeling test backend: %w", err)
+	}
+
+	success, err := c.TerminateBackend(ctx, pid)
+	if err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("pg_terminate_backend(%d) returned false", pid)
+	}
+	return nil
+}
+
 // TestConnection tests if we can connect and query the database
 func TestConnection(connString string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -236,3 +236,22 @@ func TestBuildConnectionString_ConnectTimeout(t *testing.T) {
 		t.Errorf("connection string should contain connect_timeout=30, got %s", connStr)
 	}
 }
+
+func TestBlockedCounts(t *testing.T) {
+	blockedBy := map[int][]int{
+		10: {1},
+		11: {1, 2},
+		12: {1},
+	}
+
+	got := BlockedCounts(blockedBy)
+	if got[1] != 3 {
+		t.Errorf("BlockedCounts()[1] = %d, want 3", got[1])
+	}
+	if got[2] != 1 {
+		t.Errorf("BlockedCounts()[2] = %d, want 1", got[2])
+	}
+	if got[10] != 0 {
+		t.Errorf("BlockedCounts()[10] = %d, want 0", got[10])
+	}
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error classes reported when polling fails
+const (
+	ErrorClassAuth       = "auth"
+	ErrorClassPermission = "permission"
+	ErrorClassTimeout    = "timeout"
+	ErrorClassNetwork    = "network"
+	ErrorClassOther      = "other"
+)
+
+// ClassifyError groups a query or connection error by its likely cause
+func ClassifyError(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == "28000" || pgErr.Code == "28P01": // invalid_authorization_specification, invalid_password
+			return ErrorClassAuth
+		case pgErr.Code == "42501": // insufficient_privilege
+			return ErrorClassPermission
+		case pgErr.Code == "57014": // query_canceled, e.g. statement_timeout
+			return ErrorClassTimeout
+		case strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "57P"): // connection exceptions, shutdowns
+			return ErrorClassNetwork
+		}
+		return ErrorClassOther
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || pgconn.Timeout(err) {
+		return ErrorClassTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+
+	var connectErr *pgconn.ConnectError
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	if errors.As(err, &connectErr) || errors.As(err, &opErr) || errors.As(err, &dnsErr) {
+		return ErrorClassNetwork
+	}
+	return ErrorClassOther
+}
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"bad password", &pgconn.PgError{Code: "28P01"}, ErrorClassAuth},
+		{"no pg_hba entry", fmt.Errorf("connecting: %w", &pgconn.PgError{Code: "28000"}), ErrorClassAuth},
+		{"insufficient privilege", &pgconn.PgError{Code: "42501"}, ErrorClassPermission},
+		{"statement timeout", &pgconn.PgError{Code: "57014"}, ErrorClassTimeout},
+		{"admin shutdown", &pgconn.PgError{Code: "57P01"}, ErrorClassNetwork},
+		{"connection failure", &pgconn.PgError{Code: "08006"}, ErrorClassNetwork},
+		{"other SQL error", &pgconn.PgError{Code: "42P01"}, ErrorClassOther},
+		{"context deadline", fmt.Errorf("query: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{"connection refused", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrorClassNetwork},
+		{"dns", &net.DNSError{Err: "no such host", Name: "db.internal"}, ErrorClassNetwork},
+		{"plain error", errors.New("boom"), ErrorClassOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ConnectionFilter selects connections by attribute. Zero-valued fields match
+// everything; all set fields must match.
+type ConnectionFilter struct {
+	App            string // Glob pattern for application_name
+	User           string // Glob pattern for usename
+	Database       string // Glob pattern for datname
+	ClientCIDR     *net.IPNet
+	State          ConnectionState
+	IdleLongerThan time.Duration // Minimum time in the current state
+	QueryMatches   *regexp.Regexp
+}
+
+// ParseConnectionState accepts a pg_stat_activity state, allowing '-' or '_'
+// in place of spaces (e.g. idle-in-transaction)
+func ParseConnectionState(s string) (ConnectionState, error) {
+	normalized := strings.ToLower(strings.NewReplacer("-", " ", "_", " ").Replace(strings.TrimSpace(s)))
+	switch ConnectionState(normalized) {
+	case StateActive, StateIdle, StateIdleInTransaction, StateIdleInTransactionAborted, StateFastpath, StateDisabled:
+		return ConnectionState(normalized), nil
+	case "idle in transaction aborted":
+		return StateIdleInTransactionAborted, nil
+	}
+	return "", fmt.Errorf("unknown connection state %q", s)
+}
+
+// ParseCIDR parses a CIDR or a bare IP address, which is treated as a single host
+func ParseCIDR(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", s)
+		}
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q: %w", s, err)
+	}
+	return network, nil
+}
+
+// IsEmpty returns true if no criteria are set
+func (f *ConnectionFilter) IsEmpty() bool {
+	return f.App == "" && f.User == "" && f.Database == "" && f.ClientCIDR == nil &&
+		f.State == "" && f.IdleLongerThan == 0 && f.QueryMatches == nil
+}
+
+// Matches returns true if conn satisfies every criterion in the filter
+func (f *ConnectionFilter) Matches(conn *Connection) bool {
+	if f.App != "" && !globMatch(f.App, conn.ApplicationName) {
+		return false
+	}
+	if f.User != "" && !globMatch(f.User, conn.Username) {
+		return false
+	}
+	if f.Database != "" && !globMatch(f.Database, conn.Database) {
+		return false
+	}
+	if f.ClientCIDR != nil {
+		addr, _, _ := strings.Cut(conn.ClientAddr, "/")
+		ip := net.ParseIP(addr)
+		if ip == nil || !f.ClientCIDR.Contains(ip) {
+			return false
+		}
+	}
+	if f.State != "" && conn.State != f.State {
+		return false
+	}
+	if f.IdleLongerThan > 0 && conn.IdleDuration() < f.IdleLongerThan {
+		return false
+	}
+	if f.QueryMatches != nil && !f.QueryMatches.MatchString(conn.Query) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the connections matching f
+func (f *ConnectionFilter) Filter(conns []*Connection) []*Connection {
+	var result []*Connection
+	for _, conn := range conns {
+		if f.Matches(conn) {
+			result = append(result, conn)
+		}
+	}
+	return result
+}
+
+func globMatch(pattern, s string) bool {
+	ok, err := filepath.Match(pattern, s)
+	return err == nil && ok
+}
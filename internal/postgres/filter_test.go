@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestConnectionFilter_Matches(t *testing.T) {
+	now := time.Now()
+	conn := &Connection{
+		PID:             100,
+		ApplicationName: "payment-api",
+		Username:        "payments",
+		Database:        "shop",
+		ClientAddr:      "10.0.3.17",
+		State:           StateIdleInTransaction,
+		StateChange:     now.Add(-10 * time.Minute),
+		Query:           "SELECT * FROM accounts FOR UPDATE",
+	}
+
+	cidr, err := ParseCIDR("10.0.3.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCIDR, err := ParseCIDR("10.0.4.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		filter ConnectionFilter
+		want   bool
+	}{
+		{"empty filter", ConnectionFilter{}, true},
+		{"app glob", ConnectionFilter{App: "payment-*"}, true},
+		{"app mismatch", ConnectionFilter{App: "billing-*"}, false},
+		{"user exact", ConnectionFilter{User: "payments"}, true},
+		{"database mismatch", ConnectionFilter{Database: "billing"}, false},
+		{"client in cidr", ConnectionFilter{ClientCIDR: cidr}, true},
+		{"client outside cidr", ConnectionFilter{ClientCIDR: otherCIDR}, false},
+		{"state", ConnectionFilter{State: StateIdleInTransaction}, true},
+		{"state mismatch", ConnectionFilter{State: StateActive}, false},
+		{"idle longer than", ConnectionFilter{IdleLongerThan: 5 * time.Minute}, true},
+		{"not idle long enough", ConnectionFilter{IdleLongerThan: time.Hour}, false},
+		{"query regex", ConnectionFilter{QueryMatches: regexp.MustCompile(`FOR UPDATE`)}, true},
+		{"all criteria", ConnectionFilter{App: "payment-*", ClientCIDR: cidr, State: StateIdleInTransaction}, true},
+		{"one criterion fails", ConnectionFilter{App: "payment-*", User: "admin"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Matches(conn); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConnectionFilter_LocalClient(t *testing.T) {
+	cidr, _ := ParseCIDR("0.0.0.0/0")
+	f := ConnectionFilter{ClientCIDR: cidr}
+	if f.Matches(&Connection{ClientAddr: "local"}) {
+		t.Error("expected unix socket connection not to match a CIDR")
+	}
+}
+
+func TestParseConnectionState(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    ConnectionState
+		wantErr bool
+	}{
+		{"active", StateActive, false},
+		{"idle-in-transaction", StateIdleInTransaction, false},
+		{"idle_in_transaction", StateIdleInTransaction, false},
+		{"Idle In Transaction", StateIdleInTransaction, false},
+		{"idle-in-transaction-aborted", StateIdleInTransactionAborted, false},
+		{"sleeping", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseConnectionState(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseConnectionState(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseConnectionState(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseCIDR(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{"10.0.0.0/8", "10.0.0.0/8", false},
+		{"192.168.1.5", "192.168.1.5/32", false},
+		{"::1", "::1/128", false},
+		{"not-an-ip", "", true},
+		{"10.0.0.0/99", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCIDR(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCIDR(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got.String() != tt.want {
+			t.Errorf("ParseCIDR(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
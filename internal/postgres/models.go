@@ -1,6 +1,8 @@
 package postgres
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -20,6 +22,7 @@ const (
 type Connection struct {
 	PID             int
 	Username        string
+	Database        string
 	ApplicationName string
 	ClientAddr      string
 	ClientPort      int
@@ -52,24 +55,196 @@ func (c *Connection) IsIdleInTransaction() bool {
 	return c.State == StateIdleInTransaction || c.State == StateIdleInTransactionAborted
 }
 
+// Lock is a single entry from pg_locks
+type Lock struct {
+	PID      int
+	LockType string // relation, transactionid, tuple, advisory, ...
+	Mode     string // e.g. AccessShareLock, RowExclusiveLock
+	Granted  bool   // false if the backend is waiting for this lock
+	Relation string // Relation name for relation-level locks, empty otherwise
+}
+
+// SessionDetail is everything pguard can learn about a single backend
+type SessionDetail struct {
+	Connection
+	ClientHostname string
+	XidAge         *int64 // age(backend_xid); nil if no xid assigned
+	XminAge        *int64 // age(backend_xmin); nil if no snapshot held
+	QueryID        *int64 // pg_stat_activity.query_id (PG14+, compute_query_id)
+	BlockedBy      []int  // PIDs this backend is waiting on
+	Blocking       []int  // PIDs waiting on this backend
+}
+
+// BackendDuration returns how long the backend has been connected
+func (s *SessionDetail) BackendDuration() time.Duration {
+	return time.Since(s.BackendStart)
+}
+
+// StatementStats is the pg_stat_statements summary for one query_id,
+// aggregated across users and databases
+type StatementStats struct {
+	QueryID        int64
+	Calls          int64
+	TotalExecTime  time.Duration
+	MeanExecTime   time.Duration
+	Rows           int64
+	SharedBlksHit  int64
+	SharedBlksRead int64
+}
+
 // PoolStats contains aggregate statistics about the connection pool
 type PoolStats struct {
+	ServerVersionNum     int // server_version_num, e.g. 160002
+	IsRDS                bool
 	MaxConnections       int
-	ReservedSuperuser    int
-	TotalConnections     int
+	ReservedSuperuser    int // superuser_reserved_connections
+	ReservedConnections  int // reserved_connections (PG16+, for pg_use_reserved_connections)
+	ReservedRDS          int // rds.rds_superuser_reserved_connections (RDS only)
+	TotalConnections     int // All client backends, including excluded ones
 	ActiveConnections    int
 	IdleConnections      int
 	IdleInTransaction    int
-	AvailableConnections int
+	AvailableConnections int // Slots a normal role can still get
+	OwnConnections       int // pguard's own backends
+	RDSAdminConnections  int // Backends of the rdsadmin user
+	ExcludedConnections  int // Own and rdsadmin backends excluded from usage, where configured
+}
+
+// Capacity returns the number of slots available to normal roles, i.e.
+// max_connections minus every class of reserved slot
+func (p *PoolStats) Capacity() int {
+	capacity := p.MaxConnections - p.ReservedSuperuser - p.ReservedConnections - p.ReservedRDS
+	if capacity < 0 {
+		return 0
+	}
+	return capacity
+}
+
+// UsedConnections returns the connections counted towards usage
+func (p *PoolStats) UsedConnections() int {
+	return p.TotalConnections - p.ExcludedConnections
 }
 
 // UsagePercent returns the percentage of connections in use
 func (p *PoolStats) UsagePercent() float64 {
-	available := p.MaxConnections - p.ReservedSuperuser
+	available := p.Capacity()
 	if available <= 0 {
 		return 100
 	}
-	return float64(p.TotalConnections) / float64(available) * 100
+	return float64(p.UsedConnections()) / float64(available) * 100
+}
+
+// PreparedTransaction is a two-phase transaction from pg_prepared_xacts
+type PreparedTransaction struct {
+	GID         string
+	Transaction string // Transaction ID (xid)
+	Owner       string
+	Database    string
+	Prepared    time.Time
+	XminAge     int64 // age(transaction) in transactions
+}
+
+// Age returns how long ago the transaction was prepared
+func (p *PreparedTransaction) Age() time.Duration {
+	return time.Since(p.Prepared)
+}
+
+// ReplicationSlot is a physical or logical slot from pg_replication_slots
+type ReplicationSlot struct {
+	Name          string
+	Plugin        string // Output plugin (logical slots only)
+	SlotType      string // "physical" or "logical"
+	Database      string // Empty for physical slots
+	Active        bool
+	ActivePID     int
+	RetainedBytes int64 // WAL between the current position and restart_lsn
+	XminAge       int64 // Oldest of age(xmin) and age(catalog_xmin), 0 if neither is set
+}
+
+// LimitUsage is the connection count of a single role or database
+// against its own limit (rolconnlimit or datconnlimit)
+type LimitUsage struct {
+	Name        string
+	Connections int
+	Limit       int // -1 means no limit
+}
+
+// HasLimit returns true if a connection limit is set
+func (l *LimitUsage) HasLimit() bool {
+	return l.Limit >= 0
+}
+
+// UsagePercent returns the percentage of the limit in use, or 0 without a limit.
+// A limit of 0 locks the role or database out, so it is only saturated when
+// something is connected anyway.
+func (l *LimitUsage) UsagePercent() float64 {
+	if !l.HasLimit() {
+		return 0
+	}
+	if l.Limit == 0 {
+		if l.Connections > 0 {
+			return 100
+		}
+		return 0
+	}
+	return float64(l.Connections) / float64(l.Limit) * 100
+}
+
+// RoleInfo describes the role pguard is connected as
+type RoleInfo struct {
+	Name        string
+	Superuser   bool
+	Memberships []string // Roles this role is a member of, directly or indirectly
+}
+
+// IsMemberOf returns true if the role is a member of role (superusers are members of everything)
+func (r *RoleInfo) IsMemberOf(role string) bool {
+	if r.Superuser {
+		return true
+	}
+	for _, m := range r.Memberships {
+		if m == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ActivityVisibility summarizes how much of pg_stat_activity pguard can see
+type ActivityVisibility struct {
+	OtherSessions int // Client backends owned by other roles
+	Hidden        int // Of those, sessions whose query shows <insufficient privilege>
+}
+
+// Setting is a single row from pg_settings
+type Setting struct {
+	Name    string
+	Setting string
+	Unit    string
+}
+
+// Duration interprets a time-based setting. Returns 0 for disabled (0) or
+// non-time settings.
+func (s *Setting) Duration() time.Duration {
+	n, err := strconv.ParseInt(s.Setting, 10, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	switch s.Unit {
+	case "ms":
+		return time.Duration(n) * time.Millisecond
+	case "s":
+		return time.Duration(n) * time.Second
+	case "min":
+		return time.Duration(n) * time.Minute
+	}
+	return 0
+}
+
+// Int interprets a numeric setting, returning 0 if it is not a number
+func (s *Setting) Int() int {
+	n, _ := strconv.Atoi(s.Setting)
+	return n
 }
 
 // ServerInfo contains basic information about the PostgreSQL server
@@ -78,3 +253,74 @@ type ServerInfo struct {
 	ServerStart    time.Time
 	MaxConnections int
 }
+
+// XminHolderKind identifies what kind of object is pinning an xmin
+type XminHolderKind string
+
+const (
+	XminHolderSession         XminHolderKind = "session"
+	XminHolderReplicationSlot XminHolderKind = "replication_slot"
+	XminHolderPreparedXact    XminHolderKind = "prepared_transaction"
+)
+
+// XminHolder is a single session, replication slot or prepared transaction
+// that holds back the cluster's xmin horizon
+type XminHolder struct {
+	Kind            XminHolderKind
+	Name            string // Slot name or prepared transaction GID (empty for sessions)
+	PID             int    // Backend PID; walsender PID for active slots, 0 otherwise
+	Username        string
+	ApplicationName string
+	Database        string
+	State           ConnectionState
+	XminAge         int64      // age(xmin) in transactions
+	CatalogOnly     bool       // Slot catalog_xmin: only blocks vacuum of system catalogs
+	Since           *time.Time // Transaction start or prepare time, if known
+}
+
+// Description returns a short human-readable identifier for the holder
+func (h *XminHolder) Description() string {
+	switch h.Kind {
+	case XminHolderSession:
+		if h.ApplicationName != "" {
+			return fmt.Sprintf("PID %d (%s)", h.PID, h.ApplicationName)
+		}
+		return fmt.Sprintf("PID %d", h.PID)
+	case XminHolderReplicationSlot:
+		if h.CatalogOnly {
+			return fmt.Sprintf("replication slot %s (catalog_xmin)", h.Name)
+		}
+		return fmt.Sprintf("replication slot %s", h.Name)
+	case XminHolderPreparedXact:
+		return fmt.Sprintf("prepared transaction %s", h.Name)
+	default:
+		return string(h.Kind)
+	}
+}
+
+// XminHorizon lists every xmin holder in the cluster, oldest first
+type XminHorizon struct {
+	Holders []*XminHolder
+}
+
+// Oldest returns the single holder that determines the horizon, or nil if
+// nothing is holding it back. Catalog-only slot holders are only considered
+// when no regular holder exists, because they do not block vacuum of user tables.
+func (x *XminHorizon) Oldest() *XminHolder {
+	var oldest, oldestCatalog *XminHolder
+	for _, h := range x.Holders {
+		if h.CatalogOnly {
+			if oldestCatalog == nil || h.XminAge > oldestCatalog.XminAge {
+				oldestCatalog = h
+			}
+			continue
+		}
+		if oldest == nil || h.XminAge > oldest.XminAge {
+			oldest = h
+		}
+	}
+	if oldest != nil {
+		return oldest
+	}
+	return oldestCatalog
+}
@@ -99,6 +99,36 @@ func TestPoolStatsUsagePercent(t *testing.T) {
 			},
 			want: 0.0,
 		},
+		{
+			name: "PG16 reserved_connections reduce capacity",
+			stats: PoolStats{
+				MaxConnections:      100,
+				ReservedSuperuser:   3,
+				ReservedConnections: 17,
+				TotalConnections:    40,
+			},
+			want: 50.0, // 40 / (100-3-17)
+		},
+		{
+			name: "RDS reserved slots and excluded backends",
+			stats: PoolStats{
+				MaxConnections:      105,
+				ReservedSuperuser:   3,
+				ReservedRDS:         2,
+				TotalConnections:    53,
+				ExcludedConnections: 3,
+			},
+			want: 50.0, // (53-3) / (105-3-2)
+		},
+		{
+			name: "reservations exceed max_connections",
+			stats: PoolStats{
+				MaxConnections:    3,
+				ReservedSuperuser: 3,
+				ReservedRDS:       2,
+			},
+			want: 100.0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -110,3 +140,178 @@ func TestPoolStatsUsagePercent(t *testing.T) {
 		})
 	}
 }
+
+func TestXminHorizonOldest(t *testing.T) {
+	tests := []struct {
+		name     string
+		holders  []*XminHolder
+		wantKind XminHolderKind
+		wantAge  int64
+		wantNil  bool
+	}{
+		{
+			name:    "no holders",
+			holders: nil,
+			wantNil: true,
+		},
+		{
+			name: "oldest session wins",
+			holders: []*XminHolder{
+				{Kind: XminHolderSession, PID: 1, XminAge: 500},
+				{Kind: XminHolderSession, PID: 2, XminAge: 9000},
+				{Kind: XminHolderPreparedXact, Name: "gid-1", XminAge: 100},
+			},
+			wantKind: XminHolderSession,
+			wantAge:  9000,
+		},
+		{
+			name: "prepared transaction older than sessions",
+			holders: []*XminHolder{
+				{Kind: XminHolderSession, PID: 1, XminAge: 500},
+				{Kind: XminHolderPreparedXact, Name: "gid-1", XminAge: 70000},
+			},
+			wantKind: XminHolderPreparedXact,
+			wantAge:  70000,
+		},
+		{
+			name: "catalog-only slot ignored when regular holder exists",
+			holders: []*XminHolder{
+				{Kind: XminHolderReplicationSlot, Name: "cdc", XminAge: 1000000, CatalogOnly: true},
+				{Kind: XminHolderSession, PID: 1, XminAge: 500},
+			},
+			wantKind: XminHolderSession,
+			wantAge:  500,
+		},
+		{
+			name: "catalog-only slot used as fallback",
+			holders: []*XminHolder{
+				{Kind: XminHolderReplicationSlot, Name: "cdc", XminAge: 1000000, CatalogOnly: true},
+			},
+			wantKind: XminHolderReplicationSlot,
+			wantAge:  1000000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			horizon := &XminHorizon{Holders: tt.holders}
+			got := horizon.Oldest()
+			if tt.wantNil {
+				if got != nil {
+					t.Errorf("Oldest() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("Oldest() = nil, want holder")
+			}
+			if got.Kind != tt.wantKind || got.XminAge != tt.wantAge {
+				t.Errorf("Oldest() = %s/%d, want %s/%d", got.Kind, got.XminAge, tt.wantKind, tt.wantAge)
+			}
+		})
+	}
+}
+
+func TestXminHolderDescription(t *testing.T) {
+	tests := []struct {
+		holder XminHolder
+		want   string
+	}{
+		{XminHolder{Kind: XminHolderSession, PID: 42, ApplicationName: "payment-api"}, "PID 42 (payment-api)"},
+		{XminHolder{Kind: XminHolderSession, PID: 42}, "PID 42"},
+		{XminHolder{Kind: XminHolderReplicationSlot, Name: "cdc"}, "replication slot cdc"},
+		{XminHolder{Kind: XminHolderReplicationSlot, Name: "cdc", CatalogOnly: true}, "replication slot cdc (catalog_xmin)"},
+		{XminHolder{Kind: XminHolderPreparedXact, Name: "tx-1"}, "prepared transaction tx-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.holder.Description(); got != tt.want {
+				t.Errorf("Description() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimitUsagePercent(t *testing.T) {
+	tests := []struct {
+		name      string
+		usage     LimitUsage
+		wantLimit bool
+		want      float64
+	}{
+		{"no limit", LimitUsage{Connections: 50, Limit: -1}, false, 0},
+		{"half of limit", LimitUsage{Connections: 10, Limit: 20}, true, 50},
+		{"at limit", LimitUsage{Connections: 20, Limit: 20}, true, 100},
+		{"zero limit, locked out", LimitUsage{Connections: 0, Limit: 0}, true, 0},
+		{"zero limit, connected", LimitUsage{Connections: 1, Limit: 0}, true, 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.usage.HasLimit(); got != tt.wantLimit {
+				t.Errorf("HasLimit() = %v, want %v", got, tt.wantLimit)
+			}
+			if got := tt.usage.UsagePercent(); got != tt.want {
+				t.Errorf("UsagePercent() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolStatsCapacity(t *testing.T) {
+	stats := PoolStats{
+		MaxConnections:      100,
+		ReservedSuperuser:   3,
+		ReservedConnections: 5,
+		ReservedRDS:         2,
+		TotalConnections:    12,
+		ExcludedConnections: 4,
+	}
+
+	if got := stats.Capacity(); got != 90 {
+		t.Errorf("Capacity() = %d, want 90", got)
+	}
+	if got := stats.UsedConnections(); got != 8 {
+		t.Errorf("UsedConnections() = %d, want 8", got)
+	}
+
+	negative := PoolStats{MaxConnections: 2, ReservedSuperuser: 3}
+	if got := negative.Capacity(); got != 0 {
+		t.Errorf("Capacity() = %d, want 0 when reservations exceed max", got)
+	}
+}
+
+func TestSettingDuration(t *testing.T) {
+	tests := []struct {
+		setting Setting
+		want    time.Duration
+	}{
+		{Setting{Setting: "0", Unit: "ms"}, 0},
+		{Setting{Setting: "30000", Unit: "ms"}, 30 * time.Second},
+		{Setting{Setting: "60", Unit: "s"}, time.Minute},
+		{Setting{Setting: "5", Unit: "min"}, 5 * time.Minute},
+		{Setting{Setting: "on", Unit: ""}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := tt.setting.Duration(); got != tt.want {
+			t.Errorf("Duration(%s%s) = %s, want %s", tt.setting.Setting, tt.setting.Unit, got, tt.want)
+		}
+	}
+}
+
+func TestRoleInfoIsMemberOf(t *testing.T) {
+	role := &RoleInfo{Name: "pguard", Memberships: []string{"pg_monitor"}}
+	if !role.IsMemberOf("pg_monitor") {
+		t.Error("expected membership in pg_monitor")
+	}
+	if role.IsMemberOf("pg_signal_backend") {
+		t.Error("unexpected membership in pg_signal_backend")
+	}
+
+	super := &RoleInfo{Name: "postgres", Superuser: true}
+	if !super.IsMemberOf("pg_signal_backend") {
+		t.Error("superuser should count as a member of every role")
+	}
+}
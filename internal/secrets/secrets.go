@@ -175,6 +175,11 @@ func ResolvePassword(ctx context.Context, authMethod, password, passwordSecret,
 
 // ResolveWebhookSecret retrieves a webhook URL from Secrets Manager
 func ResolveWebhookSecret(ctx context.Context, secretARN, region string) (string, error) {
+	return ResolveSecretString(ctx, secretARN, region)
+}
+
+// ResolveSecretString retrieves a secret's string value from Secrets Manager
+func ResolveSecretString(ctx context.Context, secretARN, region string) (string, error) {
 	if secretARN == "" {
 		return "", nil
 	}
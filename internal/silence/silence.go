@@ -0,0 +1,248 @@
+// Package silence quiets alerts about known issues for a while without
+// changing the config. Silences are kept in the daemon's state store.
+package silence
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/alerts"
+	"github.com/v0xg/pg-idle-guard/internal/state"
+)
+
+// stateKey is the state store section holding silences
+const stateKey = "silences"
+
+// retainExpired is how long expired silences stay listed before they are dropped
+const retainExpired = 7 * 24 * time.Hour
+
+// ErrNotFound is returned for an unknown silence ID
+var ErrNotFound = errors.New("silence not found")
+
+// Silence suppresses alerts matching every set matcher until ExpiresAt
+type Silence struct {
+	ID string `json:"id"`
+
+	App         string `json:"app,omitempty"`  // Glob pattern for application_name
+	User        string `json:"user,omitempty"` // Glob pattern
+	PID         int    `json:"pid,omitempty"`
+	SessionID   string `json:"session_id,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"` // Query fingerprint
+	Target      string `json:"target,omitempty"`      // Glob pattern for connection.name
+
+	Comment    string    `json:"comment"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Suppressed int64     `json:"suppressed"` // Alerts silenced; saved with the next change
+}
+
+// Validate checks that the silence matches something and says why
+func (s *Silence) Validate() error {
+	if s.App == "" && s.User == "" && s.PID == 0 && s.SessionID == "" && s.Fingerprint == "" && s.Target == "" {
+		return errors.New("a silence needs at least one of app, user, pid, session_id, fingerprint or target")
+	}
+	if s.PID < 0 {
+		return fmt.Errorf("invalid pid %d", s.PID)
+	}
+	for _, pattern := range []string{s.App, s.User, s.Target} {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q", pattern)
+		}
+	}
+	if s.Comment == "" {
+		return errors.New("a silence needs a comment")
+	}
+	if s.CreatedBy == "" {
+		return errors.New("a silence needs a creator")
+	}
+	return nil
+}
+
+// Active reports whether the silence is in effect at now
+func (s *Silence) Active(now time.Time) bool {
+	return now.Before(s.ExpiresAt)
+}
+
+// Matches reports whether the alert satisfies every set matcher
+func (s *Silence) Matches(a alerts.Attributes) bool {
+	if s.PID != 0 && s.PID != a.PID {
+		return false
+	}
+	if s.SessionID != "" && s.SessionID != a.SessionID {
+		return false
+	}
+	if s.Fingerprint != "" && s.Fingerprint != a.Fingerprint {
+		return false
+	}
+	return globMatch(s.App, a.Application) && globMatch(s.User, a.User) && globMatch(s.Target, a.Target)
+}
+
+// Manager holds the silences and counts the alerts they suppress. It is
+// safe for concurrent use by the poll loop and API handlers.
+type Manager struct {
+	store *state.Store
+
+	mu       sync.Mutex
+	silences []*Silence
+	counts   map[string]int64 // Suppressed alerts by kind since startup
+}
+
+// NewManager loads silences from store. With a nil store silences are kept
+// in memory only.
+func NewManager(store *state.Store) (*Manager, error) {
+	m := &Manager{store: store, counts: make(map[string]int64)}
+	if store != nil {
+		if err := store.Get(stateKey, &m.silences); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Add validates and saves a new silence, returning it with its ID set
+func (m *Manager) Add(s Silence, now time.Time) (Silence, error) {
+	s.CreatedAt = now.UTC()
+	if err := s.Validate(); err != nil {
+		return Silence{}, err
+	}
+	if !s.ExpiresAt.After(now) {
+		return Silence{}, errors.New("expiry must be in the future")
+	}
+	s.ExpiresAt = s.ExpiresAt.UTC()
+	s.Suppressed = 0
+
+	id, err := newID()
+	if err != nil {
+		return Silence{}, err
+	}
+	s.ID = id
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.silences = append(m.silences, &s)
+	return s, m.save(now)
+}
+
+// Expire ends a silence now
+func (m *Manager) Expire(id string, now time.Time) (Silence, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.silences {
+		if s.ID != id {
+			continue
+		}
+		if s.Active(now) {
+			s.ExpiresAt = now.UTC()
+		}
+		return *s, m.save(now)
+	}
+	return Silence{}, ErrNotFound
+}
+
+// List returns the active silences, soonest to expire first. With all set
+// it includes recently expired ones.
+func (m *Manager) List(all bool, now time.Time) []Silence {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := make([]Silence, 0, len(m.silences))
+	for _, s := range m.silences {
+		if all || s.Active(now) {
+			result = append(result, *s)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].ExpiresAt.Equal(result[j].ExpiresAt) {
+			return result[i].ExpiresAt.Before(result[j].ExpiresAt)
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// Silenced returns the first active silence matching the alert, or nil, and
+// counts the alert as suppressed
+func (m *Manager) Silenced(a alerts.Attributes, now time.Time) *Silence {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, s := range m.silences {
+		if s.Active(now) && s.Matches(a) {
+			s.Suppressed++
+			m.counts[a.Kind]++
+			match := *s
+			return &match
+		}
+	}
+	return nil
+}
+
+// Counts returns the alerts suppressed since startup, by kind
+func (m *Manager) Counts() map[string]int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[string]int64, len(m.counts))
+	for kind, n := range m.counts {
+		counts[kind] = n
+	}
+	return counts
+}
+
+// Active returns the number of silences in effect
+func (m *Manager) Active(now time.Time) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := 0
+	for _, s := range m.silences {
+		if s.Active(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// save drops long-expired silences and writes the rest to the store. The
+// caller holds m.mu.
+func (m *Manager) save(now time.Time) error {
+	kept := m.silences[:0]
+	for _, s := range m.silences {
+		if now.Sub(s.ExpiresAt) < retainExpired {
+			kept = append(kept, s)
+		}
+	}
+	m.silences = kept
+
+	if m.store == nil {
+		return nil
+	}
+	if err := m.store.Put(stateKey, m.silences); err != nil {
+		return fmt.Errorf("saving silences: %w", err)
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating silence ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func globMatch(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, err := filepath.Match(pattern, s)
+	return err == nil && ok
+}
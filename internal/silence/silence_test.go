@@ -0,0 +1,151 @@
+package silence
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v0xg/pg-idle-guard/internal/alerts"
+	"github.com/v0xg/pg-idle-guard/internal/state"
+)
+
+func TestSilenceMatches(t *testing.T) {
+	alert := alerts.Attributes{
+		Kind:        alerts.KindIdleTransaction,
+		Application: "payment-api",
+		User:        "payments",
+		Target:      "orders-prod",
+		PID:         4242,
+		SessionID:   "4242-1700000000000000",
+		Fingerprint: "a1b2c3d4e5f60718",
+	}
+
+	tests := []struct {
+		name    string
+		silence Silence
+		want    bool
+	}{
+		{"app glob", Silence{App: "payment-*"}, true},
+		{"other app", Silence{App: "billing"}, false},
+		{"user", Silence{User: "payments"}, true},
+		{"pid", Silence{PID: 4242}, true},
+		{"other pid", Silence{PID: 1}, false},
+		{"session", Silence{SessionID: "4242-1700000000000000"}, true},
+		{"reused pid", Silence{SessionID: "4242-1"}, false},
+		{"fingerprint", Silence{Fingerprint: "a1b2c3d4e5f60718"}, true},
+		{"target", Silence{Target: "orders-*"}, true},
+		{"every matcher must hold", Silence{App: "payment-*", Target: "staging"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.silence.Matches(alert); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A target-only silence covers alerts that aren't about a connection
+	pool := alerts.Attributes{Kind: alerts.KindConnectionPool, Target: "orders-prod"}
+	if !(&Silence{Target: "orders-prod"}).Matches(pool) {
+		t.Error("target silence should match pool alerts")
+	}
+	if (&Silence{App: "payment-*"}).Matches(pool) {
+		t.Error("app silence should not match pool alerts")
+	}
+}
+
+func TestManager(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := state.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	s, err := m.Add(Silence{
+		App:       "payment-api",
+		Comment:   "known leak, fix in PAY-123",
+		CreatedBy: "alice",
+		ExpiresAt: now.Add(2 * time.Hour),
+	}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.ID == "" || !s.CreatedAt.Equal(now) {
+		t.Errorf("Add() = %+v, want an ID and CreatedAt", s)
+	}
+
+	idle := alerts.Attributes{Kind: alerts.KindIdleTransaction, Application: "payment-api"}
+	if got := m.Silenced(idle, now.Add(time.Hour)); got == nil || got.ID != s.ID {
+		t.Errorf("Silenced() = %v, want %s", got, s.ID)
+	}
+	if m.Silenced(alerts.Attributes{Kind: alerts.KindIdleTransaction, Application: "billing"}, now) != nil {
+		t.Error("unrelated alert was silenced")
+	}
+	if m.Silenced(idle, now.Add(3*time.Hour)) != nil {
+		t.Error("expired silence still applies")
+	}
+	if counts := m.Counts(); counts[alerts.KindIdleTransaction] != 1 {
+		t.Errorf("Counts() = %v, want 1 idle_transaction", counts)
+	}
+
+	// Silences survive a restart
+	reopened, err := state.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := NewManager(reopened)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list := m2.List(false, now); len(list) != 1 || list[0].CreatedBy != "alice" {
+		t.Fatalf("reloaded silences = %+v", list)
+	}
+
+	expired, err := m2.Expire(s.ID, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired.Active(now.Add(time.Minute)) {
+		t.Error("silence still active after Expire()")
+	}
+	if len(m2.List(false, now.Add(time.Minute))) != 0 || len(m2.List(true, now.Add(time.Minute))) != 1 {
+		t.Error("expired silence should only be listed with all")
+	}
+	if _, err := m2.Expire("nope", now); err != ErrNotFound {
+		t.Errorf("Expire() of unknown ID = %v, want ErrNotFound", err)
+	}
+}
+
+func TestManagerAddValidation(t *testing.T) {
+	m, _ := NewManager(nil)
+	now := time.Now()
+	valid := Silence{App: "x", Comment: "c", CreatedBy: "me", ExpiresAt: now.Add(time.Hour)}
+
+	tests := []struct {
+		name   string
+		modify func(*Silence)
+		want   string
+	}{
+		{"no matchers", func(s *Silence) { s.App = "" }, "at least one"},
+		{"no comment", func(s *Silence) { s.Comment = "" }, "comment"},
+		{"no creator", func(s *Silence) { s.CreatedBy = "" }, "creator"},
+		{"bad pattern", func(s *Silence) { s.App = "[" }, "invalid pattern"},
+		{"already expired", func(s *Silence) { s.ExpiresAt = now.Add(-time.Minute) }, "future"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+			_, err := m.Add(s, now)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Add() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Package state persists the daemon's runtime state, such as silences, in a
+// JSON file so it survives restarts. Each feature keeps its data under its
+// own key.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/v0xg/pg-idle-guard/internal/util"
+)
+
+// Store is a JSON file of named sections. It is safe for concurrent use.
+type Store struct {
+	path string
+
+	mu       sync.Mutex
+	sections map[string]json.RawMessage
+	lastErr  error
+}
+
+// Open reads the state file at path. A missing file is an empty store; it
+// is created on the first Put.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, sections: make(map[string]json.RawMessage)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading state file: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.sections); err != nil {
+		return nil, fmt.Errorf("parsing state file %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Path returns the state file's path
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Get decodes the section stored under key into v. v is left untouched if
+// there is no such section.
+func (s *Store) Get(key string, v interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	raw, ok := s.sections[key]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("decoding state %q: %w", key, err)
+	}
+	return nil
+}
+
+// Put stores v under key and writes the file
+func (s *Store) Put(key string, v interface{}) error {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding state %q: %w", key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sections[key] = raw
+	s.lastErr = s.write()
+	return s.lastErr
+}
+
+// Health returns the error from the last write, or nil
+func (s *Store) Health() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastErr
+}
+
+func (s *Store) write() error {
+	data, err := json.MarshalIndent(s.sections, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state file: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	if err := util.WriteFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("writing state file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open() on a missing file: %v", err)
+	}
+	var missing []string
+	if err := s.Get("absent", &missing); err != nil || missing != nil {
+		t.Fatalf("Get() of a missing key = %v, %v; want nil, nil", missing, err)
+	}
+
+	if err := s.Put("names", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("count", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Health(); err != nil {
+		t.Errorf("Health() = %v after a good write", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var count int
+	if err := reopened.Get("names", &names); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Get("count", &count); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[1] != "b" || count != 3 {
+		t.Errorf("reopened state = %v, %d; want [a b], 3", names, count)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("state file mode = %o, want 600", perm)
+	}
+}
+
+func TestOpenCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Open() of a corrupt file should fail")
+	}
+}
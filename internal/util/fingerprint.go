@@ -0,0 +1,32 @@
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+var (
+	fingerprintStrings = regexp.MustCompile(`'(?:[^']|'')*'`)
+	fingerprintNumbers = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	fingerprintParams  = regexp.MustCompile(`\$\d+`)
+	fingerprintLists   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+)
+
+// QueryFingerprint identifies a query by its shape: literals and parameters
+// are replaced, whitespace and case are normalized, and the result is hashed.
+// Queries that differ only in their values share a fingerprint.
+func QueryFingerprint(query string) string {
+	if strings.TrimSpace(query) == "" {
+		return ""
+	}
+	normalized := strings.ToLower(query)
+	normalized = fingerprintStrings.ReplaceAllString(normalized, "?")
+	normalized = fingerprintParams.ReplaceAllString(normalized, "?")
+	normalized = fingerprintNumbers.ReplaceAllString(normalized, "?")
+	normalized = strings.Join(strings.Fields(normalized), " ")
+	normalized = fingerprintLists.ReplaceAllString(normalized, "(?)")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:8])
+}
@@ -4,14 +4,15 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // Truncate shortens a string to maxLen characters, adding "..." if truncated
 func Truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if utf8.RuneCountInString(s) <= maxLen {
 		return s
 	}
-	return s[:maxLen-3] + "..."
+	return string([]rune(s)[:maxLen-3]) + "..."
 }
 
 // TruncateQuery truncates a query string, first normalizing whitespace
@@ -39,3 +40,17 @@ func FormatDuration(d time.Duration) string {
 	m := int(d.Minutes()) % 60
 	return fmt.Sprintf("%dh %dm", h, m)
 }
+
+// FormatBytes formats a byte count using binary units (KB, MB, GB, TB)
+func FormatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(b)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,259 @@
+// pguard dashboard. Polls the API for pool and session state and follows
+// /events. fetch is used instead of EventSource so the token can be sent.
+"use strict";
+
+const REFRESH_MS = 5000;
+const MAX_EVENTS = 100;
+
+let token = sessionStorage.getItem("pguard-token") || "";
+let canAct = false;
+let lastEventID = "";
+let streamAbort = null;
+
+function headers(extra) {
+  const h = Object.assign({}, extra);
+  if (token) {
+    h["Authorization"] = "Bearer " + token;
+  }
+  return h;
+}
+
+async function api(path, options) {
+  const resp = await fetch(path, Object.assign({}, options, { headers: headers(options && options.headers) }));
+  const body = await resp.json().catch(() => ({}));
+  if (!resp.ok) {
+    throw new Error(body.error || resp.status + " " + resp.statusText);
+  }
+  return body;
+}
+
+function showError(err) {
+  const el = document.getElementById("error");
+  el.hidden = !err;
+  el.textContent = err ? String(err.message || err) : "";
+}
+
+function cell(text, className) {
+  const td = document.createElement("td");
+  td.textContent = text;
+  if (className) {
+    td.className = className;
+  }
+  return td;
+}
+
+function formatDuration(seconds) {
+  seconds = Math.floor(seconds);
+  if (seconds < 60) return seconds + "s";
+  if (seconds < 3600) return Math.floor(seconds / 60) + "m " + (seconds % 60) + "s";
+  return Math.floor(seconds / 3600) + "h " + Math.floor((seconds % 3600) / 60) + "m";
+}
+
+function actionButton(label, onClick) {
+  const button = document.createElement("button");
+  button.textContent = label;
+  button.disabled = !canAct;
+  button.addEventListener("click", onClick);
+  return button;
+}
+
+async function act(path, body, description) {
+  if (!confirm(description + "?")) {
+    return;
+  }
+  try {
+    const result = await api(path, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: body ? JSON.stringify(body) : undefined,
+    });
+    if (!result.success) {
+      alert("PID " + result.pid + " was already gone.");
+    }
+    showError(null);
+  } catch (err) {
+    showError(err);
+  }
+  refresh();
+}
+
+async function loadIdentity() {
+  try {
+    const me = await api("/api/v1/whoami");
+    canAct = me.role === "operator";
+    document.getElementById("identity").textContent = me.auth_enabled
+      ? "Signed in as " + me.name + " (" + me.role + ")"
+      : "API authentication is disabled";
+  } catch (err) {
+    canAct = false;
+    document.getElementById("identity").textContent = "Not signed in";
+    throw err;
+  }
+}
+
+function renderPool(pool) {
+  const percent = Math.min(pool.pool.usage_percent, 100);
+  const bar = document.getElementById("pool-bar");
+  bar.style.width = percent.toFixed(1) + "%";
+  bar.className = pool.status;
+
+  const badge = document.getElementById("pool-status");
+  badge.textContent = pool.status;
+  badge.className = "badge " + pool.status;
+
+  document.getElementById("pool-detail").textContent =
+    pool.pool.used_connections + " / " + pool.pool.capacity + " used (" + pool.pool.usage_percent.toFixed(0) + "%), " +
+    pool.pool.active_connections + " active, " + pool.pool.idle_in_transaction + " idle in transaction. " +
+    "Warning at " + pool.thresholds.pool_warning_percent + "%, critical at " + pool.thresholds.pool_critical_percent + "%.";
+}
+
+function renderIdle(rows) {
+  const tbody = document.getElementById("idle");
+  tbody.replaceChildren();
+  for (const row of rows) {
+    const tr = document.createElement("tr");
+    tr.className = row.severity;
+    tr.append(
+      cell(row.pid),
+      cell(row.application),
+      cell(row.team || ""),
+      cell(row.user),
+      cell(row.database),
+      cell(formatDuration(row.duration_seconds)),
+      cell((row.blocking || []).join(", ")),
+      cell(row.query, "query"),
+    );
+    const actions = cell("", "actions");
+    actions.append(
+      actionButton("Cancel", () => act("/api/v1/connections/" + row.pid + "/cancel", { session_id: row.session_id }, "Cancel the query of PID " + row.pid)),
+      actionButton("Terminate", () => act("/api/v1/connections/" + row.pid + "/terminate", { session_id: row.session_id }, "Terminate PID " + row.pid)),
+    );
+    tr.append(actions);
+    tbody.append(tr);
+  }
+  if (rows.length === 0) {
+    const tr = document.createElement("tr");
+    tr.append(cell("No idle transactions", "muted"));
+    tbody.append(tr);
+  }
+}
+
+function renderApprovals(rows) {
+  const tbody = document.getElementById("approvals");
+  tbody.replaceChildren();
+  for (const row of rows) {
+    const tr = document.createElement("tr");
+    tr.append(
+      cell(row.pid),
+      cell(row.application),
+      cell(row.user),
+      cell(formatDuration(row.duration_seconds)),
+      cell(new Date(row.pending_since).toLocaleTimeString()),
+      cell(row.query, "query"),
+    );
+    const actions = cell("", "actions");
+    actions.append(actionButton("Approve termination", () =>
+      act("/api/v1/approvals/" + encodeURIComponent(row.session_id) + "/approve", null, "Terminate protected PID " + row.pid)));
+    tr.append(actions);
+    tbody.append(tr);
+  }
+  if (rows.length === 0) {
+    const tr = document.createElement("tr");
+    tr.append(cell("Nothing waiting", "muted"));
+    tbody.append(tr);
+  }
+}
+
+function addEvent(event) {
+  const list = document.getElementById("events");
+  const li = document.createElement("li");
+  const time = document.createElement("span");
+  time.className = "time";
+  time.textContent = new Date(event.time).toLocaleTimeString();
+  li.append(time, (event.severity ? "[" + event.severity + "] " : "") + event.message);
+  list.prepend(li);
+  while (list.children.length > MAX_EVENTS) {
+    list.lastChild.remove();
+  }
+}
+
+// followEvents reads the SSE stream, reconnecting with Last-Event-ID so
+// nothing in the daemon's buffer is missed
+async function followEvents() {
+  if (streamAbort) {
+    streamAbort.abort();
+  }
+  const abort = new AbortController();
+  streamAbort = abort;
+
+  while (!abort.signal.aborted) {
+    try {
+      const h = headers(lastEventID ? { "Last-Event-ID": lastEventID } : {});
+      const resp = await fetch("/events", { headers: h, signal: abort.signal });
+      if (!resp.ok) {
+        throw new Error("/events: " + resp.status);
+      }
+      const reader = resp.body.pipeThrough(new TextDecoderStream()).getReader();
+      let buffer = "";
+      for (;;) {
+        const { value, done } = await reader.read();
+        if (done) break;
+        buffer += value;
+        let end;
+        while ((end = buffer.indexOf("\n\n")) >= 0) {
+          const frame = buffer.slice(0, end);
+          buffer = buffer.slice(end + 2);
+          let data = "";
+          for (const line of frame.split("\n")) {
+            if (line.startsWith("id: ")) lastEventID = line.slice(4);
+            if (line.startsWith("data: ")) data += line.slice(6);
+          }
+          if (data) addEvent(JSON.parse(data));
+        }
+      }
+    } catch (err) {
+      if (abort.signal.aborted) return;
+    }
+    await new Promise((resolve) => setTimeout(resolve, REFRESH_MS));
+  }
+}
+
+async function refresh() {
+  try {
+    const [pool, idle, pending] = await Promise.all([
+      api("/api/v1/pool"),
+      api("/api/v1/idle-transactions"),
+      api("/api/v1/approvals"),
+    ]);
+    renderPool(pool);
+    renderIdle(idle);
+    renderApprovals(pending);
+    showError(null);
+  } catch (err) {
+    showError(err);
+  }
+}
+
+async function start() {
+  try {
+    await loadIdentity();
+  } catch (err) {
+    showError(err);
+    return;
+  }
+  document.getElementById("events").replaceChildren();
+  lastEventID = "";
+  refresh();
+  followEvents();
+}
+
+document.getElementById("token-form").addEventListener("submit", (e) => {
+  e.preventDefault();
+  token = document.getElementById("token").value.trim();
+  sessionStorage.setItem("pguard-token", token);
+  document.getElementById("token").value = "";
+  start();
+});
+
+setInterval(refresh, REFRESH_MS);
+start();
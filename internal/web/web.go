@@ -0,0 +1,31 @@
+// Package web serves the daemon's built-in dashboard. The assets are
+// embedded, so the binary has no files to ship alongside it.
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Prefix is the path the dashboard is served under
+const Prefix = "/ui/"
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the dashboard assets under Prefix. The page itself holds no
+// data; it calls the API with the token the user enters.
+func Handler() http.Handler {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // The embedded directory is fixed at build time
+	}
+	files := http.StripPrefix(Prefix, http.FileServer(http.FS(assets)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})
+}
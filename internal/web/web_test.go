@@ -0,0 +1,45 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(Prefix, Handler())
+
+	tests := []struct {
+		path        string
+		wantStatus  int
+		wantType    string
+		wantContent string
+	}{
+		{"/ui/", http.StatusOK, "text/html", `<script src="app.js">`},
+		{"/ui/app.js", http.StatusOK, "javascript", "/api/v1/whoami"},
+		{"/ui/style.css", http.StatusOK, "text/css", ""},
+		{"/ui/missing.js", http.StatusNotFound, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Security-Policy"); !strings.Contains(got, "default-src 'self'") {
+				t.Errorf("Content-Security-Policy = %q", got)
+			}
+			if tt.wantType != "" && !strings.Contains(rec.Header().Get("Content-Type"), tt.wantType) {
+				t.Errorf("Content-Type = %q, want %q", rec.Header().Get("Content-Type"), tt.wantType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantContent) {
+				t.Errorf("body does not contain %q", tt.wantContent)
+			}
+		})
+	}
+}